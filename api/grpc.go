@@ -0,0 +1,222 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/infraboard/mcube/exception"
+	"github.com/infraboard/mcube/logger"
+	"github.com/infraboard/mcube/logger/zap"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/infraboard/keyauth/api/pb"
+	"github.com/infraboard/keyauth/conf"
+	"github.com/infraboard/keyauth/pkg"
+	"github.com/infraboard/keyauth/pkg/permission"
+	"github.com/infraboard/keyauth/pkg/token"
+)
+
+const (
+	// TokenMetadataKey gRPC调用时通过该metadata传递访问令牌
+	TokenMetadataKey = "x-oauth-token"
+)
+
+var (
+	// 错误详情ErrorInfo中的Domain, 构建服务时使用配置的应用名称
+	errorDomain = "keyauth"
+)
+
+// NewGRPCService 构建函数
+func NewGRPCService() *GRPCService {
+	server := grpc.NewServer()
+	pb.RegisterTokenServiceServer(server, &tokenServer{})
+	pb.RegisterPermissionServiceServer(server, &permissionServer{})
+	pb.RegisterEndpointServiceServer(server, &endpointServer{})
+
+	errorDomain = conf.C().App.Name
+	return &GRPCService{
+		server: server,
+		l:      zap.L().Named("GRPC"),
+		c:      conf.C(),
+	}
+}
+
+// GRPCService grpc服务
+type GRPCService struct {
+	server *grpc.Server
+	l      logger.Logger
+	c      *conf.Config
+}
+
+// Start 启动服务
+func (s *GRPCService) Start() error {
+	addr := s.c.App.GRPCAddr()
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("listen grpc address %s error, %s", addr, err)
+	}
+
+	s.l.Infof("GRPC服务启动成功, 监听地址: %s", addr)
+	return s.Serve(lis)
+}
+
+// Serve 在指定的listener上提供服务
+func (s *GRPCService) Serve(lis net.Listener) error {
+	if err := s.server.Serve(lis); err != nil {
+		if err == grpc.ErrServerStopped {
+			s.l.Info("service is stopped")
+			return nil
+		}
+		return fmt.Errorf("start grpc service error, %s", err.Error())
+	}
+	return nil
+}
+
+// Stop 停止server, 等待处理中的请求完成
+func (s *GRPCService) Stop() error {
+	s.l.Info("start graceful shutdown")
+	s.server.GracefulStop()
+	return nil
+}
+
+// 从metadata中获取访问令牌并校验
+func getTokenFromGRPC(ctx context.Context) (*token.Token, error) {
+	if pkg.Token == nil {
+		return nil, exception.NewInternalServerError("token service is nil")
+	}
+
+	accessToken := getAccessTokenFromGRPC(ctx)
+	if accessToken == "" {
+		return nil, exception.NewUnauthorized("%s required in metadata", TokenMetadataKey)
+	}
+
+	req := token.NewValidateTokenRequest()
+	req.AccessToken = accessToken
+	return pkg.Token.ValidateToken(req)
+}
+
+func getAccessTokenFromGRPC(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+
+	if v := md.Get(TokenMetadataKey); len(v) > 0 {
+		return v[0]
+	}
+
+	// 兼容标准的Bearer认证头
+	if v := md.Get("authorization"); len(v) > 0 {
+		return strings.TrimPrefix(v[0], "Bearer ")
+	}
+
+	return ""
+}
+
+func getRemoteIPFromGRPC(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+
+	return host
+}
+
+func getUserAgentFromGRPC(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+
+	if v := md.Get("user-agent"); len(v) > 0 {
+		return v[0]
+	}
+
+	return ""
+}
+
+// 将业务异常转换为gRPC状态码, 拒绝访问时通过ErrorInfo携带拒绝原因
+func toGRPCError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if de, ok := permission.IsDenyError(err); ok {
+		st := status.New(codes.PermissionDenied, de.Error())
+		info := &errdetails.ErrorInfo{
+			Reason: de.DenyReason,
+			Domain: errorDomain,
+		}
+		if ds, err := st.WithDetails(info); err == nil {
+			st = ds
+		}
+		return st.Err()
+	}
+
+	// 需要多因素认证时, 通过ErrorInfo返回挑战ID
+	if me, ok := token.IsMFARequired(err); ok {
+		st := status.New(codes.Unauthenticated, me.Error())
+		info := &errdetails.ErrorInfo{
+			Reason:   me.ErrorType,
+			Domain:   errorDomain,
+			Metadata: map[string]string{"challenge_id": me.ChallengeID},
+		}
+		if ds, err := st.WithDetails(info); err == nil {
+			st = ds
+		}
+		return st.Err()
+	}
+
+	// 密码过期时需要先修改密码, 通过ErrorInfo返回原因
+	if token.IsPasswordExpired(err) {
+		st := status.New(codes.FailedPrecondition, err.Error())
+		info := &errdetails.ErrorInfo{
+			Reason: token.ErrPasswordExpired,
+			Domain: errorDomain,
+		}
+		if ds, err := st.WithDetails(info); err == nil {
+			st = ds
+		}
+		return st.Err()
+	}
+
+	e, ok := err.(exception.APIException)
+	if !ok {
+		return status.Error(codes.Internal, err.Error())
+	}
+
+	var code codes.Code
+	switch e.ErrorCode() {
+	case exception.BadRequest:
+		code = codes.InvalidArgument
+	case exception.Unauthorized,
+		exception.AccessTokenIllegal,
+		exception.RefreshTokenIllegal,
+		exception.OtherClientsLoggedIn,
+		exception.AccessTokenExpired,
+		exception.RefreshTokenExpired:
+		code = codes.Unauthenticated
+	case exception.Forbidden:
+		code = codes.PermissionDenied
+	case exception.NotFound:
+		code = codes.NotFound
+	case exception.Conflict:
+		code = codes.AlreadyExists
+	default:
+		code = codes.Internal
+	}
+
+	return status.Error(code, e.Error())
+}
@@ -0,0 +1,80 @@
+package api
+
+import (
+	"context"
+
+	"github.com/infraboard/mcube/http/router"
+
+	"github.com/infraboard/keyauth/api/pb"
+	"github.com/infraboard/keyauth/pkg"
+	"github.com/infraboard/keyauth/pkg/endpoint"
+)
+
+type endpointServer struct {
+	pb.UnimplementedEndpointServiceServer
+}
+
+func (s *endpointServer) Registry(ctx context.Context, in *pb.RegistryRequest) (*pb.RegistryResponse, error) {
+	tk, err := getTokenFromGRPC(ctx)
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+
+	entries := make([]*router.Entry, 0, len(in.Entries))
+	for i := range in.Entries {
+		entries = append(entries, entryFromPB(in.Entries[i]))
+	}
+
+	req := endpoint.NewRegistryRequest(in.Version, entries)
+	req.WithToken(tk)
+	if err := pkg.Endpoint.Registry(req); err != nil {
+		return nil, toGRPCError(err)
+	}
+
+	return &pb.RegistryResponse{}, nil
+}
+
+func (s *endpointServer) DescribeEndpoint(ctx context.Context, in *pb.DescribeEndpointRequest) (*pb.Endpoint, error) {
+	if _, err := getTokenFromGRPC(ctx); err != nil {
+		return nil, toGRPCError(err)
+	}
+
+	req := endpoint.NewDescribeEndpointRequestWithID(in.Id)
+	ep, err := pkg.Endpoint.DescribeEndpoint(req)
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+
+	return &pb.Endpoint{
+		Id:       ep.ID,
+		CreateAt: ep.CreateAt.Timestamp(),
+		UpdateAt: ep.UpdateAt.Timestamp(),
+		Service:  ep.Service,
+		Version:  ep.Version,
+		Entry:    entryToPB(&ep.Entry),
+	}, nil
+}
+
+func entryFromPB(e *pb.Entry) *router.Entry {
+	return &router.Entry{
+		Path:             e.Path,
+		Method:           e.Method,
+		FunctionName:     e.FunctionName,
+		Resource:         e.Resource,
+		AuthEnable:       e.AuthEnable,
+		PermissionEnable: e.PermissionEnable,
+		Labels:           e.Labels,
+	}
+}
+
+func entryToPB(e *router.Entry) *pb.Entry {
+	return &pb.Entry{
+		Path:             e.Path,
+		Method:           e.Method,
+		FunctionName:     e.FunctionName,
+		Resource:         e.Resource,
+		AuthEnable:       e.AuthEnable,
+		PermissionEnable: e.PermissionEnable,
+		Labels:           e.Labels,
+	}
+}
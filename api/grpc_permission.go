@@ -0,0 +1,99 @@
+package api
+
+import (
+	"context"
+
+	"github.com/infraboard/keyauth/api/pb"
+	"github.com/infraboard/keyauth/pkg"
+	"github.com/infraboard/keyauth/pkg/permission"
+	"github.com/infraboard/keyauth/pkg/role"
+)
+
+type permissionServer struct {
+	pb.UnimplementedPermissionServiceServer
+}
+
+func (s *permissionServer) CheckPermission(ctx context.Context, in *pb.CheckPermissionRequest) (*pb.Decision, error) {
+	tk, err := getTokenFromGRPC(ctx)
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+
+	req := permission.NewCheckPermissionrequest()
+	req.NamespaceID = in.NamespaceId
+	req.EnpointID = in.EndpointId
+	req.RemoteIP = getRemoteIPFromGRPC(ctx)
+	req.WithToken(tk)
+
+	d, err := pkg.Permission.CheckPermission(req)
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+
+	return decisionToPB(d), nil
+}
+
+func (s *permissionServer) BatchCheckPermission(ctx context.Context, in *pb.BatchCheckPermissionRequest) (*pb.CheckResultSet, error) {
+	tk, err := getTokenFromGRPC(ctx)
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+
+	req := permission.NewBatchCheckPermissionRequest()
+	req.NamespaceID = in.NamespaceId
+	req.RemoteIP = getRemoteIPFromGRPC(ctx)
+	req.WithToken(tk)
+	for i := range in.Items {
+		req.Items = append(req.Items, &permission.CheckItem{
+			EndpointID: in.Items[i].EndpointId,
+			Resource:   in.Items[i].Resource,
+			Labels:     in.Items[i].Labels,
+		})
+	}
+
+	set, err := pkg.Permission.BatchCheckPermission(req)
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+
+	resp := &pb.CheckResultSet{}
+	for _, r := range set.Items {
+		resp.Items = append(resp.Items, &pb.CheckResult{
+			Item: &pb.CheckItem{
+				EndpointId: r.EndpointID,
+				Resource:   r.Resource,
+				Labels:     r.Labels,
+			},
+			Allowed:  r.Allowed,
+			Decision: decisionToPB(r.Decision),
+			Reason:   r.Reason,
+			Message:  r.Message,
+		})
+	}
+
+	return resp, nil
+}
+
+func decisionToPB(d *role.Decision) *pb.Decision {
+	if d == nil {
+		return nil
+	}
+
+	resp := &pb.Decision{
+		Effect:   d.Effect.String(),
+		PolicyId: d.PolicyID,
+		RoleId:   d.RoleID,
+		RoleName: d.RoleName,
+	}
+	if p := d.Permission; p != nil {
+		resp.Permission = &pb.Permission{
+			Effect:       p.GetEffect().String(),
+			ResourceName: p.ResourceName,
+			LabelKey:     p.LabelKey,
+			MatchAll:     p.MatchAll,
+			LabelValues:  p.LabelValues,
+		}
+	}
+
+	return resp
+}
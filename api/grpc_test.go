@@ -0,0 +1,143 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/infraboard/mcube/exception"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/infraboard/keyauth/api/pb"
+	"github.com/infraboard/keyauth/pkg/permission"
+	"github.com/infraboard/keyauth/pkg/token"
+)
+
+func TestToGRPCError(t *testing.T) {
+	cases := []struct {
+		name   string
+		err    error
+		code   codes.Code
+		reason string
+	}{
+		{"deny", permission.NewDenyError(permission.DenyExplicit, "denied"), codes.PermissionDenied, permission.DenyExplicit},
+		{"mfa required", token.NewMFARequiredError("c1"), codes.Unauthenticated, token.ErrMFARequired},
+		{"password expired", token.NewPasswordExpiredError("expired"), codes.FailedPrecondition, token.ErrPasswordExpired},
+		{"bad request", exception.NewBadRequest("bad"), codes.InvalidArgument, ""},
+		{"unauthorized", exception.NewUnauthorized("who"), codes.Unauthenticated, ""},
+		{"token expired", exception.NewAccessTokenExpired("expired"), codes.Unauthenticated, ""},
+		{"forbidden", exception.NewPermissionDeny("no"), codes.PermissionDenied, ""},
+		{"not found", exception.NewNotFound("none"), codes.NotFound, ""},
+		{"conflict", exception.NewConflict("dup"), codes.AlreadyExists, ""},
+		{"internal", exception.NewInternalServerError("oops"), codes.Internal, ""},
+		{"plain", errors.New("plain"), codes.Internal, ""},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			should := require.New(t)
+
+			st, ok := status.FromError(toGRPCError(c.err))
+			should.True(ok)
+			should.Equal(c.code, st.Code())
+
+			var info *errdetails.ErrorInfo
+			for _, d := range st.Details() {
+				if v, ok := d.(*errdetails.ErrorInfo); ok {
+					info = v
+				}
+			}
+			if c.reason == "" {
+				should.Nil(info)
+				return
+			}
+			should.NotNil(info)
+			should.Equal(c.reason, info.Reason)
+			should.Equal(errorDomain, info.Domain)
+		})
+	}
+
+	should := require.New(t)
+	should.NoError(toGRPCError(nil))
+
+	st, _ := status.FromError(toGRPCError(token.NewMFARequiredError("c1")))
+	info := st.Details()[0].(*errdetails.ErrorInfo)
+	should.Equal("c1", info.Metadata["challenge_id"])
+}
+
+// 记录服务端从调用上下文中获取到的信息
+type captureServer struct {
+	pb.UnimplementedEndpointServiceServer
+	accessToken string
+	remoteIP    string
+	userAgent   string
+}
+
+func (s *captureServer) DescribeEndpoint(ctx context.Context, req *pb.DescribeEndpointRequest) (*pb.Endpoint, error) {
+	s.accessToken = getAccessTokenFromGRPC(ctx)
+	s.remoteIP = getRemoteIPFromGRPC(ctx)
+	s.userAgent = getUserAgentFromGRPC(ctx)
+	return &pb.Endpoint{}, nil
+}
+
+func TestGRPCContextHelpers(t *testing.T) {
+	should := require.New(t)
+
+	lis := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer()
+	cs := &captureServer{}
+	pb.RegisterEndpointServiceServer(server, cs)
+	go server.Serve(lis)
+	defer server.Stop()
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithInsecure(),
+		grpc.WithUserAgent("keyauth-test"),
+	)
+	should.NoError(err)
+	defer conn.Close()
+	client := pb.NewEndpointServiceClient(conn)
+
+	cases := []struct {
+		name   string
+		md     metadata.MD
+		expect string
+	}{
+		{"token metadata", metadata.Pairs(TokenMetadataKey, "t1"), "t1"},
+		{"bearer", metadata.Pairs("authorization", "Bearer t2"), "t2"},
+		{"token metadata first", metadata.Pairs(TokenMetadataKey, "t1", "authorization", "Bearer t2"), "t1"},
+		{"missing", metadata.MD{}, ""},
+	}
+	for _, c := range cases {
+		ctx := metadata.NewOutgoingContext(context.Background(), c.md)
+		_, err := client.DescribeEndpoint(ctx, &pb.DescribeEndpointRequest{})
+		should.NoError(err, c.name)
+		should.Equal(c.expect, cs.accessToken, c.name)
+		// bufconn的地址不是host:port格式, 原样返回
+		should.Equal("bufconn", cs.remoteIP, c.name)
+		should.Contains(cs.userAgent, "keyauth-test", c.name)
+	}
+}
+
+func TestGetRemoteIPFromGRPC(t *testing.T) {
+	should := require.New(t)
+
+	should.Equal("", getRemoteIPFromGRPC(context.Background()))
+
+	addr := &net.TCPAddr{IP: net.ParseIP("10.1.2.3"), Port: 51234}
+	ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: addr})
+	should.Equal("10.1.2.3", getRemoteIPFromGRPC(ctx))
+
+	addr = &net.TCPAddr{IP: net.ParseIP("::1"), Port: 51234}
+	ctx = peer.NewContext(context.Background(), &peer.Peer{Addr: addr})
+	should.Equal("::1", getRemoteIPFromGRPC(ctx))
+}
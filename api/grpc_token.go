@@ -0,0 +1,108 @@
+package api
+
+import (
+	"context"
+
+	"github.com/infraboard/keyauth/api/pb"
+	"github.com/infraboard/keyauth/pkg"
+	"github.com/infraboard/keyauth/pkg/token"
+)
+
+type tokenServer struct {
+	pb.UnimplementedTokenServiceServer
+}
+
+func (s *tokenServer) IssueToken(ctx context.Context, in *pb.IssueTokenRequest) (*pb.Token, error) {
+	req := token.NewIssueTokenRequest()
+	req.GrantType = token.GrantType(in.GrantType)
+	req.ClientID = in.ClientId
+	req.ClientSecret = in.ClientSecret
+	req.Username = in.Username
+	req.Password = in.Password
+	req.RefreshToken = in.RefreshToken
+	req.AccessToken = in.AccessToken
+	req.AuthCode = in.Code
+	req.RedirectURI = in.RedirectUri
+	req.CodeVerifier = in.CodeVerifier
+	req.DeviceCode = in.DeviceCode
+	req.SubjectToken = in.SubjectToken
+	req.SubjectTokenType = in.SubjectTokenType
+	req.ActorToken = in.ActorToken
+	req.ActorTokenType = in.ActorTokenType
+	req.Type = token.Type(in.Type)
+	req.Scope = in.Scope
+	req.ChallengeID = in.ChallengeId
+	req.OTP = in.Otp
+	req.Assertion = in.Assertion
+	req.WithRemoteIP(getRemoteIPFromGRPC(ctx))
+	req.WithUserAgent(getUserAgentFromGRPC(ctx))
+
+	tk, err := pkg.Token.IssueToken(req)
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+
+	return tokenToPB(tk), nil
+}
+
+func (s *tokenServer) ValidateToken(ctx context.Context, in *pb.ValidateTokenRequest) (*pb.Token, error) {
+	req := token.NewValidateTokenRequest()
+	req.AccessToken = in.AccessToken
+	req.NamesapceID = in.NamespaceId
+	req.EndpointID = in.EndpointId
+	// 请求中没有携带令牌时, 校验metadata中的令牌
+	if req.AccessToken == "" {
+		req.AccessToken = getAccessTokenFromGRPC(ctx)
+	}
+
+	tk, err := pkg.Token.ValidateToken(req)
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+
+	return tokenToPB(tk), nil
+}
+
+func (s *tokenServer) RevolkToken(ctx context.Context, in *pb.RevolkTokenRequest) (*pb.RevolkTokenResponse, error) {
+	req := token.NewRevolkTokenRequest(in.ClientId, in.ClientSecret)
+	req.AccessToken = in.AccessToken
+	req.RefreshToken = in.RefreshToken
+	req.Token = in.Token
+	req.TokenTypeHint = token.TokenTypeHint(in.TokenTypeHint)
+
+	if err := pkg.Token.RevolkToken(req); err != nil {
+		return nil, toGRPCError(err)
+	}
+
+	return &pb.RevolkTokenResponse{}, nil
+}
+
+func tokenToPB(tk *token.Token) *pb.Token {
+	pbtk := &pb.Token{
+		AccessToken:      tk.AccessToken,
+		RefreshToken:     tk.RefreshToken,
+		CreateAt:         tk.CreatedAt.Timestamp(),
+		AccessExpiredAt:  tk.AccessExpiredAt.Timestamp(),
+		RefreshExpiredAt: tk.RefreshExpiredAt.Timestamp(),
+		Domain:           tk.Domain,
+		UserType:         string(tk.UserType),
+		Account:          tk.Account,
+		ApplicationId:    tk.ApplicationID,
+		ApplicationName:  tk.ApplicationName,
+		ClientId:         tk.ClientID,
+		StartGrantType:   string(tk.StartGrantType),
+		GrantType:        string(tk.GrantType),
+		Type:             string(tk.Type),
+		Scope:            tk.Scope,
+		Mfa:              tk.MFA,
+		IdToken:          tk.IDToken,
+	}
+
+	// 交换的令牌不允许刷新, 与HTTP接口一样不返回刷新令牌
+	if tk.GrantType.Is(token.EXCHANGE) {
+		pbtk.RefreshToken = ""
+		pbtk.RefreshExpiredAt = 0
+	}
+
+	return pbtk
+}
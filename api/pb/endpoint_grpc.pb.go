@@ -0,0 +1,137 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion7
+
+// EndpointServiceClient is the client API for EndpointService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type EndpointServiceClient interface {
+	// Registry 注册服务的端点
+	Registry(ctx context.Context, in *RegistryRequest, opts ...grpc.CallOption) (*RegistryResponse, error)
+	// DescribeEndpoint 查询端点详情
+	DescribeEndpoint(ctx context.Context, in *DescribeEndpointRequest, opts ...grpc.CallOption) (*Endpoint, error)
+}
+
+type endpointServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewEndpointServiceClient(cc grpc.ClientConnInterface) EndpointServiceClient {
+	return &endpointServiceClient{cc}
+}
+
+func (c *endpointServiceClient) Registry(ctx context.Context, in *RegistryRequest, opts ...grpc.CallOption) (*RegistryResponse, error) {
+	out := new(RegistryResponse)
+	err := c.cc.Invoke(ctx, "/keyauth.EndpointService/Registry", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *endpointServiceClient) DescribeEndpoint(ctx context.Context, in *DescribeEndpointRequest, opts ...grpc.CallOption) (*Endpoint, error) {
+	out := new(Endpoint)
+	err := c.cc.Invoke(ctx, "/keyauth.EndpointService/DescribeEndpoint", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// EndpointServiceServer is the server API for EndpointService service.
+// All implementations must embed UnimplementedEndpointServiceServer
+// for forward compatibility
+type EndpointServiceServer interface {
+	// Registry 注册服务的端点
+	Registry(context.Context, *RegistryRequest) (*RegistryResponse, error)
+	// DescribeEndpoint 查询端点详情
+	DescribeEndpoint(context.Context, *DescribeEndpointRequest) (*Endpoint, error)
+	mustEmbedUnimplementedEndpointServiceServer()
+}
+
+// UnimplementedEndpointServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedEndpointServiceServer struct {
+}
+
+func (UnimplementedEndpointServiceServer) Registry(context.Context, *RegistryRequest) (*RegistryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Registry not implemented")
+}
+func (UnimplementedEndpointServiceServer) DescribeEndpoint(context.Context, *DescribeEndpointRequest) (*Endpoint, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DescribeEndpoint not implemented")
+}
+func (UnimplementedEndpointServiceServer) mustEmbedUnimplementedEndpointServiceServer() {}
+
+// UnsafeEndpointServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to EndpointServiceServer will
+// result in compilation errors.
+type UnsafeEndpointServiceServer interface {
+	mustEmbedUnimplementedEndpointServiceServer()
+}
+
+func RegisterEndpointServiceServer(s grpc.ServiceRegistrar, srv EndpointServiceServer) {
+	s.RegisterService(&_EndpointService_serviceDesc, srv)
+}
+
+func _EndpointService_Registry_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RegistryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EndpointServiceServer).Registry(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/keyauth.EndpointService/Registry",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EndpointServiceServer).Registry(ctx, req.(*RegistryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EndpointService_DescribeEndpoint_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DescribeEndpointRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EndpointServiceServer).DescribeEndpoint(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/keyauth.EndpointService/DescribeEndpoint",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EndpointServiceServer).DescribeEndpoint(ctx, req.(*DescribeEndpointRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _EndpointService_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "keyauth.EndpointService",
+	HandlerType: (*EndpointServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Registry",
+			Handler:    _EndpointService_Registry_Handler,
+		},
+		{
+			MethodName: "DescribeEndpoint",
+			Handler:    _EndpointService_DescribeEndpoint_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "endpoint.proto",
+}
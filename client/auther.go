@@ -0,0 +1,143 @@
+package client
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/infraboard/mcube/cache"
+	"github.com/infraboard/mcube/cache/memory"
+	"github.com/infraboard/mcube/exception"
+	"github.com/infraboard/mcube/http/router"
+
+	"github.com/infraboard/keyauth/pkg/endpoint"
+	"github.com/infraboard/keyauth/pkg/permission"
+	"github.com/infraboard/keyauth/pkg/token"
+	"github.com/infraboard/keyauth/pkg/user/types"
+)
+
+const (
+	// DefaultTokenCacheTTL 令牌在本地缓存的时间, 撤销的令牌最多在该时间之后失效
+	DefaultTokenCacheTTL = 60 * time.Second
+)
+
+// NewAuther 下游服务使用的认证器, 远程校验令牌, 校验通过的令牌在本地缓存
+// serviceName 为服务注册端点时使用的账号, 用于计算端点ID
+func NewAuther(c *Client, serviceName string) *Auther {
+	conf := memory.NewDefaultConfig()
+	conf.TTL = DefaultTokenCacheTTL
+
+	return &Auther{
+		client:  c,
+		service: serviceName,
+		cache:   memory.NewCache(conf),
+	}
+}
+
+// Auther 实现router.Auther
+type Auther struct {
+	client  *Client
+	service string
+	cache   cache.Cache
+}
+
+// WithCache 使用自定义的缓存, 比如多个实例共用的redis
+func (a *Auther) WithCache(c cache.Cache) *Auther {
+	a.cache = c
+	return a
+}
+
+// Auth 与keyauth内部的认证逻辑相同: 校验令牌, 令牌范围对所有用户生效, 超级管理员跳过权限检查
+func (a *Auther) Auth(r *http.Request, entry router.Entry) (
+	authInfo interface{}, err error) {
+	if !entry.AuthEnable {
+		return nil, nil
+	}
+
+	accessToken := getAccessTokenFromHTTP(r)
+	if accessToken == "" {
+		return nil, exception.NewUnauthorized("x-oauth-token header required")
+	}
+
+	tk, err := a.validateToken(accessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if !entry.PermissionEnable {
+		return tk, nil
+	}
+
+	scope, err := token.ParseScope(tk.Scope)
+	if err != nil {
+		return nil, exception.NewBadRequest("parse token scope error, %s", err)
+	}
+	if !scope.Allow(&entry) {
+		return nil, permission.NewDenyError(permission.DenyTokenScope, "token scope not allow %s", entry.Resource)
+	}
+
+	if tk.UserType.Is(types.SupperAccount) {
+		return tk, nil
+	}
+
+	// 只有属于空间的端点需要指定空间, 其他端点使用域级别的策略
+	var namespaceID string
+	if permission.IsNamespaced(&entry) {
+		namespaceID = getNamespaceFromHTTP(r)
+		if namespaceID == "" {
+			return nil, permission.NewDenyError(permission.DenyNamespaceRequired,
+				"x-namespace-id header or namespace_id query param required")
+		}
+	}
+
+	epID := endpoint.GenHashID(a.service, entry.Path, entry.Method)
+	if _, err := a.client.CheckPermission(accessToken, namespaceID, epID); err != nil {
+		return nil, err
+	}
+
+	return tk, nil
+}
+
+func (a *Auther) validateToken(accessToken string) (*token.Token, error) {
+	key := "keyauth.token." + accessToken
+
+	tk := token.NewDefaultToken()
+	if err := a.cache.Get(key, tk); err == nil && !tk.CheckAccessIsExpired() {
+		return tk, nil
+	}
+
+	req := token.NewValidateTokenRequest()
+	req.AccessToken = accessToken
+	tk, err := a.client.ValidateToken(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// 缓存失败不影响认证
+	a.cache.PutWithTTL(key, tk, DefaultTokenCacheTTL)
+	return tk, nil
+}
+
+// getAccessTokenFromHTTP 优先使用x-oauth-token, 兼容标准的Authorization: Bearer
+func getAccessTokenFromHTTP(r *http.Request) string {
+	if tk := r.Header.Get("x-oauth-token"); tk != "" {
+		return tk
+	}
+
+	const prefix = "Bearer "
+	auth := r.Header.Get("Authorization")
+	if len(auth) > len(prefix) && strings.EqualFold(auth[:len(prefix)], prefix) {
+		return auth[len(prefix):]
+	}
+
+	return ""
+}
+
+// getNamespaceFromHTTP 优先使用x-namespace-id, 其次使用namespace_id查询参数
+func getNamespaceFromHTTP(r *http.Request) string {
+	if ns := r.Header.Get("x-namespace-id"); ns != "" {
+		return ns
+	}
+
+	return r.URL.Query().Get("namespace_id")
+}
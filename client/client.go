@@ -0,0 +1,137 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/infraboard/mcube/exception"
+	"github.com/infraboard/mcube/http/response"
+
+	"github.com/infraboard/keyauth/pkg/permission"
+	"github.com/infraboard/keyauth/pkg/token"
+)
+
+// NewClient 创建keyauth客户端
+func NewClient(conf *Config) (*Client, error) {
+	if err := conf.Validate(); err != nil {
+		return nil, err
+	}
+
+	base := strings.TrimSuffix(conf.Address, "/")
+	if conf.PathPrefix != "" {
+		base += "/" + strings.Trim(conf.PathPrefix, "/")
+	}
+
+	return &Client{
+		conf: conf,
+		base: base + "/v1",
+		hc:   &http.Client{Timeout: conf.Timeout},
+	}, nil
+}
+
+// Client keyauth HTTP API的客户端
+type Client struct {
+	conf *Config
+	base string
+	hc   *http.Client
+}
+
+// request 单次请求的参数
+type request struct {
+	method      string
+	path        string
+	query       url.Values
+	accessToken string
+	basicAuth   bool
+	body        interface{}
+}
+
+func (c *Client) do(req *request, data interface{}) error {
+	var body io.Reader
+	if req.body != nil {
+		b, err := json.Marshal(req.body)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(b)
+	}
+
+	u := c.base + req.path
+	if len(req.query) > 0 {
+		u += "?" + req.query.Encode()
+	}
+
+	r, err := http.NewRequest(req.method, u, body)
+	if err != nil {
+		return err
+	}
+	if req.body != nil {
+		r.Header.Set("Content-Type", "application/json")
+	}
+	if req.accessToken != "" {
+		r.Header.Set("X-OAUTH-TOKEN", req.accessToken)
+	}
+	if req.basicAuth {
+		r.SetBasicAuth(c.conf.ClientID, c.conf.ClientSecret)
+	}
+
+	resp, err := c.hc.Do(r)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return decodeResponse(resp.Body, data)
+}
+
+// decodeResponse 解析keyauth统一的响应格式, 保留鉴权拒绝的原因
+func decodeResponse(body io.Reader, v interface{}) error {
+	b, err := ioutil.ReadAll(body)
+	if err != nil {
+		return err
+	}
+
+	data := response.NewData(v)
+	if err := json.Unmarshal(b, data); err != nil {
+		return fmt.Errorf("decode response error, %s", err)
+	}
+
+	if data.Code == nil {
+		return errors.New("reponse code is nil")
+	}
+
+	if *data.Code == 0 {
+		return nil
+	}
+
+	if *data.Code == exception.Forbidden && isDenyReason(data.Reason) {
+		return permission.NewDenyError(data.Reason, "%s", data.Message)
+	}
+
+	if data.Reason == token.ErrMFARequired || data.Reason == token.ErrPasswordExpired {
+		return token.NewOAuthError(*data.Code, data.Reason, "%s", data.Message)
+	}
+
+	return exception.NewAPIException(data.Namespace, *data.Code, data.Reason, data.Message)
+}
+
+func isDenyReason(reason string) bool {
+	switch reason {
+	case permission.DenyNamespaceRequired,
+		permission.DenyTokenScope,
+		permission.DenyEndpointNotFound,
+		permission.DenyNoPermission,
+		permission.DenyExplicit,
+		permission.DenyConditionNotSatisfied:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,194 @@
+package client_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/infraboard/mcube/exception"
+	"github.com/infraboard/mcube/http/request"
+	"github.com/infraboard/mcube/http/response"
+	"github.com/infraboard/mcube/http/router"
+	"github.com/stretchr/testify/require"
+
+	"github.com/infraboard/keyauth/client"
+	"github.com/infraboard/keyauth/pkg/endpoint"
+	"github.com/infraboard/keyauth/pkg/permission"
+	"github.com/infraboard/keyauth/pkg/role"
+	"github.com/infraboard/keyauth/pkg/token"
+)
+
+// keyauth 模拟keyauth的HTTP接口
+type keyauth struct {
+	validateCount int32
+	registered    *endpoint.RegistryRequest
+}
+
+func (k *keyauth) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	allowEP := endpoint.GenHashID("cmdb", "/hosts", "GET")
+
+	switch r.Method + " " + r.URL.Path {
+	case "GET /keyauth/v1/oauth2/tokens/":
+		atomic.AddInt32(&k.validateCount, 1)
+		if r.Header.Get("X-OAUTH-TOKEN") != "valid" {
+			response.Failed(w, exception.NewAccessTokenIllegal("token invalid"))
+			return
+		}
+		tk := token.NewDefaultToken()
+		tk.AccessToken = "valid"
+		tk.Account = "alice"
+		response.Success(w, tk)
+	case "POST /keyauth/v1/oauth2/tokens/":
+		req := token.NewIssueTokenRequest()
+		if err := request.GetDataFromRequest(r, req); err != nil {
+			response.Failed(w, err)
+			return
+		}
+		if req.GrantType == token.MFAOTP && req.ChallengeID == "c1" && req.OTP == "123456" {
+			response.Success(w, &token.Token{AccessToken: "valid", Account: "alice", MFA: true})
+			return
+		}
+		e := token.NewMFARequiredError("c1")
+		code := e.ErrorCode()
+		json.NewEncoder(w).Encode(&response.Data{Code: &code, Reason: e.Reason(), Message: e.Error(), Data: e})
+	case "GET /keyauth/v1/namespaces/ns1/permissions/endpoints/" + allowEP:
+		response.Success(w, &role.Decision{Effect: role.Allow, RoleID: "r1"})
+	case "GET /keyauth/v1/permissions/endpoints/" + endpoint.GenHashID("cmdb", "/regions", "GET"):
+		response.Success(w, &role.Decision{Effect: role.Allow, RoleID: "domain"})
+	case "GET /keyauth/v1/namespaces/ns1/permissions/endpoints/" + endpoint.GenHashID("cmdb", "/hosts", "DELETE"):
+		response.Failed(w, permission.NewDenyError(permission.DenyExplicit, "deny by role r2"))
+	case "POST /keyauth/v1/endpoints/":
+		req := endpoint.NewDefaultRegistryRequest()
+		if err := request.GetDataFromRequest(r, req); err != nil {
+			response.Failed(w, err)
+			return
+		}
+		k.registered = req
+		response.Success(w, req)
+	default:
+		response.Failed(w, exception.NewNotFound("%s not found", r.URL.Path))
+	}
+}
+
+func newTestClient(t *testing.T, k *keyauth) *client.Client {
+	server := httptest.NewServer(k)
+	t.Cleanup(server.Close)
+
+	conf := client.NewDefaultConfig()
+	conf.Address = server.URL
+	c, err := client.NewClient(conf)
+	require.NoError(t, err)
+	return c
+}
+
+func TestValidateToken(t *testing.T) {
+	should := require.New(t)
+	c := newTestClient(t, &keyauth{})
+
+	req := token.NewValidateTokenRequest()
+	req.AccessToken = "valid"
+	tk, err := c.ValidateToken(req)
+	should.NoError(err)
+	should.Equal("alice", tk.Account)
+
+	req.AccessToken = "invalid"
+	_, err = c.ValidateToken(req)
+	should.Error(err)
+	should.True(err.(exception.APIException).Is(exception.AccessTokenIllegal))
+}
+
+func TestCheckPermission(t *testing.T) {
+	should := require.New(t)
+	c := newTestClient(t, &keyauth{})
+
+	d, err := c.CheckPermission("valid", "ns1", endpoint.GenHashID("cmdb", "/hosts", "GET"))
+	should.NoError(err)
+	should.True(d.IsAllowed())
+
+	_, err = c.CheckPermission("valid", "ns1", endpoint.GenHashID("cmdb", "/hosts", "DELETE"))
+	e, ok := permission.IsDenyError(err)
+	should.True(ok)
+	should.Equal(permission.DenyExplicit, e.Reason())
+}
+
+func TestAutherCacheToken(t *testing.T) {
+	should := require.New(t)
+	k := &keyauth{}
+	a := client.NewAuther(newTestClient(t, k), "cmdb")
+
+	entry := router.Entry{Path: "/hosts", Method: "GET", AuthEnable: true, PermissionEnable: true,
+		Labels: map[string]string{permission.NamespaceLableKey: permission.Namespaced.Value()}}
+	for i := 0; i < 3; i++ {
+		r := httptest.NewRequest("GET", "/hosts?namespace_id=ns1", nil)
+		r.Header.Set("Authorization", "Bearer valid")
+		info, err := a.Auth(r, entry)
+		should.NoError(err)
+		should.Equal("alice", info.(*token.Token).Account)
+	}
+	should.Equal(int32(1), atomic.LoadInt32(&k.validateCount))
+
+	// 没有指定空间
+	r := httptest.NewRequest("GET", "/hosts", nil)
+	r.Header.Set("X-OAUTH-TOKEN", "valid")
+	_, err := a.Auth(r, entry)
+	e, ok := permission.IsDenyError(err)
+	should.True(ok)
+	should.Equal(permission.DenyNamespaceRequired, e.Reason())
+
+	entry.Method = "DELETE"
+	r = httptest.NewRequest("DELETE", "/hosts", nil)
+	r.Header.Set("X-OAUTH-TOKEN", "valid")
+	r.Header.Set("X-NAMESPACE-ID", "ns1")
+	_, err = a.Auth(r, entry)
+	e, ok = permission.IsDenyError(err)
+	should.True(ok)
+	should.Equal(permission.DenyExplicit, e.Reason())
+
+	r = httptest.NewRequest("GET", "/hosts", nil)
+	_, err = a.Auth(r, entry)
+	should.Error(err)
+}
+
+func TestAutherDomainEndpoint(t *testing.T) {
+	should := require.New(t)
+	a := client.NewAuther(newTestClient(t, &keyauth{}), "cmdb")
+
+	// 不属于空间的端点不需要指定空间, 使用域级别的权限
+	entry := router.Entry{Path: "/regions", Method: "GET", AuthEnable: true, PermissionEnable: true}
+	r := httptest.NewRequest("GET", "/regions", nil)
+	r.Header.Set("X-OAUTH-TOKEN", "valid")
+	info, err := a.Auth(r, entry)
+	should.NoError(err)
+	should.Equal("alice", info.(*token.Token).Account)
+}
+
+func TestRegistryEndpoints(t *testing.T) {
+	should := require.New(t)
+	k := &keyauth{}
+	c := newTestClient(t, k)
+
+	entries := []*router.Entry{{Path: "/hosts", Method: "GET", Resource: "host"}}
+	should.NoError(c.RegistryEndpoints("valid", "v1.0.0", entries))
+	should.Equal("v1.0.0", k.registered.Version)
+	should.Len(k.registered.Entries, 1)
+}
+
+func TestIssueTokenWithMFA(t *testing.T) {
+	should := require.New(t)
+	c := newTestClient(t, &keyauth{})
+
+	_, err := c.IssueToken(token.NewIssueTokenByPassword("cid", "secret", "alice", "pass"))
+	e, ok := token.IsMFARequired(err)
+	should.True(ok)
+	should.Equal("c1", e.ChallengeID)
+
+	req := token.NewIssueTokenRequest()
+	req.GrantType = token.MFAOTP
+	req.ChallengeID = e.ChallengeID
+	req.OTP = "123456"
+	tk, err := c.IssueToken(req)
+	should.NoError(err)
+	should.True(tk.MFA)
+}
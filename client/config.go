@@ -0,0 +1,33 @@
+package client
+
+import (
+	"errors"
+	"time"
+)
+
+// NewDefaultConfig 默认配置, 对应keyauth的默认监听地址
+func NewDefaultConfig() *Config {
+	return &Config{
+		Address:    "http://127.0.0.1:8050",
+		PathPrefix: "keyauth",
+		Timeout:    10 * time.Second,
+	}
+}
+
+// Config 客户端配置
+type Config struct {
+	Address      string        // keyauth服务地址, 例如: http://127.0.0.1:8050
+	PathPrefix   string        // 接口前缀, 与keyauth配置中的app.name相同
+	ClientID     string        // 应用凭证, 颁发和撤销令牌时使用
+	ClientSecret string        // 应用凭证
+	Timeout      time.Duration // 请求超时时间
+}
+
+// Validate 校验配置
+func (c *Config) Validate() error {
+	if c.Address == "" {
+		return errors.New("keyauth address required")
+	}
+
+	return nil
+}
@@ -0,0 +1,41 @@
+package client
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/infraboard/mcube/http/router"
+
+	"github.com/infraboard/keyauth/pkg/endpoint"
+)
+
+// DescribeEndpoint 查询端点详情
+func (c *Client) DescribeEndpoint(accessToken, id string) (*endpoint.Endpoint, error) {
+	ep := endpoint.NewDefaultEndpoint()
+	err := c.do(&request{
+		method:      "GET",
+		path:        fmt.Sprintf("/endpoints/%s", url.PathEscape(id)),
+		accessToken: accessToken,
+	}, ep)
+	if err != nil {
+		return nil, err
+	}
+
+	return ep, nil
+}
+
+// RegistryEndpoints 注册服务的端点, 令牌需要是服务的凭证, 端点归属于令牌的账号
+func (c *Client) RegistryEndpoints(accessToken, version string, entries []*router.Entry) error {
+	req := endpoint.NewRegistryRequest(version, entries)
+	return c.do(&request{
+		method:      "POST",
+		path:        "/endpoints/",
+		accessToken: accessToken,
+		body:        req,
+	}, nil)
+}
+
+// RegistryRouter 注册路由中的所有端点, 与keyauth自身的HTTPService.RegistryEndpoints相同
+func (c *Client) RegistryRouter(accessToken, version string, r router.Router) error {
+	return c.RegistryEndpoints(accessToken, version, r.GetEndpoints().Items)
+}
@@ -0,0 +1,68 @@
+package client
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/infraboard/keyauth/pkg/permission"
+	"github.com/infraboard/keyauth/pkg/role"
+)
+
+// permissionPath 空间为空时使用域级别的权限
+func permissionPath(namespaceID string) string {
+	if namespaceID == "" {
+		return "/permissions"
+	}
+
+	return fmt.Sprintf("/namespaces/%s/permissions", url.PathEscape(namespaceID))
+}
+
+// QueryPermission 查询令牌用户在空间中的所有权限, 空间为空时查询域级别的权限
+func (c *Client) QueryPermission(accessToken, namespaceID string) (*role.PermissionSet, error) {
+	set := role.NewPermissionSet(nil)
+	err := c.do(&request{
+		method:      "GET",
+		path:        permissionPath(namespaceID),
+		accessToken: accessToken,
+	}, set)
+	if err != nil {
+		return nil, err
+	}
+
+	return set, nil
+}
+
+// CheckPermission 检查令牌用户在空间中是否有访问端点的权限, 没有权限时返回*permission.DenyError
+func (c *Client) CheckPermission(accessToken, namespaceID, endpointID string) (*role.Decision, error) {
+	d := &role.Decision{}
+	err := c.do(&request{
+		method:      "GET",
+		path:        permissionPath(namespaceID) + "/endpoints/" + url.PathEscape(endpointID),
+		accessToken: accessToken,
+	}, d)
+	if err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+// BatchCheckPermission 批量检查权限, 结果与请求的顺序一致
+func (c *Client) BatchCheckPermission(accessToken, namespaceID string, items ...*permission.CheckItem) (
+	*permission.CheckResultSet, error) {
+	req := permission.NewBatchCheckPermissionRequest()
+	req.Items = items
+
+	set := permission.NewCheckResultSet()
+	err := c.do(&request{
+		method:      "POST",
+		path:        permissionPath(namespaceID) + "/check",
+		accessToken: accessToken,
+		body:        req,
+	}, set)
+	if err != nil {
+		return nil, err
+	}
+
+	return set, nil
+}
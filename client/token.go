@@ -0,0 +1,71 @@
+package client
+
+import (
+	"net/url"
+
+	"github.com/infraboard/keyauth/pkg/token"
+)
+
+// issueTokenResponse 需要多因素认证时, data中携带挑战ID
+type issueTokenResponse struct {
+	*token.Token
+	ChallengeID string `json:"challenge_id,omitempty"`
+}
+
+// IssueToken 颁发令牌, 没有指定客户端凭证时使用配置中的凭证
+// 用户启用了多因素认证时返回mfa_required错误, 通过token.IsMFARequired获取挑战ID
+func (c *Client) IssueToken(req *token.IssueTokenRequest) (*token.Token, error) {
+	if req.ClientID == "" {
+		req.ClientID = c.conf.ClientID
+		req.ClientSecret = c.conf.ClientSecret
+	}
+
+	resp := &issueTokenResponse{Token: token.NewDefaultToken()}
+	err := c.do(&request{
+		method: "POST",
+		path:   "/oauth2/tokens/",
+		body:   req,
+	}, resp)
+	if err != nil {
+		if e, ok := token.IsMFARequired(err); ok {
+			e.ChallengeID = resp.ChallengeID
+		}
+		return nil, err
+	}
+
+	return resp.Token, nil
+}
+
+// ValidateToken 校验令牌是否有效, 返回令牌的详细信息
+func (c *Client) ValidateToken(req *token.ValidateTokenRequest) (*token.Token, error) {
+	qs := url.Values{}
+	if req.NamesapceID != "" {
+		qs.Set("namespace_id", req.NamesapceID)
+	}
+	if req.EndpointID != "" {
+		qs.Set("endpoint_id", req.EndpointID)
+	}
+
+	tk := token.NewDefaultToken()
+	err := c.do(&request{
+		method:      "GET",
+		path:        "/oauth2/tokens/",
+		query:       qs,
+		accessToken: req.AccessToken,
+	}, tk)
+	if err != nil {
+		return nil, err
+	}
+
+	return tk, nil
+}
+
+// RevolkToken 使用配置中的客户端凭证撤销令牌
+func (c *Client) RevolkToken(accessToken string) error {
+	return c.do(&request{
+		method:      "DELETE",
+		path:        "/oauth2/tokens/",
+		accessToken: accessToken,
+		basicAuth:   true,
+	}, nil)
+}
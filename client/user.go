@@ -0,0 +1,38 @@
+package client
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/infraboard/keyauth/pkg/user"
+)
+
+// DescribeProfile 查询令牌用户自己的信息
+func (c *Client) DescribeProfile(accessToken string) (*user.User, error) {
+	u := user.NewDefaultUser()
+	err := c.do(&request{
+		method:      "GET",
+		path:        "/profile/",
+		accessToken: accessToken,
+	}, u)
+	if err != nil {
+		return nil, err
+	}
+
+	return u, nil
+}
+
+// DescribeSubAccount 查询子账号信息
+func (c *Client) DescribeSubAccount(accessToken, account string) (*user.User, error) {
+	u := user.NewDefaultUser()
+	err := c.do(&request{
+		method:      "GET",
+		path:        fmt.Sprintf("/sub_users/%s", url.PathEscape(account)),
+		accessToken: accessToken,
+	}, u)
+	if err != nil {
+		return nil, err
+	}
+
+	return u, nil
+}
@@ -88,10 +88,14 @@ func NewInitialerFromCLI() (*Initialer, error) {
 	var repeatPass string
 	err = survey.AskOne(
 		&survey.Password{
-			Message: "请输入管理员密码:",
+			Message: fmt.Sprintf("请输入管理员密码(至少%d位):", domain.DefaultPasswordMinLength),
 		},
 		&i.password,
 		survey.WithValidator(survey.Required),
+		// 初始化时域还不存在, 管理员密码使用默认的密码策略, 提前校验避免初始化到一半失败
+		survey.WithValidator(func(ans interface{}) error {
+			return domain.NewDefaultPasswordPolicy().Check(strings.TrimSpace(ans.(string)))
+		}),
 	)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/infraboard/keyauth/pkg"
+	"github.com/infraboard/keyauth/pkg/keystore"
+)
+
+var (
+	keyAlgorithm string
+)
+
+// KeyCmd 令牌签名秘钥管理
+var KeyCmd = &cobra.Command{
+	Use:   "key",
+	Short: "签名秘钥管理",
+	Long:  `JWT签名秘钥管理`,
+}
+
+// rotateKeyCmd 轮转签名秘钥
+var rotateKeyCmd = &cobra.Command{
+	Use:   "rotate",
+	Short: "轮转签名秘钥",
+	Long:  `生成新的签名秘钥并立即生效, 老秘钥保留到配置的保留期后清理`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := loadGlobalConfig(confType); err != nil {
+			return err
+		}
+
+		if err := loadGlobalLogger(); err != nil {
+			return err
+		}
+
+		if err := loadCache(); err != nil {
+			return err
+		}
+
+		if err := pkg.InitService(); err != nil {
+			return err
+		}
+
+		req := keystore.NewRotateKeyRequest()
+		req.Algorithm = keyAlgorithm
+		k, err := pkg.KeyStore.RotateKey(req)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("rotate key success, active key: %s (%s)\n", k.ID, k.Algorithm)
+		return nil
+	},
+}
+
+func init() {
+	rotateKeyCmd.Flags().StringVarP(&confType, "config-type", "t", "file", "the service config type [file/env/etcd]")
+	rotateKeyCmd.Flags().StringVarP(&confFile, "config-file", "f", "etc/keyauth.toml", "the service config from file")
+	rotateKeyCmd.Flags().StringVarP(&keyAlgorithm, "algorithm", "a", "", "the new key algorithm [RS256/ES256], default use config")
+	KeyCmd.AddCommand(rotateKeyCmd)
+	RootCmd.AddCommand(KeyCmd)
+}
@@ -19,6 +19,7 @@ import (
 	"github.com/infraboard/keyauth/api"
 	"github.com/infraboard/keyauth/conf"
 	"github.com/infraboard/keyauth/pkg"
+	"github.com/infraboard/keyauth/pkg/token"
 
 	// 加载所有服务
 	_ "github.com/infraboard/keyauth/pkg/all"
@@ -52,6 +53,11 @@ var serviceCmd = &cobra.Command{
 			return err
 		}
 
+		// 只信任来自可信代理的转发头
+		if err := token.SetTrustedProxies(conf.C().App.TrustedProxies); err != nil {
+			return err
+		}
+
 		// 初始化服务层
 		if err := pkg.InitService(); err != nil {
 			return err
@@ -84,9 +90,11 @@ var serviceCmd = &cobra.Command{
 
 func newService(cnf *conf.Config) (*service, error) {
 	http := api.NewHTTPService()
+	grpc := api.NewGRPCService()
 
 	svr := &service{
 		http: http,
+		grpc: grpc,
 		log:  zap.L().Named("CLI"),
 	}
 
@@ -95,6 +103,7 @@ func newService(cnf *conf.Config) (*service, error) {
 
 type service struct {
 	http *api.HTTPService
+	grpc *api.GRPCService
 
 	log  logger.Logger
 	stop context.CancelFunc
@@ -102,7 +111,37 @@ type service struct {
 
 func (s *service) start() error {
 	s.log.Infof("loaded services: %v", pkg.LoadedService())
-	return s.http.Start()
+
+	grpcErr := make(chan error, 1)
+	go func() {
+		grpcErr <- s.grpc.Start()
+	}()
+
+	httpErr := make(chan error, 1)
+	go func() {
+		httpErr <- s.http.Start()
+	}()
+
+	// 任意一个服务启动失败, 关闭另一个服务并返回错误
+	select {
+	case err := <-grpcErr:
+		if err != nil {
+			s.log.Errorf("start grpc service error, %s", err)
+			if err := s.http.Stop(); err != nil {
+				s.log.Errorf("stop http service error, %s", err)
+			}
+			<-httpErr
+			return err
+		}
+		// grpc服务已正常停止, 等待http服务退出
+		return <-httpErr
+	case err := <-httpErr:
+		if err := s.grpc.Stop(); err != nil {
+			s.log.Errorf("stop grpc service error, %s", err)
+		}
+		<-grpcErr
+		return err
+	}
 }
 
 // config 为全局变量, 只需要load 即可全局可用户
@@ -201,6 +240,9 @@ func (s *service) waitSign(sign chan os.Signal) {
 				if err := s.http.Stop(); err != nil {
 					s.log.Errorf("graceful shutdown err: %s, force exit", err)
 				}
+				if err := s.grpc.Stop(); err != nil {
+					s.log.Errorf("grpc graceful shutdown err: %s, force exit", err)
+				}
 				s.log.Infof("service stop complete")
 				return
 			}
@@ -17,19 +17,27 @@ var (
 
 func newConfig() *Config {
 	return &Config{
-		App:   newDefaultAPP(),
-		Log:   newDefaultLog(),
-		Mongo: newDefaultMongoDB(),
-		Cache: newDefaultCache(),
+		App:      newDefaultAPP(),
+		Log:      newDefaultLog(),
+		Mongo:    newDefaultMongoDB(),
+		Cache:    newDefaultCache(),
+		JWT:      newDefaultJWT(),
+		OIDC:     newDefaultOIDC(),
+		WebAuthn: newDefaultWebAuthn(),
+		Notify:   newDefaultNotify(),
 	}
 }
 
 // Config 应用配置
 type Config struct {
-	App   *app     `toml:"app"`
-	Log   *log     `toml:"log"`
-	Mongo *mongodb `toml:"mongodb"`
-	Cache *_cache  `toml:"cache"`
+	App      *app      `toml:"app"`
+	Log      *log      `toml:"log"`
+	Mongo    *mongodb  `toml:"mongodb"`
+	Cache    *_cache   `toml:"cache"`
+	JWT      *jwt      `toml:"jwt"`
+	OIDC     *oidc     `toml:"oidc"`
+	WebAuthn *webauthn `toml:"webauthn"`
+	Notify   *notify   `toml:"notify"`
 }
 
 // InitGloabl 注入全局变量
@@ -47,22 +55,106 @@ func (c *Config) InitGloabl() error {
 }
 
 type app struct {
-	Name string `toml:"name" env:"K_APP_NAME"`
-	Host string `toml:"host" env:"K_APP_HOST"`
-	Port string `toml:"port" env:"K_APP_PORT"`
-	Key  string `toml:"key" env:"K_APP_KEY"`
+	Name     string `toml:"name" env:"K_APP_NAME"`
+	Host     string `toml:"host" env:"K_APP_HOST"`
+	Port     string `toml:"port" env:"K_APP_PORT"`
+	GRPCPort string `toml:"grpc_port" env:"K_APP_GRPC_PORT"`
+	Key      string `toml:"key" env:"K_APP_KEY"`
+	// 可信的反向代理(IP或者CIDR), 只有来自这些地址的请求才会读取X-Forwarded-For/X-Real-IP获取调用方IP
+	TrustedProxies []string `toml:"trusted_proxies" env:"K_APP_TRUSTED_PROXIES" envSeparator:","`
 }
 
 func (a *app) Addr() string {
 	return a.Host + ":" + a.Port
 }
 
+// GRPCAddr gRPC服务的监听地址
+func (a *app) GRPCAddr() string {
+	return a.Host + ":" + a.GRPCPort
+}
+
 func newDefaultAPP() *app {
 	return &app{
-		Name: "keyauth",
-		Host: "127.0.0.1",
-		Port: "8050",
-		Key:  "default",
+		Name:     "keyauth",
+		Host:     "127.0.0.1",
+		Port:     "8050",
+		GRPCPort: "18050",
+		Key:      "default",
+	}
+}
+
+type jwt struct {
+	// 令牌的签发者, 同时作为OIDC的issuer, 需要配置为服务对外的地址, 例如 https://auth.example.com/keyauth/v1
+	Issuer         string `toml:"issuer" env:"K_JWT_ISSUER"`
+	Algorithm      string `toml:"algorithm" env:"K_JWT_ALGORITHM"`
+	PrivateKeyFile string `toml:"private_key_file" env:"K_JWT_PRIVATE_KEY_FILE"`
+	// 轮转后老秘钥的保留时长, 需要大于令牌的最长有效期
+	KeyRetainSecond int64 `toml:"key_retain_second" env:"K_JWT_KEY_RETAIN_SECOND"`
+}
+
+func newDefaultJWT() *jwt {
+	return &jwt{
+		Issuer:          "http://127.0.0.1:8050/keyauth/v1",
+		Algorithm:       "RS256",
+		KeyRetainSecond: 7 * 24 * 3600,
+	}
+}
+
+type oidc struct {
+	// 用户登录并授权的前端页面地址, 为空时使用授权API的地址
+	AuthorizeURL string `toml:"authorize_url" env:"K_OIDC_AUTHORIZE_URL"`
+	// 用户输入设备码完成授权的前端页面地址, 为空时使用设备授权API的地址
+	DeviceVerificationURL string `toml:"device_verification_url" env:"K_OIDC_DEVICE_VERIFICATION_URL"`
+}
+
+func newDefaultOIDC() *oidc {
+	return &oidc{}
+}
+
+type webauthn struct {
+	// 依赖方ID, 需要是前端页面的域名或者其父域名, 例如 example.com
+	RPID   string `toml:"rp_id" env:"K_WEBAUTHN_RP_ID"`
+	RPName string `toml:"rp_name" env:"K_WEBAUTHN_RP_NAME"`
+	// 允许发起认证的前端页面来源, 例如 https://auth.example.com
+	Origins []string `toml:"origins" env:"K_WEBAUTHN_ORIGINS" envSeparator:","`
+}
+
+func newDefaultWebAuthn() *webauthn {
+	return &webauthn{
+		RPID:    "localhost",
+		RPName:  "keyauth",
+		Origins: []string{"http://localhost:8050"},
+	}
+}
+
+type notify struct {
+	// 邮件和短信的发送方式, smtp: 通过SMTP发送邮件, file: 写入文件, 用于开发测试
+	Email string `toml:"email" env:"K_NOTIFY_EMAIL"`
+	SMS   string `toml:"sms" env:"K_NOTIFY_SMS"`
+	// file方式写入的文件, 为空时输出到日志
+	File string `toml:"file" env:"K_NOTIFY_FILE"`
+	SMTP *smtp  `toml:"smtp"`
+}
+
+func newDefaultNotify() *notify {
+	return &notify{
+		Email: "file",
+		SMS:   "file",
+		SMTP:  newDefaultSMTP(),
+	}
+}
+
+type smtp struct {
+	Host     string `toml:"host" env:"K_SMTP_HOST"`
+	Port     int    `toml:"port" env:"K_SMTP_PORT"`
+	Username string `toml:"username" env:"K_SMTP_USERNAME"`
+	Password string `toml:"password" env:"K_SMTP_PASSWORD"`
+	From     string `toml:"from" env:"K_SMTP_FROM"`
+}
+
+func newDefaultSMTP() *smtp {
+	return &smtp{
+		Port: 25,
 	}
 }
 
@@ -6,8 +6,10 @@ require (
 	github.com/AlecAivazis/survey/v2 v2.1.1
 	github.com/BurntSushi/toml v0.3.1
 	github.com/caarlos0/env/v6 v6.3.0
+	github.com/fxamacker/cbor/v2 v2.2.0
 	github.com/go-ldap/ldap/v3 v3.2.3
 	github.com/go-playground/validator/v10 v10.3.0
+	github.com/golang/protobuf v1.4.2
 	github.com/infraboard/mcube v0.4.3
 	github.com/mssola/user_agent v0.5.2
 	github.com/rs/xid v1.2.1
@@ -16,4 +18,7 @@ require (
 	github.com/stretchr/testify v1.6.1
 	go.mongodb.org/mongo-driver v1.4.1
 	golang.org/x/crypto v0.0.0-20200728195943-123391ffb6de
+	google.golang.org/genproto v0.0.0-20200526211855-cb27e3aa2013
+	google.golang.org/grpc v1.33.2
+	google.golang.org/protobuf v1.25.0
 )
@@ -6,9 +6,13 @@ import (
 	_ "github.com/infraboard/keyauth/pkg/application/mongo"
 	_ "github.com/infraboard/keyauth/pkg/audit/http"
 	_ "github.com/infraboard/keyauth/pkg/audit/mongo"
+	_ "github.com/infraboard/keyauth/pkg/authcode/http"
+	_ "github.com/infraboard/keyauth/pkg/authcode/mongo"
 	_ "github.com/infraboard/keyauth/pkg/counter/mongo"
 	_ "github.com/infraboard/keyauth/pkg/department/http"
 	_ "github.com/infraboard/keyauth/pkg/department/mongo"
+	_ "github.com/infraboard/keyauth/pkg/device/http"
+	_ "github.com/infraboard/keyauth/pkg/device/mongo"
 	_ "github.com/infraboard/keyauth/pkg/domain/http"
 	_ "github.com/infraboard/keyauth/pkg/domain/mongo"
 	_ "github.com/infraboard/keyauth/pkg/endpoint/http"
@@ -17,16 +21,23 @@ import (
 	_ "github.com/infraboard/keyauth/pkg/geoip/mongo"
 	_ "github.com/infraboard/keyauth/pkg/ip2region/http"
 	_ "github.com/infraboard/keyauth/pkg/ip2region/mongo"
+	_ "github.com/infraboard/keyauth/pkg/keystore/http"
+	_ "github.com/infraboard/keyauth/pkg/keystore/mongo"
+	_ "github.com/infraboard/keyauth/pkg/lockout/http"
+	_ "github.com/infraboard/keyauth/pkg/lockout/mongo"
 	_ "github.com/infraboard/keyauth/pkg/micro/http"
 	_ "github.com/infraboard/keyauth/pkg/micro/mongo"
 	_ "github.com/infraboard/keyauth/pkg/namespace/http"
 	_ "github.com/infraboard/keyauth/pkg/namespace/mongo"
+	_ "github.com/infraboard/keyauth/pkg/oidc/http"
 	_ "github.com/infraboard/keyauth/pkg/permission/engine"
 	_ "github.com/infraboard/keyauth/pkg/permission/http"
 	_ "github.com/infraboard/keyauth/pkg/policy/http"
 	_ "github.com/infraboard/keyauth/pkg/policy/mongo"
 	_ "github.com/infraboard/keyauth/pkg/provider/http"
 	_ "github.com/infraboard/keyauth/pkg/provider/mongo"
+	_ "github.com/infraboard/keyauth/pkg/reset/http"
+	_ "github.com/infraboard/keyauth/pkg/reset/mongo"
 	_ "github.com/infraboard/keyauth/pkg/role/http"
 	_ "github.com/infraboard/keyauth/pkg/role/mongo"
 	_ "github.com/infraboard/keyauth/pkg/storage/mongo"
@@ -2,6 +2,7 @@ package application
 
 import (
 	"errors"
+	"fmt"
 
 	"github.com/infraboard/mcube/exception"
 	"github.com/infraboard/mcube/http/request"
@@ -29,6 +30,9 @@ func NewUserApplicartion(account string, req *CreateApplicatonRequest) (*Applica
 
 	app := newDeafultApplication(req)
 	app.User = account
+	if tk := req.GetToken(); tk != nil {
+		app.Domain = tk.Domain
+	}
 
 	return app, nil
 }
@@ -60,7 +64,7 @@ func newDeafultApplication(req *CreateApplicatonRequest) *Application {
 type Application struct {
 	ID                       string     `bson:"_id" json:"id,omitempty"`                      // 唯一ID
 	BuildIn                  bool       `bson:"build_in" json:"build_in"`                     // 是否是内建应用
-	Domain                   string     `bosn:"domain" json:"domain,omitempty"`               // 所处于域
+	Domain                   string     `bson:"domain" json:"domain,omitempty"`               // 所处于域
 	User                     string     `bson:"user" json:"user,omitempty"`                   // 应用属于那个用户
 	CreateAt                 ftime.Time `bson:"create_at" json:"create_at,omitempty"`         // 应用创建的时间
 	UpdateAt                 ftime.Time `bson:"update_at" json:"update_at,omitempty"`         // 应用更新的时间
@@ -79,6 +83,20 @@ func (a *Application) CheckClientSecret(secret string) error {
 	return nil
 }
 
+// IsJWT 应用是否使用JWT格式的访问令牌
+func (a *Application) IsJWT() bool {
+	return a.TokenType == token.JWT
+}
+
+// CheckClientCredentialsGrant 只有机密客户端才能使用client_credentials授权
+func (a *Application) CheckClientCredentialsGrant() error {
+	if a.ClientType != Confidential {
+		return fmt.Errorf("client_credentials grant only support %s client", Confidential)
+	}
+
+	return nil
+}
+
 // NewApplicationSet 实例化
 func NewApplicationSet(req *request.PageRequest) *Set {
 	return &Set{
@@ -2,12 +2,14 @@ package mongo
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/infraboard/mcube/exception"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 
 	"github.com/infraboard/keyauth/pkg/application"
+	"github.com/infraboard/keyauth/pkg/token"
 )
 
 func (s *service) CreateUserApplication(req *application.CreateApplicatonRequest) (
@@ -78,5 +80,13 @@ func (s *service) DeleteApplication(id string) error {
 	if result.DeletedCount == 0 {
 		return exception.NewNotFound("app %s not found", id)
 	}
+
+	// 应用删除后, 该应用颁发的令牌全部失效
+	req := token.NewBatchRevolkTokenRequest()
+	req.ApplicationID = id
+	if _, err := s.token.BatchRevolkToken(req); err != nil {
+		return fmt.Errorf("revolk application %s token error, %s", id, err)
+	}
+
 	return nil
 }
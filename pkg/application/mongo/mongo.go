@@ -2,6 +2,7 @@ package mongo
 
 import (
 	"context"
+	"errors"
 
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
@@ -10,6 +11,7 @@ import (
 	"github.com/infraboard/keyauth/conf"
 	"github.com/infraboard/keyauth/pkg"
 	"github.com/infraboard/keyauth/pkg/application"
+	"github.com/infraboard/keyauth/pkg/token"
 )
 
 var (
@@ -21,9 +23,15 @@ type service struct {
 	col           *mongo.Collection
 	enableCache   bool
 	notifyCachPre string
+	token         token.Service
 }
 
 func (s *service) Config() error {
+	if pkg.Token == nil {
+		return errors.New("denpence token service is nil")
+	}
+	s.token = pkg.Token
+
 	db := conf.C().Mongo.GetDB()
 	ac := db.Collection("application")
 
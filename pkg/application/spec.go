@@ -2,6 +2,7 @@ package application
 
 import (
 	"errors"
+	"fmt"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/infraboard/keyauth/pkg/token"
@@ -47,6 +48,13 @@ func NewDescriptApplicationRequest() *DescriptApplicationRequest {
 	}
 }
 
+// NewDescriptApplicationRequestWithID todo
+func NewDescriptApplicationRequestWithID(id string) *DescriptApplicationRequest {
+	req := NewDescriptApplicationRequest()
+	req.ID = id
+	return req
+}
+
 // DescriptApplicationRequest 查询应用详情
 type DescriptApplicationRequest struct {
 	*token.Session
@@ -84,6 +92,7 @@ func NewCreateApplicatonRequest() *CreateApplicatonRequest {
 		AccessTokenExpireSecond:   DefaultAccessTokenExpireSecond,
 		RefreshTokenExpiredSecond: DefaultRefreshTokenExpiredSecond,
 		ClientType:                Public,
+		TokenType:                 token.Bearer,
 	}
 }
 
@@ -98,9 +107,16 @@ type CreateApplicatonRequest struct {
 	AccessTokenExpireSecond   int64      `bson:"access_token_expire_second" json:"access_token_expire_second"`   // 应用申请的token的过期时间
 	RefreshTokenExpiredSecond int64      `bson:"refresh_token_expire_second" json:"refresh_token_expire_second"` // 刷新token过期时间
 	ClientType                ClientType `bson:"client_type" json:"client_type,omitempty"`                       // 客户端类型
+	TokenType                 token.Type `bson:"token_type" json:"token_type,omitempty"`                         // 颁发的访问令牌类型: bearer/jwt, 默认bearer
 }
 
 // Validate 请求校验
 func (req *CreateApplicatonRequest) Validate() error {
+	switch req.TokenType {
+	case "", token.Bearer, token.JWT:
+	default:
+		return fmt.Errorf("unsupported token type %s", req.TokenType)
+	}
+
 	return validate.Struct(req)
 }
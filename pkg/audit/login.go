@@ -132,6 +132,7 @@ type LoginLogData struct {
 	ApplicationName string          `bson:"application_name" json:"application_name" alidate:"required"` // 用户通过哪个端登录的
 	GrantType       token.GrantType `bson:"grant_type" json:"grant_type" alidate:"required"`             // 登录方式
 	LoginIP         string          `bson:"login_ip" json:"login_ip" alidate:"required"`                 // 登录IP
+	Actor           string          `bson:"actor" json:"actor,omitempty"`                                // 令牌交换时, 代表用户行事的调用链
 	userAgent       string          `bson:"-"`
 }
 
@@ -22,6 +22,7 @@ var (
 
 type service struct {
 	login         *mongo.Collection
+	operate       *mongo.Collection
 	enableCache   bool
 	notifyCachPre string
 	ip            ip2region.Service
@@ -51,7 +52,23 @@ func (s *service) Config() error {
 		return err
 	}
 
+	oc := db.Collection("operate")
+	operateIndexs := []mongo.IndexModel{
+		{
+			Keys: bsonx.Doc{{Key: "account", Value: bsonx.Int32(-1)}},
+		},
+		{
+			Keys: bsonx.Doc{{Key: "operate_at", Value: bsonx.Int32(-1)}},
+		},
+	}
+
+	_, err = oc.Indexes().CreateMany(context.Background(), operateIndexs)
+	if err != nil {
+		return err
+	}
+
 	s.login = dc
+	s.operate = oc
 	s.log = zap.L().Named("Audit")
 	return nil
 }
@@ -0,0 +1,19 @@
+package mongo
+
+import (
+	"context"
+
+	"github.com/infraboard/keyauth/pkg/audit"
+)
+
+func (s *service) SaveOperateRecord(req *audit.OperateLogData) {
+	if err := req.Validate(); err != nil {
+		s.log.Errorf("validate operate record error, %s", err)
+		return
+	}
+
+	record := audit.NewOperateLog(req)
+	if _, err := s.operate.InsertOne(context.TODO(), record); err != nil {
+		s.log.Errorf("inserted operate document error, %s", err)
+	}
+}
@@ -1,10 +1,24 @@
 package audit
 
 import (
+	"fmt"
+
 	"github.com/infraboard/mcube/http/request"
 	"github.com/infraboard/mcube/types/ftime"
+	"github.com/rs/xid"
+
+	"github.com/infraboard/keyauth/pkg/token"
 )
 
+// NewOperateLog todo
+func NewOperateLog(data *OperateLogData) *OperateLog {
+	return &OperateLog{
+		ID:             xid.New().String(),
+		Domain:         data.GetToken().Domain,
+		OperateLogData: data,
+	}
+}
+
 // OperateLog 操作日志
 type OperateLog struct {
 	ID              string `bson:"_id" json:"id"`
@@ -13,8 +27,17 @@ type OperateLog struct {
 	*OperateLogData `bson:",inline"`
 }
 
+// NewDefaultOperateLogData todo
+func NewDefaultOperateLogData() *OperateLogData {
+	return &OperateLogData{
+		Session:   token.NewSession(),
+		OperateAt: ftime.Now(),
+	}
+}
+
 // OperateLogData todo
 type OperateLogData struct {
+	*token.Session  `bson:"-" json:"-"`
 	Account         string     `bson:"account" json:"account" alidate:"required"`       // 用户
 	OperateAt       ftime.Time `bson:"operate_at" json:"operate_at" alidate:"required"` // 操作时间
 	ApplicationID   string     `bson:"application_id" json:"application_id"`            // 用户通过哪个端登录的
@@ -25,6 +48,16 @@ type OperateLogData struct {
 	Comment         string     `bson:"comment" json:"comment"`                          // 备注, 用于记录失败原因
 	ResourceID      string     `bson:"resource_id" json:"resource_id"`                  // 资源ID
 	ResourceName    string     `bson:"resource_name" json:"resource_name"`              // 资源名称
+	Actor           string     `bson:"actor" json:"actor,omitempty"`                    // 代表用户行事的调用链
+}
+
+// Validate 校验必填参数
+func (l *OperateLogData) Validate() error {
+	if l.GetToken() == nil {
+		return fmt.Errorf("operate token required")
+	}
+
+	return validate.Struct(l)
 }
 
 // OperateRecordSet todo
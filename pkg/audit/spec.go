@@ -15,6 +15,7 @@ import (
 type Service interface {
 	SaveLoginRecord(*LoginLogData)
 	QueryLoginRecord(*QueryLoginRecordRequest) (*LoginRecordSet, error)
+	SaveOperateRecord(*OperateLogData)
 }
 
 // NewQueryLoginRecordRequestFromHTTP 列表查询请求
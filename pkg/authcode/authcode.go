@@ -0,0 +1,150 @@
+package authcode
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/infraboard/mcube/exception"
+	"github.com/infraboard/mcube/types/ftime"
+
+	"github.com/infraboard/keyauth/pkg/application"
+	"github.com/infraboard/keyauth/pkg/token"
+	"github.com/infraboard/keyauth/pkg/user/types"
+)
+
+const (
+	// ResponseTypeCode 授权码模式
+	ResponseTypeCode = "code"
+	// DefaultExpiresSecond 授权码默认有效期, 协议建议最长10分钟
+	DefaultExpiresSecond = 5 * 60
+)
+
+// NewCode 为已登录用户颁发授权码
+func NewCode(req *IssueCodeRequest, app *application.Application) (*Code, error) {
+	if err := req.Validate(); err != nil {
+		return nil, exception.NewBadRequest(err.Error())
+	}
+
+	if app.Locked {
+		return nil, exception.NewBadRequest("application %s is locked", app.Name)
+	}
+
+	// 授权码只能发往应用注册的地址, 未注册时拒绝颁发, 防止授权码被发送到任意地址
+	if app.RedirectURI == "" {
+		return nil, exception.NewBadRequest("application %s has no registered redirect_uri", app.Name)
+	}
+	redirect := req.RedirectURI
+	if redirect == "" {
+		redirect = app.RedirectURI
+	}
+	if redirect != app.RedirectURI {
+		return nil, exception.NewBadRequest("redirect_uri not match application")
+	}
+	// 公开客户端无法保存secret, 必须使用PKCE
+	if app.ClientType == application.Public && req.CodeChallenge == "" {
+		return nil, exception.NewBadRequest("public client must use PKCE, code_challenge required")
+	}
+
+	tk := req.GetToken()
+	now := time.Now()
+	code := &Code{
+		Code:        token.MakeBearer(24),
+		ClientID:    req.ClientID,
+		RedirectURI: redirect,
+		Scope:       req.Scope,
+		State:       req.State,
+		Nonce:       req.Nonce,
+		Account:     tk.Account,
+		UserType:    tk.UserType,
+		Domain:      tk.Domain,
+		CreateAt:    ftime.T(now),
+		ExpiredAt:   ftime.T(now.Add(DefaultExpiresSecond * time.Second)),
+		ExpireAt:    now.Add(DefaultExpiresSecond * time.Second),
+
+		RedirectURISent: req.RedirectURI != "",
+
+		CodeChallenge:       req.CodeChallenge,
+		CodeChallengeMethod: req.CodeChallengeMethod,
+	}
+
+	return code, nil
+}
+
+// NewDefaultCode todo
+func NewDefaultCode() *Code {
+	return &Code{}
+}
+
+// Code 授权码: https://tools.ietf.org/html/rfc6749#section-4.1.2
+type Code struct {
+	Code        string     `bson:"_id" json:"code"`                        // 授权码
+	ClientID    string     `bson:"client_id" json:"client_id"`             // 颁发给哪个客户端
+	RedirectURI string     `bson:"redirect_uri" json:"redirect_uri"`       // 授权时的重定向地址, 换取token时必须一致
+	Scope       string     `bson:"scope" json:"scope,omitempty"`           // 授权范围
+	State       string     `bson:"state" json:"state,omitempty"`           // 客户端状态
+	Nonce       string     `bson:"nonce" json:"-"`                         // OIDC nonce
+	Account     string     `bson:"account" json:"account"`                 // 授权的用户
+	UserType    types.Type `bson:"user_type" json:"user_type,omitempty"`   // 用户类型
+	Domain      string     `bson:"domain" json:"domain,omitempty"`         // 用户所处域
+	CreateAt    ftime.Time `bson:"create_at" json:"create_at,omitempty"`   // 创建时间
+	ExpiredAt   ftime.Time `bson:"expired_at" json:"expired_at,omitempty"` // 过期时间
+	ExpireAt    time.Time  `bson:"expire_at" json:"-"`                     // 过期时间, 通过TTL索引清理未使用的授权码
+
+	RedirectURISent bool `bson:"redirect_uri_sent" json:"-"` // 授权时是否携带了redirect_uri, 携带时换取token也必须携带
+
+	CodeChallenge       string `bson:"code_challenge" json:"-"`        // PKCE challenge
+	CodeChallengeMethod string `bson:"code_challenge_method" json:"-"` // PKCE challenge 计算方式
+}
+
+// IsPKCE 授权时是否携带了code_challenge
+func (c *Code) IsPKCE() bool {
+	return c.CodeChallenge != ""
+}
+
+// IsExpired 授权码是否过期
+func (c *Code) IsExpired() bool {
+	return c.ExpiredAt.T().Before(time.Now())
+}
+
+// Check 校验授权码与换取请求是否匹配
+func (c *Code) Check(req *CheckCodeRequest) error {
+	if c.IsExpired() {
+		return fmt.Errorf("code expired")
+	}
+
+	if c.ClientID != req.ClientID {
+		return fmt.Errorf("code is not issue to client %s", req.ClientID)
+	}
+
+	// 授权时没有携带redirect_uri, 换取token时也可以不携带: https://tools.ietf.org/html/rfc6749#section-4.1.3
+	if (c.RedirectURISent || req.RedirectURI != "") && c.RedirectURI != req.RedirectURI {
+		return fmt.Errorf("redirect_uri not match")
+	}
+
+	if c.IsPKCE() {
+		return VerifyCodeChallenge(c.CodeChallenge, c.CodeChallengeMethod, req.CodeVerifier)
+	}
+	if req.CodeVerifier != "" {
+		return fmt.Errorf("code_verifier present but code has no code_challenge")
+	}
+
+	return nil
+}
+
+// RedirectURL 携带授权码的回调地址
+func (c *Code) RedirectURL() (string, error) {
+	u, err := url.Parse(c.RedirectURI)
+	if err != nil {
+		return "", err
+	}
+
+	qs := u.Query()
+	qs.Set("code", c.Code)
+	if c.State != "" {
+		qs.Set("state", c.State)
+	}
+	u.RawQuery = qs.Encode()
+
+	return u.String(), nil
+}
@@ -0,0 +1,74 @@
+package authcode_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/infraboard/keyauth/pkg/application"
+	"github.com/infraboard/keyauth/pkg/authcode"
+	"github.com/infraboard/keyauth/pkg/token"
+)
+
+const (
+	testRedirectURI = "https://app.example.com/callback"
+)
+
+func newTestApp(redirect string) *application.Application {
+	req := application.NewCreateApplicatonRequest()
+	req.Name = "test"
+	req.RedirectURI = redirect
+	app, _ := application.NewUserApplicartion("alice", req)
+	return app
+}
+
+func newTestIssueRequest(app *application.Application, redirect string) *authcode.IssueCodeRequest {
+	req := authcode.NewIssueCodeRequest()
+	req.WithToken(&token.Token{Account: "alice", Domain: "default"})
+	req.ClientID = app.ClientID
+	req.RedirectURI = redirect
+	req.CodeChallenge = rfcChallenge
+	req.CodeChallengeMethod = authcode.PKCEMethodS256
+	return req
+}
+
+func TestNewCodeRedirectURI(t *testing.T) {
+	should := require.New(t)
+
+	app := newTestApp(testRedirectURI)
+	code, err := authcode.NewCode(newTestIssueRequest(app, ""), app)
+	should.NoError(err)
+	should.Equal(testRedirectURI, code.RedirectURI)
+	should.Equal("alice", code.Account)
+
+	_, err = authcode.NewCode(newTestIssueRequest(app, "https://evil.example.com/callback"), app)
+	should.Error(err)
+
+	// 应用没有注册回调地址时, 不能发往调用方指定的地址
+	app = newTestApp("")
+	_, err = authcode.NewCode(newTestIssueRequest(app, "https://evil.example.com/callback"), app)
+	should.Error(err)
+	_, err = authcode.NewCode(newTestIssueRequest(app, ""), app)
+	should.Error(err)
+}
+
+func TestCodeCheck(t *testing.T) {
+	should := require.New(t)
+
+	app := newTestApp(testRedirectURI)
+	code, err := authcode.NewCode(newTestIssueRequest(app, testRedirectURI), app)
+	should.NoError(err)
+
+	should.NoError(code.Check(authcode.NewCheckCodeRequest(code.Code, app.ClientID, testRedirectURI, rfcVerifier)))
+	should.Error(code.Check(authcode.NewCheckCodeRequest(code.Code, "other", testRedirectURI, rfcVerifier)))
+	should.Error(code.Check(authcode.NewCheckCodeRequest(code.Code, app.ClientID, testRedirectURI+"/x", rfcVerifier)))
+	should.Error(code.Check(authcode.NewCheckCodeRequest(code.Code, app.ClientID, testRedirectURI, "")))
+	should.Error(code.Check(authcode.NewCheckCodeRequest(code.Code, app.ClientID, "", rfcVerifier)))
+
+	// 授权时没有携带redirect_uri, 换取时也可以不携带, 携带时必须与注册的地址一致
+	code, err = authcode.NewCode(newTestIssueRequest(app, ""), app)
+	should.NoError(err)
+	should.NoError(code.Check(authcode.NewCheckCodeRequest(code.Code, app.ClientID, "", rfcVerifier)))
+	should.NoError(code.Check(authcode.NewCheckCodeRequest(code.Code, app.ClientID, testRedirectURI, rfcVerifier)))
+	should.Error(code.Check(authcode.NewCheckCodeRequest(code.Code, app.ClientID, testRedirectURI+"/x", rfcVerifier)))
+}
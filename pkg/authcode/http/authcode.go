@@ -0,0 +1,48 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/infraboard/mcube/http/request"
+	"github.com/infraboard/mcube/http/response"
+
+	"github.com/infraboard/keyauth/pkg"
+	"github.com/infraboard/keyauth/pkg/authcode"
+)
+
+// Authorize 颁发授权码
+func (h *handler) Authorize(w http.ResponseWriter, r *http.Request) {
+	tk, err := pkg.GetTokenFromContext(r)
+	if err != nil {
+		response.Failed(w, err)
+		return
+	}
+
+	req := authcode.NewIssueCodeRequestFromHTTP(r)
+	if r.Method == http.MethodPost {
+		if err := request.GetDataFromRequest(r, req); err != nil {
+			response.Failed(w, err)
+			return
+		}
+	}
+	req.WithToken(tk)
+
+	code, err := h.service.IssueCode(req)
+	if err != nil {
+		response.Failed(w, err)
+		return
+	}
+
+	redirect, err := code.RedirectURL()
+	if err != nil {
+		response.Failed(w, err)
+		return
+	}
+
+	response.Success(w, map[string]string{
+		"code":         code.Code,
+		"state":        code.State,
+		"redirect_uri": redirect,
+	})
+	return
+}
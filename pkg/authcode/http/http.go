@@ -0,0 +1,40 @@
+package http
+
+import (
+	"errors"
+
+	"github.com/infraboard/mcube/http/router"
+
+	"github.com/infraboard/keyauth/pkg"
+	"github.com/infraboard/keyauth/pkg/authcode"
+)
+
+var (
+	api = &handler{}
+)
+
+type handler struct {
+	service authcode.Service
+}
+
+// Registry 注册HTTP服务路由
+func (h *handler) Registry(router router.SubRouter) {
+	r := router.ResourceRouter("authcode")
+	// 用户登录后为第三方应用授权
+	r.BasePath("/oauth2/authorize")
+	r.Handle("GET", "/", h.Authorize)
+	r.Handle("POST", "/", h.Authorize)
+}
+
+func (h *handler) Config() error {
+	if pkg.AuthCode == nil {
+		return errors.New("denpence authcode service is nil")
+	}
+
+	h.service = pkg.AuthCode
+	return nil
+}
+
+func init() {
+	pkg.RegistryHTTPV1("authcode", api)
+}
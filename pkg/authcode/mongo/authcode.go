@@ -0,0 +1,56 @@
+package mongo
+
+import (
+	"context"
+
+	"github.com/infraboard/mcube/exception"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/infraboard/keyauth/pkg/application"
+	"github.com/infraboard/keyauth/pkg/authcode"
+	"github.com/infraboard/keyauth/pkg/token"
+)
+
+func (s *service) IssueCode(req *authcode.IssueCodeRequest) (*authcode.Code, error) {
+	descApp := application.NewDescriptApplicationRequest()
+	descApp.ClientID = req.ClientID
+	app, err := s.app.DescriptionApplication(descApp)
+	if err != nil {
+		return nil, err
+	}
+
+	code, err := authcode.NewCode(req, app)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.col.InsertOne(context.TODO(), code); err != nil {
+		return nil, exception.NewInternalServerError("inserted code document error, %s", err)
+	}
+
+	return code, nil
+}
+
+func (s *service) CheckCode(req *authcode.CheckCodeRequest) (*authcode.Code, error) {
+	if err := req.Validate(); err != nil {
+		return nil, exception.NewBadRequest(err.Error())
+	}
+
+	// 查询的同时删除, 保证授权码只能被使用一次
+	code := authcode.NewDefaultCode()
+	err := s.col.FindOneAndDelete(context.TODO(), bson.M{"_id": req.Code}).Decode(code)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, token.NewInvalidGrantError("code not found or has been used")
+		}
+
+		return nil, exception.NewInternalServerError("find code error, %s", err)
+	}
+
+	if err := code.Check(req); err != nil {
+		return nil, token.NewInvalidGrantError("%s", err)
+	}
+
+	return code, nil
+}
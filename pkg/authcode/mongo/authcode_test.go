@@ -0,0 +1,76 @@
+package mongo_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/infraboard/keyauth/conf"
+	"github.com/infraboard/keyauth/pkg"
+	"github.com/infraboard/keyauth/pkg/application"
+	"github.com/infraboard/keyauth/pkg/authcode"
+	"github.com/infraboard/keyauth/pkg/authcode/mongo"
+	"github.com/infraboard/keyauth/pkg/token"
+)
+
+const (
+	testRedirectURI = "https://app.example.com/callback"
+	testVerifier    = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	testChallenge   = "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+)
+
+// appService 只实现查询应用, 其他方法不会被调用
+type appService struct {
+	application.Service
+	app *application.Application
+}
+
+func (s *appService) Config() error {
+	return nil
+}
+
+func (s *appService) DescriptionApplication(req *application.DescriptApplicationRequest) (*application.Application, error) {
+	return s.app, nil
+}
+
+// 需要通过K_MONGO_ENDPOINTS等环境变量指定测试使用的MongoDB
+func setup(t *testing.T) (authcode.Service, *application.Application) {
+	if os.Getenv("K_MONGO_ENDPOINTS") == "" {
+		t.Skip("K_MONGO_ENDPOINTS not set, skip mongo test")
+	}
+	should := require.New(t)
+	should.NoError(conf.LoadConfigFromEnv())
+
+	req := application.NewCreateApplicatonRequest()
+	req.Name = "test"
+	req.RedirectURI = testRedirectURI
+	app, err := application.NewUserApplicartion("alice", req)
+	should.NoError(err)
+
+	pkg.RegistryService("application", &appService{app: app})
+	should.NoError(mongo.Service.Config())
+	return mongo.Service, app
+}
+
+func TestCodeSingleUse(t *testing.T) {
+	svr, app := setup(t)
+	should := require.New(t)
+
+	req := authcode.NewIssueCodeRequest()
+	req.WithToken(&token.Token{Account: "alice", Domain: "default"})
+	req.ClientID = app.ClientID
+	req.CodeChallenge = testChallenge
+	req.CodeChallengeMethod = authcode.PKCEMethodS256
+	code, err := svr.IssueCode(req)
+	should.NoError(err)
+
+	check := authcode.NewCheckCodeRequest(code.Code, app.ClientID, testRedirectURI, testVerifier)
+	got, err := svr.CheckCode(check)
+	should.NoError(err)
+	should.Equal("alice", got.Account)
+
+	// 授权码只能换取一次
+	_, err = svr.CheckCode(check)
+	should.Error(err)
+}
@@ -0,0 +1,58 @@
+package authcode
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"regexp"
+)
+
+// PKCE code_challenge_method: https://tools.ietf.org/html/rfc7636#section-4.2
+const (
+	// PKCEMethodPlain code_challenge = code_verifier
+	PKCEMethodPlain = "plain"
+	// PKCEMethodS256 code_challenge = BASE64URL-ENCODE(SHA256(ASCII(code_verifier)))
+	PKCEMethodS256 = "S256"
+)
+
+var (
+	// code-verifier = 43*128unreserved
+	verifierRE = regexp.MustCompile(`^[A-Za-z0-9\-._~]{43,128}$`)
+)
+
+// ParsePKCEMethod 未指定时默认为plain
+func ParsePKCEMethod(method string) (string, error) {
+	switch method {
+	case "", PKCEMethodPlain:
+		return PKCEMethodPlain, nil
+	case PKCEMethodS256:
+		return PKCEMethodS256, nil
+	default:
+		return "", fmt.Errorf("unsupported code_challenge_method %s", method)
+	}
+}
+
+// VerifyCodeChallenge 校验code_verifier: https://tools.ietf.org/html/rfc7636#section-4.6
+func VerifyCodeChallenge(challenge, method, verifier string) error {
+	if !verifierRE.MatchString(verifier) {
+		return fmt.Errorf("invalid code_verifier format")
+	}
+
+	var computed string
+	switch method {
+	case PKCEMethodS256:
+		sum := sha256.Sum256([]byte(verifier))
+		computed = base64.RawURLEncoding.EncodeToString(sum[:])
+	case PKCEMethodPlain:
+		computed = verifier
+	default:
+		return fmt.Errorf("unsupported code_challenge_method %s", method)
+	}
+
+	if subtle.ConstantTimeCompare([]byte(computed), []byte(challenge)) != 1 {
+		return fmt.Errorf("code_verifier not match code_challenge")
+	}
+
+	return nil
+}
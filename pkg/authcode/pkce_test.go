@@ -0,0 +1,41 @@
+package authcode_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/infraboard/keyauth/pkg/authcode"
+)
+
+// https://tools.ietf.org/html/rfc7636#appendix-B
+const (
+	rfcVerifier  = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	rfcChallenge = "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+)
+
+func TestVerifyCodeChallengeS256(t *testing.T) {
+	should := require.New(t)
+
+	should.NoError(authcode.VerifyCodeChallenge(rfcChallenge, authcode.PKCEMethodS256, rfcVerifier))
+	should.Error(authcode.VerifyCodeChallenge(rfcChallenge, authcode.PKCEMethodS256, rfcVerifier+"x"))
+}
+
+func TestVerifyCodeChallengePlain(t *testing.T) {
+	should := require.New(t)
+
+	should.NoError(authcode.VerifyCodeChallenge(rfcVerifier, authcode.PKCEMethodPlain, rfcVerifier))
+	should.Error(authcode.VerifyCodeChallenge(rfcChallenge, authcode.PKCEMethodPlain, rfcVerifier))
+	should.Error(authcode.VerifyCodeChallenge("short", authcode.PKCEMethodPlain, "short"))
+}
+
+func TestParsePKCEMethod(t *testing.T) {
+	should := require.New(t)
+
+	m, err := authcode.ParsePKCEMethod("")
+	should.NoError(err)
+	should.Equal(authcode.PKCEMethodPlain, m)
+
+	_, err = authcode.ParsePKCEMethod("S512")
+	should.Error(err)
+}
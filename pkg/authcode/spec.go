@@ -0,0 +1,108 @@
+package authcode
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+
+	"github.com/infraboard/keyauth/pkg/token"
+)
+
+// use a single instance of Validate, it caches struct info
+var (
+	validate = validator.New()
+)
+
+// Service 授权码服务
+type Service interface {
+	IssueCode(req *IssueCodeRequest) (*Code, error)
+	CheckCode(req *CheckCodeRequest) (*Code, error)
+}
+
+// NewIssueCodeRequest todo
+func NewIssueCodeRequest() *IssueCodeRequest {
+	return &IssueCodeRequest{
+		Session:      token.NewSession(),
+		ResponseType: ResponseTypeCode,
+	}
+}
+
+// NewIssueCodeRequestFromHTTP 从URL参数中获取请求
+func NewIssueCodeRequestFromHTTP(r *http.Request) *IssueCodeRequest {
+	qs := r.URL.Query()
+	req := NewIssueCodeRequest()
+	if rt := qs.Get("response_type"); rt != "" {
+		req.ResponseType = rt
+	}
+	req.ClientID = qs.Get("client_id")
+	req.RedirectURI = qs.Get("redirect_uri")
+	req.Scope = qs.Get("scope")
+	req.State = qs.Get("state")
+	req.Nonce = qs.Get("nonce")
+	req.CodeChallenge = qs.Get("code_challenge")
+	req.CodeChallengeMethod = qs.Get("code_challenge_method")
+	return req
+}
+
+// IssueCodeRequest 授权请求: https://tools.ietf.org/html/rfc6749#section-4.1.1
+type IssueCodeRequest struct {
+	*token.Session      `json:"-"`
+	ResponseType        string `json:"response_type,omitempty" validate:"lte=20"`         // 固定为code
+	ClientID            string `json:"client_id,omitempty" validate:"required,lte=80"`    // 客户端ID
+	RedirectURI         string `json:"redirect_uri,omitempty" validate:"lte=200"`         // 重定向地址, 必须与应用注册时一致
+	Scope               string `json:"scope,omitempty" validate:"lte=100"`                // 申请的作用范围
+	State               string `json:"state,omitempty" validate:"lte=40"`                 // 客户端状态, 原样返回
+	Nonce               string `json:"nonce,omitempty" validate:"lte=200"`                // OIDC nonce, 原样写入id_token
+	CodeChallenge       string `json:"code_challenge,omitempty" validate:"lte=128"`       // PKCE: https://tools.ietf.org/html/rfc7636#section-4.3
+	CodeChallengeMethod string `json:"code_challenge_method,omitempty" validate:"lte=10"` // plain/S256, 默认plain
+}
+
+// Validate 校验请求
+func (req *IssueCodeRequest) Validate() error {
+	if req.GetToken() == nil {
+		return errors.New("token required")
+	}
+
+	if req.ResponseType != ResponseTypeCode {
+		return fmt.Errorf("unsupported response_type %s", req.ResponseType)
+	}
+
+	if _, err := token.ParseScope(req.Scope); err != nil {
+		return err
+	}
+
+	if req.CodeChallenge != "" {
+		method, err := ParsePKCEMethod(req.CodeChallengeMethod)
+		if err != nil {
+			return err
+		}
+		req.CodeChallengeMethod = method
+	}
+
+	return validate.Struct(req)
+}
+
+// NewCheckCodeRequest todo
+func NewCheckCodeRequest(code, clientID, redirectURI, verifier string) *CheckCodeRequest {
+	return &CheckCodeRequest{
+		Code:         code,
+		ClientID:     clientID,
+		RedirectURI:  redirectURI,
+		CodeVerifier: verifier,
+	}
+}
+
+// CheckCodeRequest 校验并使用授权码, 授权码只能使用一次
+type CheckCodeRequest struct {
+	Code         string `json:"code" validate:"required,lte=80"`
+	ClientID     string `json:"client_id" validate:"required,lte=80"`
+	RedirectURI  string `json:"redirect_uri" validate:"lte=200"`
+	CodeVerifier string `json:"code_verifier" validate:"lte=128"`
+}
+
+// Validate 校验请求
+func (req *CheckCodeRequest) Validate() error {
+	return validate.Struct(req)
+}
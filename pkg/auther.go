@@ -32,7 +32,7 @@ func (i *internal) Auth(r *http.Request, entry router.Entry) (
 	if entry.AuthEnable {
 		req := token.NewValidateTokenRequest()
 		// 获取需要校验的access token(用户的身份凭证)
-		accessToken := r.Header.Get("x-oauth-token")
+		accessToken := getAccessTokenFromHTTP(r)
 		if accessToken == "" {
 			return nil, exception.NewUnauthorized("x-oauth-token header required")
 		}
@@ -45,6 +45,15 @@ func (i *internal) Auth(r *http.Request, entry router.Entry) (
 	}
 
 	if entry.PermissionEnable && tk != nil {
+		// 令牌范围对所有用户生效, 包括超级管理员
+		scope, err := token.ParseScope(tk.Scope)
+		if err != nil {
+			return nil, exception.NewBadRequest("parse token scope error, %s", err)
+		}
+		if !scope.Allow(&entry) {
+			return nil, permission.NewDenyError(permission.DenyTokenScope, "token scope not allow %s", entry.Resource)
+		}
+
 		// 如果是超级管理员不做权限校验, 直接放行
 		if tk.UserType.Is(types.SupperAccount) {
 			return tk, nil
@@ -57,10 +66,22 @@ func (i *internal) Auth(r *http.Request, entry router.Entry) (
 		}
 
 		req := permission.NewCheckPermissionrequest()
+		req.WithToken(tk)
 		req.EnpointID = i.endpointHashID(entry)
+		req.RemoteIP = token.GetRemoteIPFromHTTP(r)
+
+		// 只有属于空间的端点需要指定空间, 其他端点使用域级别的策略
+		if permission.IsNamespaced(&entry) {
+			req.NamespaceID = getNamespaceFromHTTP(r)
+			if req.NamespaceID == "" {
+				return nil, permission.NewDenyError(permission.DenyNamespaceRequired,
+					"x-namespace-id header or namespace_id query param required")
+			}
+		}
+
 		_, err = Permission.CheckPermission(req)
 		if err != nil {
-			return nil, exception.NewPermissionDeny("no permission")
+			return nil, err
 		}
 	}
 
@@ -71,6 +92,31 @@ func (i *internal) endpointHashID(entry router.Entry) string {
 	return endpoint.GenHashID(version.ServiceName, entry.Path, entry.Method)
 }
 
+// getNamespaceFromHTTP 优先使用x-namespace-id, 其次使用namespace_id查询参数
+func getNamespaceFromHTTP(r *http.Request) string {
+	if ns := r.Header.Get("x-namespace-id"); ns != "" {
+		return ns
+	}
+
+	return r.URL.Query().Get("namespace_id")
+}
+
+// getAccessTokenFromHTTP 优先使用x-oauth-token, 兼容标准的Authorization: Bearer
+// https://tools.ietf.org/html/rfc6750#section-2.1
+func getAccessTokenFromHTTP(r *http.Request) string {
+	if tk := r.Header.Get("x-oauth-token"); tk != "" {
+		return tk
+	}
+
+	const prefix = "Bearer "
+	auth := r.Header.Get("Authorization")
+	if len(auth) > len(prefix) && strings.EqualFold(auth[:len(prefix)], prefix) {
+		return auth[len(prefix):]
+	}
+
+	return ""
+}
+
 // parseBasicAuth parses an HTTP Basic Authentication string.
 // "Basic QWxhZGRpbjpvcGVuIHNlc2FtZQ==" returns ("Aladdin", "open sesame", true).
 func parseBasicAuth(auth string) (username, password string, ok bool) {
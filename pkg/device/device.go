@@ -0,0 +1,181 @@
+package device
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/infraboard/mcube/exception"
+	"github.com/infraboard/mcube/types/ftime"
+
+	"github.com/infraboard/keyauth/pkg/application"
+	"github.com/infraboard/keyauth/pkg/token"
+	"github.com/infraboard/keyauth/pkg/user/types"
+)
+
+const (
+	// DefaultExpiresSecond 设备码默认有效期
+	DefaultExpiresSecond = 10 * 60
+	// DefaultIntervalSecond 设备默认轮询间隔
+	DefaultIntervalSecond = 5
+	// SlowDownIntervalSecond 轮询过快时, 每次增加的间隔: https://tools.ietf.org/html/rfc8628#section-3.5
+	SlowDownIntervalSecond = 5
+)
+
+const (
+	// 用户码不包含元音和容易混淆的字符, 方便用户输入: https://tools.ietf.org/html/rfc8628#section-6.1
+	userCodeCharset = "BCDFGHJKLMNPQRSTVWXZ"
+	userCodeLength  = 8
+)
+
+// Status 设备授权状态
+type Status string
+
+const (
+	// Pending 等待用户授权
+	Pending Status = "pending"
+	// Approved 用户已授权
+	Approved Status = "approved"
+	// Denied 用户拒绝授权
+	Denied Status = "denied"
+)
+
+// NewCode 为设备颁发设备码和用户码
+func NewCode(req *IssueDeviceCodeRequest, app *application.Application) (*Code, error) {
+	if err := req.Validate(); err != nil {
+		return nil, exception.NewBadRequest(err.Error())
+	}
+
+	if app.Locked {
+		return nil, exception.NewBadRequest("application %s is locked", app.Name)
+	}
+
+	userCode, err := MakeUserCode()
+	if err != nil {
+		return nil, exception.NewInternalServerError("make user code error, %s", err)
+	}
+
+	now := time.Now()
+	code := &Code{
+		DeviceCode: token.MakeBearer(32),
+		UserCode:   userCode,
+		ClientID:   req.ClientID,
+		Scope:      req.Scope,
+		Status:     Pending,
+		Interval:   DefaultIntervalSecond,
+		CreateAt:   ftime.T(now),
+		ExpiredAt:  ftime.T(now.Add(DefaultExpiresSecond * time.Second)),
+		ExpireAt:   now.Add(DefaultExpiresSecond * time.Second),
+	}
+
+	return code, nil
+}
+
+// MakeUserCode 生成用户码, 格式为 XXXX-XXXX
+func MakeUserCode() (string, error) {
+	max := big.NewInt(int64(len(userCodeCharset)))
+	b := make([]byte, userCodeLength)
+	for i := range b {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		b[i] = userCodeCharset[n.Int64()]
+	}
+
+	return FormatUserCode(string(b)), nil
+}
+
+// FormatUserCode 用户输入的用户码忽略大小写和分隔符
+func FormatUserCode(code string) string {
+	code = strings.ToUpper(code)
+	code = strings.NewReplacer("-", "", " ", "").Replace(code)
+	if len(code) != userCodeLength {
+		return code
+	}
+
+	return code[:userCodeLength/2] + "-" + code[userCodeLength/2:]
+}
+
+// NewDefaultCode todo
+func NewDefaultCode() *Code {
+	return &Code{}
+}
+
+// Code 设备码: https://tools.ietf.org/html/rfc8628#section-3.2
+type Code struct {
+	DeviceCode string     `bson:"_id" json:"-"`                               // 设备码, 设备轮询时使用
+	UserCode   string     `bson:"user_code" json:"user_code"`                 // 用户码, 用户在浏览器中输入
+	ClientID   string     `bson:"client_id" json:"client_id"`                 // 颁发给哪个客户端
+	Scope      string     `bson:"scope" json:"scope,omitempty"`               // 授权范围
+	Status     Status     `bson:"status" json:"status"`                       // 授权状态
+	Account    string     `bson:"account" json:"account,omitempty"`           // 授权的用户
+	UserType   types.Type `bson:"user_type" json:"user_type,omitempty"`       // 用户类型
+	Domain     string     `bson:"domain" json:"domain,omitempty"`             // 用户所处域
+	Interval   int64      `bson:"interval" json:"interval"`                   // 轮询间隔(秒)
+	LastPollAt ftime.Time `bson:"last_poll_at" json:"last_poll_at,omitempty"` // 最近一次轮询时间
+	CreateAt   ftime.Time `bson:"create_at" json:"create_at,omitempty"`       // 创建时间
+	ExpiredAt  ftime.Time `bson:"expired_at" json:"expired_at,omitempty"`     // 过期时间
+	ExpireAt   time.Time  `bson:"expire_at" json:"-"`                         // 过期时间, 通过TTL索引清理未轮询的设备码
+}
+
+// IsExpired 设备码是否过期
+func (c *Code) IsExpired() bool {
+	return c.ExpiredAt.T().Before(time.Now())
+}
+
+// Authorize 用户确认或者拒绝授权
+func (c *Code) Authorize(req *AuthorizeDeviceRequest) error {
+	if c.IsExpired() {
+		return fmt.Errorf("user code expired")
+	}
+
+	if c.Status != Pending {
+		return fmt.Errorf("user code has been %s", c.Status)
+	}
+
+	if req.Deny {
+		c.Status = Denied
+		return nil
+	}
+
+	tk := req.GetToken()
+	c.Status = Approved
+	c.Account = tk.Account
+	c.UserType = tk.UserType
+	c.Domain = tk.Domain
+	return nil
+}
+
+// NewAuthorization 设备授权的响应, 所有地址都基于校验页面
+func NewAuthorization(c *Code, verificationURI string) *Authorization {
+	resp := &Authorization{
+		DeviceCode:      c.DeviceCode,
+		UserCode:        c.UserCode,
+		VerificationURI: verificationURI,
+		ExpiresIn:       int64(time.Until(c.ExpiredAt.T()).Seconds()),
+		Interval:        c.Interval,
+	}
+
+	if u, err := url.Parse(verificationURI); err == nil {
+		qs := u.Query()
+		qs.Set("user_code", c.UserCode)
+		u.RawQuery = qs.Encode()
+		resp.VerificationURIComplete = u.String()
+	}
+
+	return resp
+}
+
+// Authorization https://tools.ietf.org/html/rfc8628#section-3.2
+type Authorization struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete,omitempty"`
+	ExpiresIn               int64  `json:"expires_in"`
+	Interval                int64  `json:"interval"`
+}
@@ -0,0 +1,26 @@
+package device_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/infraboard/keyauth/pkg/device"
+)
+
+func TestMakeUserCode(t *testing.T) {
+	should := require.New(t)
+
+	code, err := device.MakeUserCode()
+	should.NoError(err)
+	should.Len(code, 9)
+	should.Equal(code, device.FormatUserCode(code))
+}
+
+func TestFormatUserCode(t *testing.T) {
+	should := require.New(t)
+
+	should.Equal("WDJB-MJHT", device.FormatUserCode("wdjbmjht"))
+	should.Equal("WDJB-MJHT", device.FormatUserCode("wdjb-mjht"))
+	should.Equal("WDJB-MJHT", device.FormatUserCode(" WDJB MJHT"))
+}
@@ -0,0 +1,81 @@
+package http
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/infraboard/mcube/http/request"
+	"github.com/infraboard/mcube/http/response"
+
+	"github.com/infraboard/keyauth/conf"
+	"github.com/infraboard/keyauth/pkg"
+	"github.com/infraboard/keyauth/pkg/device"
+	"github.com/infraboard/keyauth/pkg/token"
+)
+
+// DeviceAuthorization 为设备颁发设备码和用户码
+func (h *handler) DeviceAuthorization(w http.ResponseWriter, r *http.Request) {
+	req, err := device.NewIssueDeviceCodeRequestFromForm(r)
+	if err != nil {
+		token.WriteOAuthError(w, token.NewInvalidRequestError("%s", err))
+		return
+	}
+
+	code, err := h.service.IssueDeviceCode(req)
+	if err != nil {
+		token.WriteOAuthError(w, err)
+		return
+	}
+
+	token.WriteOAuthJSON(w, http.StatusOK, device.NewAuthorization(code, verificationURI()))
+	return
+}
+
+// DescribeUserCode 查询用户码对应的待授权设备, 用于授权确认页面展示
+func (h *handler) DescribeUserCode(w http.ResponseWriter, r *http.Request) {
+	req := device.NewDescribeUserCodeRequest(r.URL.Query().Get("user_code"))
+
+	code, err := h.service.DescribeUserCode(req)
+	if err != nil {
+		response.Failed(w, err)
+		return
+	}
+
+	response.Success(w, code)
+	return
+}
+
+// AuthorizeDevice 用户确认或者拒绝设备授权
+func (h *handler) AuthorizeDevice(w http.ResponseWriter, r *http.Request) {
+	tk, err := pkg.GetTokenFromContext(r)
+	if err != nil {
+		response.Failed(w, err)
+		return
+	}
+
+	req := device.NewAuthorizeDeviceRequest()
+	if err := request.GetDataFromRequest(r, req); err != nil {
+		response.Failed(w, err)
+		return
+	}
+	req.WithToken(tk)
+
+	code, err := h.service.AuthorizeDevice(req)
+	if err != nil {
+		response.Failed(w, err)
+		return
+	}
+
+	response.Success(w, code)
+	return
+}
+
+// verificationURI 未配置前端页面时, 使用授权API的地址
+func verificationURI() string {
+	c := conf.C()
+	if c.OIDC.DeviceVerificationURL != "" {
+		return c.OIDC.DeviceVerificationURL
+	}
+
+	return strings.TrimSuffix(c.JWT.Issuer, "/") + "/oauth2/device"
+}
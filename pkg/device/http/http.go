@@ -0,0 +1,45 @@
+package http
+
+import (
+	"errors"
+
+	"github.com/infraboard/mcube/http/label"
+	"github.com/infraboard/mcube/http/router"
+
+	"github.com/infraboard/keyauth/pkg"
+	"github.com/infraboard/keyauth/pkg/device"
+)
+
+var (
+	api = &handler{}
+)
+
+type handler struct {
+	service device.Service
+}
+
+// Registry 注册HTTP服务路由
+func (h *handler) Registry(router router.SubRouter) {
+	r := router.ResourceRouter("device")
+	// 设备申请授权, 标准协议接口, 表单格式请求
+	r.BasePath("/oauth2/device_authorization")
+	r.Handle("POST", "/", h.DeviceAuthorization).DisableAuth()
+
+	// 用户登录后输入用户码, 为设备授权
+	r.BasePath("/oauth2/device")
+	r.Handle("GET", "/", h.DescribeUserCode).AddLabel(label.Get)
+	r.Handle("POST", "/", h.AuthorizeDevice).AddLabel(label.Update)
+}
+
+func (h *handler) Config() error {
+	if pkg.Device == nil {
+		return errors.New("denpence device service is nil")
+	}
+
+	h.service = pkg.Device
+	return nil
+}
+
+func init() {
+	pkg.RegistryHTTPV1("device", api)
+}
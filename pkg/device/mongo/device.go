@@ -0,0 +1,176 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"github.com/infraboard/mcube/exception"
+	"github.com/infraboard/mcube/types/ftime"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/infraboard/keyauth/pkg/application"
+	"github.com/infraboard/keyauth/pkg/device"
+	"github.com/infraboard/keyauth/pkg/token"
+)
+
+func (s *service) IssueDeviceCode(req *device.IssueDeviceCodeRequest) (*device.Code, error) {
+	descApp := application.NewDescriptApplicationRequest()
+	descApp.ClientID = req.ClientID
+	app, err := s.app.DescriptionApplication(descApp)
+	if err != nil {
+		return nil, token.NewInvalidClientError("%s", err)
+	}
+
+	// 公开客户端无法保存secret, 机密客户端必须认证
+	if req.ClientSecret != "" || app.ClientType != application.Public {
+		if err := app.CheckClientSecret(req.ClientSecret); err != nil {
+			return nil, token.NewInvalidClientError("%s", err)
+		}
+	}
+
+	code, err := device.NewCode(req, app)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.col.InsertOne(context.TODO(), code); err != nil {
+		return nil, exception.NewInternalServerError("inserted device code document error, %s", err)
+	}
+
+	return code, nil
+}
+
+func (s *service) DescribeUserCode(req *device.DescribeUserCodeRequest) (*device.Code, error) {
+	if err := req.Validate(); err != nil {
+		return nil, exception.NewBadRequest(err.Error())
+	}
+
+	code, err := s.describeUserCode(req.UserCode)
+	if err != nil {
+		return nil, err
+	}
+
+	if code.IsExpired() {
+		return nil, exception.NewBadRequest("user code expired")
+	}
+
+	return code, nil
+}
+
+func (s *service) AuthorizeDevice(req *device.AuthorizeDeviceRequest) (*device.Code, error) {
+	if err := req.Validate(); err != nil {
+		return nil, exception.NewBadRequest(err.Error())
+	}
+
+	code, err := s.describeUserCode(req.UserCode)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := code.Authorize(req); err != nil {
+		return nil, exception.NewBadRequest(err.Error())
+	}
+
+	// 只有等待授权的设备码可以被确认, 防止重复授权
+	filter := bson.M{"_id": code.DeviceCode, "status": device.Pending}
+	update := bson.M{"$set": bson.M{
+		"status":    code.Status,
+		"account":   code.Account,
+		"user_type": code.UserType,
+		"domain":    code.Domain,
+	}}
+	resp, err := s.col.UpdateOne(context.TODO(), filter, update)
+	if err != nil {
+		return nil, exception.NewInternalServerError("update device code error, %s", err)
+	}
+	if resp.MatchedCount == 0 {
+		return nil, exception.NewBadRequest("user code has been used")
+	}
+
+	return code, nil
+}
+
+func (s *service) CheckDeviceCode(req *device.CheckDeviceCodeRequest) (*device.Code, error) {
+	if err := req.Validate(); err != nil {
+		return nil, token.NewInvalidRequestError("%s", err)
+	}
+
+	code := device.NewDefaultCode()
+	if err := s.col.FindOne(context.TODO(), bson.M{"_id": req.DeviceCode}).Decode(code); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, token.NewInvalidGrantError("device_code not found or has been used")
+		}
+
+		return nil, exception.NewInternalServerError("find device code error, %s", err)
+	}
+
+	if code.ClientID != req.ClientID {
+		return nil, token.NewInvalidGrantError("device_code is not issue to client %s", req.ClientID)
+	}
+
+	if code.IsExpired() {
+		s.deleteDeviceCode(code.DeviceCode)
+		return nil, token.NewExpiredTokenError("device_code expired")
+	}
+
+	switch code.Status {
+	case device.Denied:
+		s.deleteDeviceCode(code.DeviceCode)
+		return nil, token.NewAccessDeniedError("the user denied the authorization request")
+	case device.Approved:
+		// 查询的同时删除, 保证设备码只能换取一次令牌
+		filter := bson.M{"_id": code.DeviceCode, "status": device.Approved}
+		if err := s.col.FindOneAndDelete(context.TODO(), filter).Decode(code); err != nil {
+			if err == mongo.ErrNoDocuments {
+				return nil, token.NewInvalidGrantError("device_code has been used")
+			}
+
+			return nil, exception.NewInternalServerError("delete device code error, %s", err)
+		}
+		return code, nil
+	}
+
+	// 只有距离上次轮询超过间隔时才更新轮询时间, 并发轮询时只有一个请求能通过
+	now := time.Now()
+	filter := bson.M{
+		"_id":          code.DeviceCode,
+		"status":       device.Pending,
+		"interval":     code.Interval,
+		"last_poll_at": bson.M{"$lte": ftime.T(now.Add(-time.Duration(code.Interval) * time.Second))},
+	}
+	resp, err := s.col.UpdateOne(context.TODO(), filter, bson.M{"$set": bson.M{"last_poll_at": ftime.T(now)}})
+	if err != nil {
+		return nil, exception.NewInternalServerError("update device code error, %s", err)
+	}
+
+	// 轮询过快时需要增大轮询间隔
+	if resp.MatchedCount == 0 {
+		slowDown := bson.M{"$inc": bson.M{"interval": device.SlowDownIntervalSecond}}
+		if _, err := s.col.UpdateOne(context.TODO(), bson.M{"_id": code.DeviceCode}, slowDown); err != nil {
+			return nil, exception.NewInternalServerError("update device code error, %s", err)
+		}
+		return nil, token.NewSlowDownError("polling too fast, interval must be at least %d seconds",
+			code.Interval+device.SlowDownIntervalSecond)
+	}
+
+	return nil, token.NewAuthorizationPendingError("the authorization request is still pending")
+}
+
+func (s *service) describeUserCode(userCode string) (*device.Code, error) {
+	code := device.NewDefaultCode()
+	filter := bson.M{"user_code": device.FormatUserCode(userCode)}
+	if err := s.col.FindOne(context.TODO(), filter).Decode(code); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, exception.NewNotFound("user code %s not found", userCode)
+		}
+
+		return nil, exception.NewInternalServerError("find user code error, %s", err)
+	}
+
+	return code, nil
+}
+
+func (s *service) deleteDeviceCode(deviceCode string) {
+	s.col.DeleteOne(context.TODO(), bson.M{"_id": deviceCode})
+}
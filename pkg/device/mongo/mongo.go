@@ -0,0 +1,62 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/x/bsonx"
+
+	"github.com/infraboard/keyauth/conf"
+	"github.com/infraboard/keyauth/pkg"
+	"github.com/infraboard/keyauth/pkg/application"
+	"github.com/infraboard/keyauth/pkg/device"
+)
+
+var (
+	// Service 服务实例
+	Service = &service{}
+)
+
+type service struct {
+	col *mongo.Collection
+	app application.Service
+}
+
+func (s *service) Config() error {
+	if pkg.Application == nil {
+		return errors.New("denpence application service is nil")
+	}
+	s.app = pkg.Application
+
+	db := conf.C().Mongo.GetDB()
+	col := db.Collection("device_code")
+
+	indexs := []mongo.IndexModel{
+		{
+			Keys:    bsonx.Doc{{Key: "user_code", Value: bsonx.Int32(-1)}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bsonx.Doc{{Key: "create_at", Value: bsonx.Int32(-1)}},
+		},
+		{
+			Keys:    bsonx.Doc{{Key: "expire_at", Value: bsonx.Int32(1)}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	}
+
+	_, err := col.Indexes().CreateMany(context.Background(), indexs)
+	if err != nil {
+		return err
+	}
+
+	s.col = col
+	return nil
+}
+
+func init() {
+	var _ device.Service = Service
+	pkg.RegistryService("device", Service)
+}
@@ -0,0 +1,120 @@
+package device
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+
+	"github.com/infraboard/keyauth/pkg/token"
+)
+
+// use a single instance of Validate, it caches struct info
+var (
+	validate = validator.New()
+)
+
+// Service 设备授权服务: https://tools.ietf.org/html/rfc8628
+type Service interface {
+	IssueDeviceCode(req *IssueDeviceCodeRequest) (*Code, error)
+	DescribeUserCode(req *DescribeUserCodeRequest) (*Code, error)
+	AuthorizeDevice(req *AuthorizeDeviceRequest) (*Code, error)
+	CheckDeviceCode(req *CheckDeviceCodeRequest) (*Code, error)
+}
+
+// NewIssueDeviceCodeRequest todo
+func NewIssueDeviceCodeRequest() *IssueDeviceCodeRequest {
+	return &IssueDeviceCodeRequest{}
+}
+
+// NewIssueDeviceCodeRequestFromForm 标准的表单格式请求: https://tools.ietf.org/html/rfc8628#section-3.1
+func NewIssueDeviceCodeRequestFromForm(r *http.Request) (*IssueDeviceCodeRequest, error) {
+	if err := r.ParseForm(); err != nil {
+		return nil, err
+	}
+
+	req := NewIssueDeviceCodeRequest()
+	var ok bool
+	req.ClientID, req.ClientSecret, ok = r.BasicAuth()
+	if !ok {
+		req.ClientID = r.PostForm.Get("client_id")
+		req.ClientSecret = r.PostForm.Get("client_secret")
+	}
+	req.Scope = r.PostForm.Get("scope")
+	return req, nil
+}
+
+// IssueDeviceCodeRequest 设备授权请求
+type IssueDeviceCodeRequest struct {
+	ClientID     string `json:"client_id,omitempty" validate:"required,lte=80"` // 客户端ID
+	ClientSecret string `json:"client_secret,omitempty" validate:"lte=80"`      // 客户端凭证, 公开客户端可以为空
+	Scope        string `json:"scope,omitempty" validate:"lte=100"`             // 申请的作用范围
+}
+
+// Validate 校验请求
+func (req *IssueDeviceCodeRequest) Validate() error {
+	if _, err := token.ParseScope(req.Scope); err != nil {
+		return err
+	}
+
+	return validate.Struct(req)
+}
+
+// NewDescribeUserCodeRequest todo
+func NewDescribeUserCodeRequest(userCode string) *DescribeUserCodeRequest {
+	return &DescribeUserCodeRequest{
+		UserCode: userCode,
+	}
+}
+
+// DescribeUserCodeRequest 用户输入设备上显示的用户码, 查询待授权的设备信息
+type DescribeUserCodeRequest struct {
+	UserCode string `json:"user_code" validate:"required,lte=20"`
+}
+
+// Validate 校验请求
+func (req *DescribeUserCodeRequest) Validate() error {
+	return validate.Struct(req)
+}
+
+// NewAuthorizeDeviceRequest todo
+func NewAuthorizeDeviceRequest() *AuthorizeDeviceRequest {
+	return &AuthorizeDeviceRequest{
+		Session: token.NewSession(),
+	}
+}
+
+// AuthorizeDeviceRequest 已登录用户确认或者拒绝设备授权
+type AuthorizeDeviceRequest struct {
+	*token.Session `json:"-"`
+	UserCode       string `json:"user_code" validate:"required,lte=20"`
+	Deny           bool   `json:"deny"`
+}
+
+// Validate 校验请求
+func (req *AuthorizeDeviceRequest) Validate() error {
+	if req.GetToken() == nil {
+		return errors.New("token required")
+	}
+
+	return validate.Struct(req)
+}
+
+// NewCheckDeviceCodeRequest todo
+func NewCheckDeviceCodeRequest(deviceCode, clientID string) *CheckDeviceCodeRequest {
+	return &CheckDeviceCodeRequest{
+		DeviceCode: deviceCode,
+		ClientID:   clientID,
+	}
+}
+
+// CheckDeviceCodeRequest 设备轮询换取令牌, 授权完成后设备码只能使用一次
+type CheckDeviceCodeRequest struct {
+	DeviceCode string `json:"device_code" validate:"required,lte=80"`
+	ClientID   string `json:"client_id" validate:"required,lte=80"`
+}
+
+// Validate 校验请求
+func (req *CheckDeviceCodeRequest) Validate() error {
+	return validate.Struct(req)
+}
@@ -74,6 +74,24 @@ type Domain struct { // 域ID
 	*CreateDomainRequst `bson:",inline"`
 }
 
+// GetPasswordPolicy 域没有配置密码策略时, 使用默认策略
+func (d *Domain) GetPasswordPolicy() *PasswordPolicy {
+	if d.PasswordPolicy == nil {
+		return NewDefaultPasswordPolicy()
+	}
+
+	return d.PasswordPolicy
+}
+
+// GetLockoutPolicy 域没有配置锁定策略时, 使用默认策略
+func (d *Domain) GetLockoutPolicy() *LockoutPolicy {
+	if d.LockoutPolicy == nil {
+		return NewDefaultLockoutPolicy()
+	}
+
+	return d.LockoutPolicy
+}
+
 func (d *Domain) String() string {
 	return fmt.Sprint(*d)
 }
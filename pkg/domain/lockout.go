@@ -0,0 +1,53 @@
+package domain
+
+import (
+	"time"
+)
+
+// NewDefaultLockoutPolicy 默认策略: 15分钟内账号连续失败5次或者同一IP失败20次, 锁定15分钟, 再次锁定时时长翻倍, 最长1天
+func NewDefaultLockoutPolicy() *LockoutPolicy {
+	return &LockoutPolicy{
+		AccountThreshold: 5,
+		IPThreshold:      20,
+		WindowMinutes:    15,
+		LockMinutes:      15,
+		MaxLockMinutes:   24 * 60,
+	}
+}
+
+// LockoutPolicy 登录失败锁定策略
+type LockoutPolicy struct {
+	AccountThreshold int `bson:"account_threshold" json:"account_threshold" validate:"gte=0,lte=100"` // 统计窗口内账号失败达到该次数后锁定, 0表示不锁定账号
+	IPThreshold      int `bson:"ip_threshold" json:"ip_threshold" validate:"gte=0,lte=10000"`         // 统计窗口内来源IP失败达到该次数后锁定, 0表示不锁定IP
+	WindowMinutes    int `bson:"window_minutes" json:"window_minutes" validate:"gte=1,lte=1440"`      // 失败次数的统计窗口, 为0时失败次数无法累计, 相当于关闭锁定
+	LockMinutes      int `bson:"lock_minutes" json:"lock_minutes" validate:"gte=0,lte=43200"`         // 第一次锁定的时长, 之后每次锁定时长翻倍
+	MaxLockMinutes   int `bson:"max_lock_minutes" json:"max_lock_minutes" validate:"gte=0,lte=43200"` // 最长锁定时长
+}
+
+// Validate todo
+func (p *LockoutPolicy) Validate() error {
+	return validate.Struct(p)
+}
+
+// Window 失败次数的统计窗口
+func (p *LockoutPolicy) Window() time.Duration {
+	return time.Duration(p.WindowMinutes) * time.Minute
+}
+
+// LockDuration 第n次(从1开始)锁定的时长, 每次翻倍直到最长锁定时长
+func (p *LockoutPolicy) LockDuration(n int) time.Duration {
+	d := time.Duration(p.LockMinutes) * time.Minute
+	max := time.Duration(p.MaxLockMinutes) * time.Minute
+	if max < d {
+		max = d
+	}
+
+	for i := 1; i < n && d < max; i++ {
+		d *= 2
+	}
+	if d > max {
+		d = max
+	}
+
+	return d
+}
@@ -0,0 +1,88 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	// DefaultPasswordMinLength 没有配置策略时, 密码的最小长度
+	DefaultPasswordMinLength = 8
+)
+
+// NewDefaultPasswordPolicy 默认策略只限制长度, 保持对老用户的兼容
+func NewDefaultPasswordPolicy() *PasswordPolicy {
+	return &PasswordPolicy{
+		MinLength: DefaultPasswordMinLength,
+	}
+}
+
+// PasswordPolicy 域下所有用户的密码策略
+type PasswordPolicy struct {
+	MinLength        int      `bson:"min_length" json:"min_length" validate:"gte=0,lte=80"`         // 最小长度
+	RequireUppercase bool     `bson:"require_uppercase" json:"require_uppercase"`                   // 必须包含大写字母
+	RequireLowercase bool     `bson:"require_lowercase" json:"require_lowercase"`                   // 必须包含小写字母
+	RequireNumber    bool     `bson:"require_number" json:"require_number"`                         // 必须包含数字
+	RequireSymbol    bool     `bson:"require_symbol" json:"require_symbol"`                         // 必须包含特殊字符
+	BannedPasswords  []string `bson:"banned_passwords" json:"banned_passwords" validate:"lte=1000"` // 禁止使用的密码, 不区分大小写
+	HistoryCount     int      `bson:"history_count" json:"history_count" validate:"gte=0,lte=5"`    // 不允许与最近N次的密码重复, 每次比对都是一次bcrypt计算, 最多5次
+	MaxAgeDays       int      `bson:"max_age_days" json:"max_age_days" validate:"gte=0,lte=3650"`   // 密码有效天数, 0表示永不过期
+}
+
+// Validate todo
+func (p *PasswordPolicy) Validate() error {
+	return validate.Struct(p)
+}
+
+// Check 校验密码是否满足策略
+func (p *PasswordPolicy) Check(password string) error {
+	if len([]rune(password)) < p.MinLength {
+		return fmt.Errorf("password length must be at least %d", p.MinLength)
+	}
+
+	var upper, lower, number, symbol bool
+	for _, c := range password {
+		switch {
+		case unicode.IsUpper(c):
+			upper = true
+		case unicode.IsLower(c):
+			lower = true
+		case unicode.IsDigit(c):
+			number = true
+		case unicode.IsPunct(c) || unicode.IsSymbol(c):
+			symbol = true
+		}
+	}
+
+	missing := []string{}
+	if p.RequireUppercase && !upper {
+		missing = append(missing, "uppercase letter")
+	}
+	if p.RequireLowercase && !lower {
+		missing = append(missing, "lowercase letter")
+	}
+	if p.RequireNumber && !number {
+		missing = append(missing, "number")
+	}
+	if p.RequireSymbol && !symbol {
+		missing = append(missing, "symbol")
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("password must contain at least one %s", strings.Join(missing, ", "))
+	}
+
+	for i := range p.BannedPasswords {
+		if strings.EqualFold(p.BannedPasswords[i], password) {
+			return fmt.Errorf("password is too common, please choose another one")
+		}
+	}
+
+	return nil
+}
+
+// MaxAge 密码有效期, 0表示永不过期
+func (p *PasswordPolicy) MaxAge() time.Duration {
+	return time.Duration(p.MaxAgeDays) * 24 * time.Hour
+}
@@ -0,0 +1,32 @@
+package domain_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/infraboard/keyauth/pkg/domain"
+)
+
+func TestPasswordPolicy(t *testing.T) {
+	should := require.New(t)
+
+	p := domain.NewDefaultPasswordPolicy()
+	should.Error(p.Check("1234567"))
+	should.NoError(p.Check("12345678"))
+
+	p.RequireUppercase = true
+	p.RequireLowercase = true
+	p.RequireNumber = true
+	p.RequireSymbol = true
+	should.Error(p.Check("abcdefgh1"))
+	should.Error(p.Check("Abcdefgh1"))
+	should.NoError(p.Check("Abcdefgh1!"))
+
+	p.BannedPasswords = []string{"p@ssw0rD1"}
+	should.Error(p.Check("P@ssw0rd1"))
+
+	should.Equal(int64(0), int64(p.MaxAge()))
+	p.MaxAgeDays = 90
+	should.Equal(float64(90*24), p.MaxAge().Hours())
+}
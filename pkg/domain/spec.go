@@ -90,6 +90,9 @@ type CreateDomainRequst struct {
 	ContactsTitle  string `bson:"contacts_title" json:"contacts_title" validate:"lte=40"`   // 联系人职位
 	ContactsMobile string `bson:"contacts_mobile" json:"contacts_mobile" validate:"lte=20"` // 联系人电话
 	ContactsEmail  string `bson:"contacts_email" json:"contacts_email" validate:"lte=40"`   // 联系人邮箱
+
+	PasswordPolicy *PasswordPolicy `bson:"password_policy" json:"password_policy,omitempty"` // 密码策略, 为空时使用默认策略
+	LockoutPolicy  *LockoutPolicy  `bson:"lockout_policy" json:"lockout_policy,omitempty"`   // 登录失败锁定策略, 为空时使用默认策略
 }
 
 // Validate 校验请求是否合法
@@ -87,5 +87,9 @@ func (s *service) Registry(req *endpoint.RegistryRequest) error {
 		}
 	}
 
+	if err := s.perm.ExpireDecisionCache(); err != nil {
+		return exception.NewInternalServerError(err.Error())
+	}
+
 	return nil
 }
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"math/big"
 	"net"
+	"strings"
 )
 
 // AddressRange returns the first and last addresses in the given CIDR range.
@@ -68,3 +69,44 @@ func IntToIP(ipInt *big.Int, bits int) net.IP {
 	}
 	return net.IP(ret)
 }
+
+// ParseCIDR 解析网段, 单个IP地址视为只包含该地址的网段
+func ParseCIDR(s string) (*net.IPNet, error) {
+	if !strings.Contains(s, "/") {
+		ip := net.ParseIP(s)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid ip address %s", s)
+		}
+		if ip4 := ip.To4(); ip4 != nil {
+			return &net.IPNet{IP: ip4, Mask: net.CIDRMask(32, 32)}, nil
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}, nil
+	}
+
+	_, network, err := net.ParseCIDR(s)
+	if err != nil {
+		return nil, err
+	}
+
+	return network, nil
+}
+
+// ContainsIP 判断IP是否在任意一个网段之内
+func ContainsIP(cidrs []string, ipAddress string) (bool, error) {
+	ip := net.ParseIP(ipAddress)
+	if ip == nil {
+		return false, fmt.Errorf("invalid ip address %s", ipAddress)
+	}
+
+	for i := range cidrs {
+		network, err := ParseCIDR(cidrs[i])
+		if err != nil {
+			return false, err
+		}
+		if network.Contains(ip) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
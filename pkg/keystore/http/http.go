@@ -0,0 +1,45 @@
+package http
+
+import (
+	"errors"
+
+	"github.com/infraboard/mcube/http/label"
+	"github.com/infraboard/mcube/http/router"
+
+	"github.com/infraboard/keyauth/pkg"
+	"github.com/infraboard/keyauth/pkg/keystore"
+)
+
+var (
+	api = &handler{}
+)
+
+type handler struct {
+	service keystore.Service
+}
+
+// Registry 注册HTTP服务路由
+func (h *handler) Registry(router router.SubRouter) {
+	r := router.ResourceRouter("keystore")
+	// 公钥对外公开, 资源服务用于离线校验令牌
+	r.BasePath("/.well-known")
+	r.Handle("GET", "/jwks.json", h.JWKS).DisableAuth()
+
+	r.BasePath("keys")
+	r.Permission(true)
+	r.Handle("GET", "/", h.List).AddLabel(label.List)
+	r.Handle("POST", "/rotate", h.Rotate).AddLabel(label.Create)
+}
+
+func (h *handler) Config() error {
+	if pkg.KeyStore == nil {
+		return errors.New("denpence keystore service is nil")
+	}
+
+	h.service = pkg.KeyStore
+	return nil
+}
+
+func init() {
+	pkg.RegistryHTTPV1("keystore", api)
+}
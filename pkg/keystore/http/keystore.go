@@ -0,0 +1,80 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/infraboard/mcube/exception"
+	"github.com/infraboard/mcube/http/request"
+	"github.com/infraboard/mcube/http/response"
+
+	"github.com/infraboard/keyauth/pkg"
+	"github.com/infraboard/keyauth/pkg/keystore"
+	"github.com/infraboard/keyauth/pkg/user/types"
+)
+
+// JWKS 公钥集合, 按照RFC 7517直接返回, 不做统一包装
+func (h *handler) JWKS(w http.ResponseWriter, r *http.Request) {
+	set, err := h.service.JWKS()
+	if err != nil {
+		response.Failed(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	json.NewEncoder(w).Encode(set)
+	return
+}
+
+func (h *handler) List(w http.ResponseWriter, r *http.Request) {
+	req := keystore.NewQueryKeyRequest(request.NewPageRequestFromHTTP(r))
+	req.Status = keystore.Status(r.URL.Query().Get("status"))
+
+	set, err := h.service.QueryKey(req)
+	if err != nil {
+		response.Failed(w, err)
+		return
+	}
+
+	response.Success(w, set)
+	return
+}
+
+func (h *handler) Rotate(w http.ResponseWriter, r *http.Request) {
+	tk, err := pkg.GetTokenFromContext(r)
+	if err != nil {
+		response.Failed(w, err)
+		return
+	}
+
+	// 签名密钥为全局共享, 只有超级管理员可以轮换
+	if !tk.UserType.Is(types.SupperAccount) {
+		response.Failed(w, exception.NewPermissionDeny("only supper account can rotate signing key"))
+		return
+	}
+
+	// 请求体可以为空, 为空时使用默认算法
+	body, err := request.ReadBody(r)
+	if err != nil {
+		response.Failed(w, err)
+		return
+	}
+	req := keystore.NewRotateKeyRequest()
+	if len(body) > 0 {
+		if err := json.Unmarshal(body, req); err != nil {
+			response.Failed(w, err)
+			return
+		}
+	}
+	req.WithToken(tk)
+
+	k, err := h.service.RotateKey(req)
+	if err != nil {
+		response.Failed(w, err)
+		return
+	}
+
+	response.Success(w, k)
+	return
+}
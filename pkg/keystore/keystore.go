@@ -0,0 +1,110 @@
+package keystore
+
+import (
+	"encoding/base64"
+	"fmt"
+	"time"
+
+	"github.com/infraboard/mcube/crypto/cbc"
+	"github.com/infraboard/mcube/http/request"
+	"github.com/infraboard/mcube/types/ftime"
+
+	"github.com/infraboard/keyauth/pkg/token/jwt"
+)
+
+// Status 秘钥状态
+type Status string
+
+const (
+	// Active 当前用于签名的秘钥, 同一时刻只有一个
+	Active Status = "active"
+	// Retired 已退役, 仅用于校验退役前颁发的令牌
+	Retired Status = "retired"
+)
+
+// NewKey 私钥使用应用的key加密后存储
+func NewKey(k *jwt.Key, secret string) (*Key, error) {
+	priv, err := k.MarshalPrivateKeyPEM()
+	if err != nil {
+		return nil, err
+	}
+
+	cipher, err := cbc.Encrypt(priv, []byte(secret))
+	if err != nil {
+		return nil, fmt.Errorf("encrypt private key error, %s", err)
+	}
+
+	return &Key{
+		ID:         k.ID,
+		Algorithm:  k.Algorithm,
+		Status:     Active,
+		PrivateKey: base64.StdEncoding.EncodeToString(cipher),
+		CreateAt:   ftime.Now(),
+	}, nil
+}
+
+// NewDefaultKey todo
+func NewDefaultKey() *Key {
+	return &Key{}
+}
+
+// Key 存储的签名秘钥
+type Key struct {
+	ID         string        `bson:"_id" json:"id"`                          // kid
+	Algorithm  jwt.Algorithm `bson:"algorithm" json:"algorithm"`             // 签名算法
+	Status     Status        `bson:"status" json:"status"`                   // 秘钥状态
+	PrivateKey string        `bson:"private_key" json:"-"`                   // 加密后的私钥
+	CreateAt   ftime.Time    `bson:"create_at" json:"create_at,omitempty"`   // 创建时间
+	RetiredAt  ftime.Time    `bson:"retired_at" json:"retired_at,omitempty"` // 退役时间
+	ExpiredAt  ftime.Time    `bson:"expired_at" json:"expired_at,omitempty"` // 退役的秘钥保留到该时间, 之后被清理
+}
+
+// IsExpired 退役秘钥是否已经过了保留期
+func (k *Key) IsExpired() bool {
+	if k.Status != Retired {
+		return false
+	}
+
+	return k.ExpiredAt.T().Before(time.Now())
+}
+
+// Decrypt 解密私钥
+func (k *Key) Decrypt(secret string) (*jwt.Key, error) {
+	cipher, err := base64.StdEncoding.DecodeString(k.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	priv, err := cbc.Decrypt(cipher, []byte(secret))
+	if err != nil {
+		return nil, fmt.Errorf("decrypt private key %s error, %s", k.ID, err)
+	}
+
+	signer, err := jwt.ParsePrivateKeyPEM(priv)
+	if err != nil {
+		return nil, err
+	}
+
+	return jwt.NewKey(k.Algorithm, signer)
+}
+
+// NewKeySet todo
+func NewKeySet(req *request.PageRequest) *Set {
+	return &Set{
+		PageRequest: req,
+		Items:       []*Key{},
+	}
+}
+
+// Set 秘钥列表
+type Set struct {
+	*request.PageRequest
+
+	Total int64  `json:"total"`
+	Items []*Key `json:"items"`
+}
+
+// Add todo
+func (s *Set) Add(item *Key) {
+	s.Items = append(s.Items, item)
+}
@@ -0,0 +1,262 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/infraboard/mcube/exception"
+	"github.com/infraboard/mcube/types/ftime"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/infraboard/keyauth/pkg/keystore"
+	"github.com/infraboard/keyauth/pkg/token/jwt"
+)
+
+func (s *service) SigningKey() (*jwt.Key, error) {
+	s.mu.RLock()
+	active, loadAt := s.active, s.loadAt
+	s.mu.RUnlock()
+
+	if active != nil && time.Since(loadAt) < s.refreshInterval {
+		return active, nil
+	}
+
+	if err := s.reload(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.active == nil {
+		return nil, exception.NewInternalServerError("no active signing key")
+	}
+	return s.active, nil
+}
+
+func (s *service) VerifyKey(kid string) (*jwt.Key, error) {
+	s.mu.RLock()
+	k, ok := s.keys[kid]
+	loadAt := s.loadAt
+	s.mu.RUnlock()
+	if ok {
+		return k, nil
+	}
+
+	// 其他实例可能已经轮转了秘钥, 限制刷新频率, 防止伪造的kid击穿到数据库
+	if time.Since(loadAt) > 5*time.Second {
+		if err := s.reload(); err != nil {
+			return nil, err
+		}
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if k, ok := s.keys[kid]; ok {
+		return k, nil
+	}
+	return nil, fmt.Errorf("key %s not found", kid)
+}
+
+func (s *service) RotateKey(req *keystore.RotateKeyRequest) (*keystore.Key, error) {
+	if err := req.Validate(); err != nil {
+		return nil, exception.NewBadRequest(err.Error())
+	}
+
+	alg := s.alg
+	if req.Algorithm != "" {
+		alg = jwt.Algorithm(req.Algorithm)
+	}
+	k, err := jwt.GenerateKey(alg)
+	if err != nil {
+		return nil, exception.NewInternalServerError("generate key error, %s", err)
+	}
+
+	ins, err := s.saveActiveKey(k)
+	if err != nil {
+		return nil, err
+	}
+
+	// 清理过了保留期的秘钥
+	_, err = s.col.DeleteMany(context.TODO(), bson.M{
+		"status":     keystore.Retired,
+		"expired_at": bson.M{"$lt": ftime.Now()},
+	})
+	if err != nil {
+		s.log.Errorf("delete expired keys error, %s", err)
+	}
+
+	if err := s.reload(); err != nil {
+		return nil, err
+	}
+
+	s.log.Infof("signing key rotated, new key: %s", ins.ID)
+	return ins, nil
+}
+
+func (s *service) QueryKey(req *keystore.QueryKeyRequest) (*keystore.Set, error) {
+	filter := bson.M{}
+	if req.Status != "" {
+		filter["status"] = req.Status
+	}
+
+	pageSize := int64(req.PageSize)
+	skip := int64(req.PageSize) * int64(req.PageNumber-1)
+	opt := &options.FindOptions{
+		Sort:  bson.D{{Key: "create_at", Value: -1}},
+		Limit: &pageSize,
+		Skip:  &skip,
+	}
+
+	resp, err := s.col.Find(context.TODO(), filter, opt)
+	if err != nil {
+		return nil, exception.NewInternalServerError("find key error, error is %s", err)
+	}
+
+	set := keystore.NewKeySet(req.PageRequest)
+	for resp.Next(context.TODO()) {
+		ins := keystore.NewDefaultKey()
+		if err := resp.Decode(ins); err != nil {
+			return nil, exception.NewInternalServerError("decode key error, error is %s", err)
+		}
+		set.Add(ins)
+	}
+
+	count, err := s.col.CountDocuments(context.TODO(), filter)
+	if err != nil {
+		return nil, exception.NewInternalServerError("get key count error, error is %s", err)
+	}
+	set.Total = count
+
+	return set, nil
+}
+
+func (s *service) JWKS() (*jwt.JWKSet, error) {
+	if _, err := s.SigningKey(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	set := jwt.NewJWKSet()
+	for _, k := range s.keys {
+		jwk, err := jwt.NewJWK(k)
+		if err != nil {
+			return nil, exception.NewInternalServerError(err.Error())
+		}
+		set.Add(jwk)
+	}
+
+	return set, nil
+}
+
+// saveActiveKey 先写入新秘钥再退役老秘钥, 保证任意时刻都有可用的签名秘钥
+func (s *service) saveActiveKey(k *jwt.Key) (*keystore.Key, error) {
+	ins, err := keystore.NewKey(k, s.secret)
+	if err != nil {
+		return nil, exception.NewInternalServerError(err.Error())
+	}
+
+	if _, err := s.col.InsertOne(context.TODO(), ins); err != nil {
+		return nil, exception.NewInternalServerError("inserted key(%s) document error, %s", ins.ID, err)
+	}
+
+	now := time.Now()
+	_, err = s.col.UpdateMany(context.TODO(),
+		bson.M{"status": keystore.Active, "_id": bson.M{"$ne": ins.ID}},
+		bson.M{"$set": bson.M{
+			"status":     keystore.Retired,
+			"retired_at": ftime.T(now),
+			"expired_at": ftime.T(now.Add(s.retain)),
+		}},
+	)
+	if err != nil {
+		return nil, exception.NewInternalServerError("retire old keys error, %s", err)
+	}
+
+	return ins, nil
+}
+
+func (s *service) ensureActiveKey(privateKeyFile string) error {
+	count, err := s.col.CountDocuments(context.TODO(), bson.M{"status": keystore.Active})
+	if err != nil {
+		return fmt.Errorf("count active key error, %s", err)
+	}
+	if count > 0 {
+		return s.reload()
+	}
+
+	var k *jwt.Key
+	if privateKeyFile != "" {
+		data, err := ioutil.ReadFile(privateKeyFile)
+		if err != nil {
+			return fmt.Errorf("read jwt private key file error, %s", err)
+		}
+		priv, err := jwt.ParsePrivateKeyPEM(data)
+		if err != nil {
+			return fmt.Errorf("parse jwt private key error, %s", err)
+		}
+		k, err = jwt.NewKey(s.alg, priv)
+		if err != nil {
+			return err
+		}
+		s.log.Infof("import signing key from %s", privateKeyFile)
+	} else {
+		k, err = jwt.GenerateKey(s.alg)
+		if err != nil {
+			return err
+		}
+		s.log.Infof("generate new signing key")
+	}
+
+	if _, err := s.saveActiveKey(k); err != nil {
+		return err
+	}
+
+	return s.reload()
+}
+
+// reload 从库中加载生效和保留期内的秘钥
+func (s *service) reload() error {
+	resp, err := s.col.Find(context.TODO(),
+		bson.M{"status": bson.M{"$in": []keystore.Status{keystore.Active, keystore.Retired}}},
+		options.Find().SetSort(bson.D{{Key: "create_at", Value: -1}}),
+	)
+	if err != nil {
+		return exception.NewInternalServerError("find keys error, %s", err)
+	}
+
+	var active *jwt.Key
+	keys := map[string]*jwt.Key{}
+	for resp.Next(context.TODO()) {
+		ins := keystore.NewDefaultKey()
+		if err := resp.Decode(ins); err != nil {
+			return exception.NewInternalServerError("decode key error, %s", err)
+		}
+		if ins.IsExpired() {
+			continue
+		}
+
+		k, err := ins.Decrypt(s.secret)
+		if err != nil {
+			s.log.Errorf("load key %s error, %s", ins.ID, err)
+			continue
+		}
+		keys[k.ID] = k
+
+		// 按创建时间倒序, 轮转过程中短暂存在多个生效秘钥时使用最新的
+		if ins.Status == keystore.Active && active == nil {
+			active = k
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = keys
+	s.active = active
+	s.loadAt = time.Now()
+	return nil
+}
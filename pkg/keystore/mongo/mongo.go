@@ -0,0 +1,77 @@
+package mongo
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/infraboard/mcube/logger"
+	"github.com/infraboard/mcube/logger/zap"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/x/bsonx"
+
+	"github.com/infraboard/keyauth/conf"
+	"github.com/infraboard/keyauth/pkg"
+	"github.com/infraboard/keyauth/pkg/keystore"
+	"github.com/infraboard/keyauth/pkg/token/jwt"
+)
+
+var (
+	// Service 服务实例
+	Service = &service{}
+)
+
+type service struct {
+	col    *mongo.Collection
+	log    logger.Logger
+	secret string
+	alg    jwt.Algorithm
+	retain time.Duration
+
+	// 秘钥缓存, 多实例部署时定期从库中刷新
+	mu              sync.RWMutex
+	active          *jwt.Key
+	keys            map[string]*jwt.Key
+	loadAt          time.Time
+	refreshInterval time.Duration
+}
+
+func (s *service) Config() error {
+	c := conf.C()
+	alg, err := jwt.ParseAlgorithm(c.JWT.Algorithm)
+	if err != nil {
+		return err
+	}
+	s.alg = alg
+	s.secret = c.App.Key
+	s.retain = time.Duration(c.JWT.KeyRetainSecond) * time.Second
+	s.refreshInterval = time.Minute
+	s.keys = map[string]*jwt.Key{}
+	s.log = zap.L().Named("KeyStore")
+
+	db := c.Mongo.GetDB()
+	col := db.Collection("keystore")
+
+	indexs := []mongo.IndexModel{
+		{
+			Keys: bsonx.Doc{
+				{Key: "status", Value: bsonx.Int32(-1)},
+				{Key: "create_at", Value: bsonx.Int32(-1)},
+			},
+		},
+	}
+
+	_, err = col.Indexes().CreateMany(context.Background(), indexs)
+	if err != nil {
+		return err
+	}
+	s.col = col
+
+	// 首次启动时初始化签名秘钥
+	return s.ensureActiveKey(c.JWT.PrivateKeyFile)
+}
+
+func init() {
+	var _ keystore.Service = Service
+	pkg.RegistryService("keystore", Service)
+}
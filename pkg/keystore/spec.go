@@ -0,0 +1,59 @@
+package keystore
+
+import (
+	"github.com/go-playground/validator/v10"
+	"github.com/infraboard/mcube/http/request"
+
+	"github.com/infraboard/keyauth/pkg/token"
+	"github.com/infraboard/keyauth/pkg/token/jwt"
+)
+
+// use a single instance of Validate, it caches struct info
+var (
+	validate = validator.New()
+)
+
+// Service 令牌签名秘钥管理
+type Service interface {
+	jwt.KeyProvider
+	RotateKey(req *RotateKeyRequest) (*Key, error)
+	QueryKey(req *QueryKeyRequest) (*Set, error)
+	JWKS() (*jwt.JWKSet, error)
+}
+
+// NewRotateKeyRequest todo
+func NewRotateKeyRequest() *RotateKeyRequest {
+	return &RotateKeyRequest{
+		Session: token.NewSession(),
+	}
+}
+
+// RotateKeyRequest 轮转签名秘钥, 新秘钥立即生效, 老秘钥退役后保留一段时间用于校验
+type RotateKeyRequest struct {
+	*token.Session `json:"-"`
+	Algorithm      string `json:"algorithm,omitempty" validate:"lte=10"` // 新秘钥的算法, 默认使用配置中的算法
+}
+
+// Validate todo
+func (req *RotateKeyRequest) Validate() error {
+	if req.Algorithm != "" {
+		if _, err := jwt.ParseAlgorithm(req.Algorithm); err != nil {
+			return err
+		}
+	}
+
+	return validate.Struct(req)
+}
+
+// NewQueryKeyRequest todo
+func NewQueryKeyRequest(page *request.PageRequest) *QueryKeyRequest {
+	return &QueryKeyRequest{
+		PageRequest: page,
+	}
+}
+
+// QueryKeyRequest 查询秘钥列表
+type QueryKeyRequest struct {
+	*request.PageRequest
+	Status Status `json:"status,omitempty"`
+}
@@ -0,0 +1,40 @@
+package http
+
+import (
+	"errors"
+
+	"github.com/infraboard/mcube/http/label"
+	"github.com/infraboard/mcube/http/router"
+
+	"github.com/infraboard/keyauth/pkg"
+	"github.com/infraboard/keyauth/pkg/lockout"
+)
+
+var (
+	api = &handler{}
+)
+
+type handler struct {
+	service lockout.Service
+}
+
+// Registry 注册HTTP服务路由
+func (h *handler) Registry(router router.SubRouter) {
+	r := router.ResourceRouter("lockout")
+	r.BasePath("lockouts")
+	r.Permission(true)
+	r.Handle("DELETE", "/accounts/:account", h.UnlockAccount).AddLabel(label.Delete)
+}
+
+func (h *handler) Config() error {
+	if pkg.Lockout == nil {
+		return errors.New("denpence lockout service is nil")
+	}
+
+	h.service = pkg.Lockout
+	return nil
+}
+
+func init() {
+	pkg.RegistryHTTPV1("lockout", api)
+}
@@ -0,0 +1,32 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/infraboard/mcube/http/context"
+	"github.com/infraboard/mcube/http/response"
+
+	"github.com/infraboard/keyauth/pkg"
+	"github.com/infraboard/keyauth/pkg/lockout"
+)
+
+// UnlockAccount 管理员解除账号锁定
+func (h *handler) UnlockAccount(w http.ResponseWriter, r *http.Request) {
+	tk, err := pkg.GetTokenFromContext(r)
+	if err != nil {
+		response.Failed(w, err)
+		return
+	}
+
+	rctx := context.GetContext(r)
+	req := lockout.NewUnlockAccountRequest(rctx.PS.ByName("account"))
+	req.WithToken(tk)
+
+	if err := h.service.UnlockAccount(req); err != nil {
+		response.Failed(w, err)
+		return
+	}
+
+	response.Success(w, "unlock ok")
+	return
+}
@@ -0,0 +1,81 @@
+package lockout
+
+import (
+	"time"
+
+	"github.com/infraboard/mcube/types/ftime"
+
+	"github.com/infraboard/keyauth/pkg/domain"
+)
+
+const (
+	// 最后一次失败或者锁定结束后, 记录保留的时长, 期间再次锁定时锁定时长继续递增
+	failureRetain = 24 * time.Hour
+)
+
+// Kind 失败记录的统计维度
+type Kind string
+
+const (
+	// AccountKind 按照账号统计
+	AccountKind Kind = "account"
+	// IPKind 按照来源IP统计
+	IPKind Kind = "ip"
+)
+
+// FailureID 失败记录的ID, 账号按所在域统计
+func FailureID(kind Kind, domain, key string) string {
+	return string(kind) + ":" + domain + ":" + key
+}
+
+// IPFailureID 来源IP不区分域全局统计, 防止轮换不同域的账号绕过IP锁定
+func IPFailureID(ip string) string {
+	return FailureID(IPKind, "", ip)
+}
+
+// NewFailure todo
+func NewFailure(kind Kind, domain, key string) *Failure {
+	return &Failure{
+		ID:     FailureID(kind, domain, key),
+		Kind:   kind,
+		Domain: domain,
+		Key:    key,
+	}
+}
+
+// Failure 登录失败记录, 保存在数据库中, 服务重启后不会丢失
+type Failure struct {
+	ID          string     `bson:"_id" json:"id"`
+	Kind        Kind       `bson:"kind" json:"kind"`                 // 统计维度
+	Domain      string     `bson:"domain" json:"domain"`             // 所处域
+	Key         string     `bson:"key" json:"key"`                   // 账号或者IP
+	Count       int        `bson:"count" json:"count"`               // 统计窗口内的失败次数
+	WindowStart ftime.Time `bson:"window_start" json:"window_start"` // 统计窗口的开始时间
+	LockCount   int        `bson:"lock_count" json:"lock_count"`     // 已经锁定的次数, 用于计算递增的锁定时长
+	LockedUntil ftime.Time `bson:"locked_until" json:"locked_until"` // 锁定的到期时间
+	ExpireAt    time.Time  `bson:"expire_at" json:"-"`               // 记录的过期时间, 通过TTL索引清理
+}
+
+// IsLocked 是否处于锁定中
+func (f *Failure) IsLocked() bool {
+	if f.LockedUntil.Timestamp() <= 0 {
+		return false
+	}
+
+	return time.Now().Before(f.LockedUntil.T())
+}
+
+// ReachThreshold 统计窗口内失败次数是否达到锁定阈值, 阈值为0时不锁定
+func (f *Failure) ReachThreshold(threshold int) bool {
+	return threshold > 0 && f.Count >= threshold
+}
+
+// NextLockDuration 本次锁定的时长, 多次锁定时递增
+func (f *Failure) NextLockDuration(policy *domain.LockoutPolicy) time.Duration {
+	return policy.LockDuration(f.LockCount + 1)
+}
+
+// RetainUntil 最后一次失败或者锁定结束后, 记录保留到的时间
+func RetainUntil(d time.Duration) time.Time {
+	return time.Now().Add(d + failureRetain)
+}
@@ -0,0 +1,50 @@
+package lockout_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/infraboard/keyauth/pkg/domain"
+	"github.com/infraboard/keyauth/pkg/lockout"
+)
+
+func TestProgressiveLockout(t *testing.T) {
+	should := require.New(t)
+
+	policy := domain.NewDefaultLockoutPolicy()
+	policy.AccountThreshold = 3
+	f := lockout.NewFailure(lockout.AccountKind, "default", "alice")
+
+	f.Count = 2
+	should.False(f.ReachThreshold(policy.AccountThreshold))
+	should.False(f.IsLocked())
+
+	f.Count = 3
+	should.True(f.ReachThreshold(policy.AccountThreshold))
+	should.Equal(15*time.Minute, f.NextLockDuration(policy))
+
+	// 再次锁定时锁定时长翻倍
+	f.LockCount = 1
+	should.Equal(30*time.Minute, f.NextLockDuration(policy))
+
+	should.Equal(24*time.Hour, policy.LockDuration(10))
+}
+
+func TestLockoutDisabled(t *testing.T) {
+	should := require.New(t)
+
+	policy := domain.NewDefaultLockoutPolicy()
+	policy.IPThreshold = 0
+	f := lockout.NewFailure(lockout.IPKind, "", "127.0.0.1")
+	f.Count = 100
+	should.False(f.ReachThreshold(policy.IPThreshold))
+}
+
+func TestIPFailureID(t *testing.T) {
+	should := require.New(t)
+
+	should.Equal(lockout.NewFailure(lockout.IPKind, "", "127.0.0.1").ID, lockout.IPFailureID("127.0.0.1"))
+	should.NotEqual(lockout.FailureID(lockout.AccountKind, "", "127.0.0.1"), lockout.IPFailureID("127.0.0.1"))
+}
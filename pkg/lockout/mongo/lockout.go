@@ -0,0 +1,366 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/infraboard/mcube/exception"
+	"github.com/infraboard/mcube/http/request"
+	"github.com/infraboard/mcube/types/ftime"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/infraboard/keyauth/pkg/audit"
+	"github.com/infraboard/keyauth/pkg/domain"
+	"github.com/infraboard/keyauth/pkg/lockout"
+	"github.com/infraboard/keyauth/pkg/token"
+	"github.com/infraboard/keyauth/pkg/user"
+	"github.com/infraboard/keyauth/pkg/user/types"
+)
+
+const (
+	lockAction   = "lockout"
+	unlockAction = "unlock"
+
+	duplicateKeyErrorCode = 11000
+)
+
+// target 登录的账号以及账号所在域的锁定策略, 账号不存在时使用默认策略
+type target struct {
+	user   *user.User
+	domain string
+	policy *domain.LockoutPolicy
+}
+
+func (s *service) CheckLogin(req *lockout.LoginRequest) error {
+	t, err := s.getTarget(req.Account)
+	if err != nil {
+		return err
+	}
+
+	if req.IP != "" {
+		f, err := s.getFailure(lockout.IPFailureID(req.IP))
+		if err != nil {
+			return err
+		}
+		if f.IsLocked() {
+			return exception.NewPermissionDeny("too many failed logins from %s, retry after %s",
+				req.IP, f.LockedUntil.T().Format(time.RFC3339))
+		}
+	}
+
+	if t.user != nil && t.user.Status.IsLocked() {
+		if t.user.Status.UnLockTime.Timestamp() <= 0 {
+			return exception.NewPermissionDeny("account %s is locked, %s", t.user.Account, t.user.Status.LockedReson)
+		}
+		return exception.NewPermissionDeny("account %s is locked, retry after %s",
+			t.user.Account, t.user.Status.UnLockTime.T().Format(time.RFC3339))
+	}
+
+	return nil
+}
+
+func (s *service) RecordFailure(req *lockout.LoginRequest) error {
+	t, err := s.getTarget(req.Account)
+	if err != nil {
+		return err
+	}
+
+	// 来源IP全局统计, 达到当前账号所在域的阈值时锁定
+	if req.IP != "" {
+		f, d, locked, err := s.incFailure(lockout.IPKind, "", req.IP, t.policy.IPThreshold, t.policy)
+		if err != nil {
+			return err
+		}
+		if locked {
+			s.saveLockLog(f, d)
+		}
+	}
+
+	// 不存在的账号没有锁定的对象, 只统计来源IP
+	if t.user == nil {
+		return nil
+	}
+
+	f, d, locked, err := s.incFailure(lockout.AccountKind, t.domain, t.user.Account, t.policy.AccountThreshold, t.policy)
+	if err != nil {
+		return err
+	}
+	if locked {
+		reason := fmt.Sprintf("too many failed logins, locked %s", d)
+		if err := s.user.LockAccount(t.user.Account, reason, d); err != nil {
+			return err
+		}
+		s.saveLockLog(f, d)
+	}
+
+	return nil
+}
+
+func (s *service) RecordSuccess(req *lockout.LoginRequest) error {
+	t, err := s.getTarget(req.Account)
+	if err != nil {
+		return err
+	}
+	if t.user == nil {
+		return nil
+	}
+
+	// 临时锁定已经到期, 清除冻结状态
+	if t.user.Status.Locked && !t.user.Status.IsLocked() {
+		if err := s.user.UnlockAccount(t.user.Account); err != nil {
+			return err
+		}
+	}
+
+	return s.deleteFailure(lockout.AccountKind, t.domain, t.user.Account)
+}
+
+func (s *service) UnlockAccount(req *lockout.UnlockAccountRequest) error {
+	if err := req.Validate(); err != nil {
+		return exception.NewBadRequest("check unlock account request error, %s", err)
+	}
+
+	t, err := s.getTarget(req.Account)
+	if err != nil {
+		return err
+	}
+	if t.user == nil {
+		return exception.NewNotFound("user %s not found", req.Account)
+	}
+
+	// 主账号只能解除自己域下的账号
+	tk := req.GetToken()
+	if tk.UserType.Is(types.PrimaryAccount) && t.domain != tk.Domain {
+		return exception.NewPermissionDeny("user %s not in domain %s", req.Account, tk.Domain)
+	}
+
+	// 管理员禁用的账号没有解冻时间, 不属于登录失败锁定
+	if t.user.Status.Locked && t.user.Status.UnLockTime.Timestamp() <= 0 {
+		return exception.NewBadRequest("account %s is blocked, not locked by failed logins", t.user.Account)
+	}
+
+	if err := s.user.UnlockAccount(t.user.Account); err != nil {
+		return err
+	}
+	if err := s.deleteFailure(lockout.AccountKind, t.domain, t.user.Account); err != nil {
+		return err
+	}
+
+	s.saveUnlockLog(tk, t)
+	return nil
+}
+
+func (s *service) getTarget(account string) (*target, error) {
+	t := &target{policy: domain.NewDefaultLockoutPolicy()}
+	if account == "" {
+		return t, nil
+	}
+
+	u, err := s.user.DescribeAccount(user.NewDescriptAccountRequestWithAccount(account))
+	if err != nil {
+		if exception.IsNotFoundError(err) {
+			return t, nil
+		}
+		return nil, err
+	}
+	t.user = u
+
+	d, err := s.getUserDomain(u)
+	if err != nil {
+		return nil, err
+	}
+	if d != nil {
+		t.domain = d.Name
+		t.policy = d.GetLockoutPolicy()
+	}
+
+	return t, nil
+}
+
+// getUserDomain 子账号使用所在的域, 主账号使用其创建的域
+func (s *service) getUserDomain(u *user.User) (*domain.Domain, error) {
+	if u.Domain != "" {
+		d, err := s.domain.DescriptionDomain(domain.NewDescriptDomainRequestWithName(u.Domain))
+		if err != nil {
+			if exception.IsNotFoundError(err) {
+				return nil, nil
+			}
+			return nil, err
+		}
+		return d, nil
+	}
+
+	if !u.Type.Is(types.SupperAccount, types.PrimaryAccount) {
+		return nil, nil
+	}
+
+	req := domain.NewQueryDomainRequest(request.NewPageRequest(1, 1))
+	req.WithToken(&token.Token{Account: u.Account})
+	domains, err := s.domain.QueryDomain(req)
+	if err != nil {
+		return nil, err
+	}
+	if domains.Length() == 0 {
+		return nil, nil
+	}
+
+	return domains.Items[0], nil
+}
+
+func (s *service) getFailure(id string) (*lockout.Failure, error) {
+	f := &lockout.Failure{ID: id}
+	if err := s.col.FindOne(context.TODO(), bson.M{"_id": id}).Decode(f); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return f, nil
+		}
+		return nil, exception.NewInternalServerError("find login failure %s error, %s", id, err)
+	}
+
+	return f, nil
+}
+
+// incFailure 原子的累加统计窗口内的失败次数, 达到阈值时锁定, 并发的失败请求不会丢失计数
+func (s *service) incFailure(kind lockout.Kind, domainName, key string, threshold int,
+	policy *domain.LockoutPolicy) (*lockout.Failure, time.Duration, bool, error) {
+	f, err := s.countFailure(lockout.NewFailure(kind, domainName, key), policy)
+	if err != nil {
+		return nil, 0, false, err
+	}
+	if !f.ReachThreshold(threshold) {
+		return f, 0, false, nil
+	}
+
+	return s.lockFailure(f, threshold, policy)
+}
+
+func (s *service) countFailure(f *lockout.Failure, policy *domain.LockoutPolicy) (*lockout.Failure, error) {
+	now := time.Now()
+	windowStart := ftime.T(now.Add(-policy.Window()))
+	retain := bson.M{"expire_at": lockout.RetainUntil(policy.Window())}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	// 统计窗口内的失败直接累加
+	err := s.col.FindOneAndUpdate(context.TODO(),
+		bson.M{"_id": f.ID, "window_start": bson.M{"$gte": windowStart}},
+		bson.M{"$inc": bson.M{"count": 1}, "$max": retain},
+		opts,
+	).Decode(f)
+	if err == nil {
+		return f, nil
+	}
+	if err != mongo.ErrNoDocuments {
+		return nil, exception.NewInternalServerError("inc login failure %s error, %s", f.ID, err)
+	}
+
+	// 没有记录或者窗口已过期时开启新的统计窗口, 并发开启时唯一索引冲突, 重新累加
+	err = s.col.FindOneAndUpdate(context.TODO(),
+		bson.M{"_id": f.ID, "window_start": bson.M{"$lt": windowStart}},
+		bson.M{
+			"$set":         bson.M{"count": 1, "window_start": ftime.T(now)},
+			"$setOnInsert": bson.M{"kind": f.Kind, "domain": f.Domain, "key": f.Key},
+			"$max":         retain,
+		},
+		opts.SetUpsert(true),
+	).Decode(f)
+	if err != nil {
+		if isDuplicateKeyError(err) {
+			return s.countFailure(f, policy)
+		}
+		return nil, exception.NewInternalServerError("start login failure %s window error, %s", f.ID, err)
+	}
+
+	return f, nil
+}
+
+// lockFailure 以锁定次数作为版本, 并发达到阈值时只有一个请求执行锁定
+func (s *service) lockFailure(f *lockout.Failure, threshold int,
+	policy *domain.LockoutPolicy) (*lockout.Failure, time.Duration, bool, error) {
+	d := f.NextLockDuration(policy)
+	err := s.col.FindOneAndUpdate(context.TODO(),
+		bson.M{"_id": f.ID, "count": bson.M{"$gte": threshold}, "lock_count": f.LockCount},
+		bson.M{
+			"$inc": bson.M{"lock_count": 1},
+			"$set": bson.M{
+				"count":        0,
+				"window_start": ftime.Time{},
+				"locked_until": ftime.T(time.Now().Add(d)),
+			},
+			"$max": bson.M{"expire_at": lockout.RetainUntil(d)},
+		},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(f)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return f, 0, false, nil
+		}
+		return nil, 0, false, exception.NewInternalServerError("lock login failure %s error, %s", f.ID, err)
+	}
+
+	return f, d, true, nil
+}
+
+// findAndModify的唯一索引冲突返回CommandError, 其他写操作返回WriteException
+func isDuplicateKeyError(err error) bool {
+	cmdErr, ok := err.(mongo.CommandError)
+	if ok {
+		return cmdErr.Code == duplicateKeyErrorCode
+	}
+
+	we, ok := err.(mongo.WriteException)
+	if !ok {
+		return false
+	}
+	for _, e := range we.WriteErrors {
+		if e.Code == duplicateKeyErrorCode {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s *service) deleteFailure(kind lockout.Kind, domainName, key string) error {
+	id := lockout.FailureID(kind, domainName, key)
+	if _, err := s.col.DeleteOne(context.TODO(), bson.M{"_id": id}); err != nil {
+		return exception.NewInternalServerError("delete login failure %s error, %s", id, err)
+	}
+
+	return nil
+}
+
+func (s *service) saveLockLog(f *lockout.Failure, d time.Duration) {
+	data := audit.NewDefaultOperateLogData()
+	data.ResourceType = string(f.Kind)
+	data.ResourceID = f.Key
+	data.ResourceName = f.Key
+	data.Action = lockAction
+	data.Result = audit.Failed
+	data.Comment = fmt.Sprintf("%s %s locked %s after too many failed logins, lock count %d", f.Kind, f.Key, d, f.LockCount)
+
+	// 审计日志需要通过令牌获取所处域
+	tk := &token.Token{Domain: f.Domain}
+	if f.Kind == lockout.AccountKind {
+		data.Account = f.Key
+		tk.Account = f.Key
+	}
+	data.WithToken(tk)
+	s.audit.SaveOperateRecord(data)
+}
+
+func (s *service) saveUnlockLog(tk *token.Token, t *target) {
+	data := audit.NewDefaultOperateLogData()
+	data.Account = tk.Account
+	data.ApplicationID = tk.ApplicationID
+	data.ApplicationName = tk.ApplicationName
+	data.ResourceType = string(lockout.AccountKind)
+	data.ResourceID = t.user.Account
+	data.ResourceName = t.user.Account
+	data.Action = unlockAction
+	data.Result = audit.Success
+	data.Comment = fmt.Sprintf("account %s unlocked by %s", t.user.Account, tk.Account)
+	data.WithToken(&token.Token{Domain: t.domain, Account: tk.Account})
+	s.audit.SaveOperateRecord(data)
+}
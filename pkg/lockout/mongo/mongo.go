@@ -0,0 +1,73 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+
+	"github.com/infraboard/mcube/logger"
+	"github.com/infraboard/mcube/logger/zap"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/x/bsonx"
+
+	"github.com/infraboard/keyauth/conf"
+	"github.com/infraboard/keyauth/pkg"
+	"github.com/infraboard/keyauth/pkg/audit"
+	"github.com/infraboard/keyauth/pkg/domain"
+	"github.com/infraboard/keyauth/pkg/lockout"
+	"github.com/infraboard/keyauth/pkg/user"
+)
+
+var (
+	// Service 服务实例
+	Service = &service{}
+)
+
+type service struct {
+	col    *mongo.Collection
+	log    logger.Logger
+	user   user.Service
+	domain domain.Service
+	audit  audit.Service
+}
+
+func (s *service) Config() error {
+	if pkg.User == nil {
+		return errors.New("denpence user service is nil")
+	}
+	s.user = pkg.User
+
+	if pkg.Domain == nil {
+		return errors.New("denpence domain service is nil")
+	}
+	s.domain = pkg.Domain
+
+	if pkg.Audit == nil {
+		return errors.New("denpence audit service is nil")
+	}
+	s.audit = pkg.Audit
+
+	db := conf.C().Mongo.GetDB()
+	col := db.Collection("login_failure")
+
+	indexs := []mongo.IndexModel{
+		{
+			Keys:    bsonx.Doc{{Key: "expire_at", Value: bsonx.Int32(1)}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	}
+
+	_, err := col.Indexes().CreateMany(context.Background(), indexs)
+	if err != nil {
+		return err
+	}
+
+	s.col = col
+	s.log = zap.L().Named("Lockout")
+	return nil
+}
+
+func init() {
+	var _ lockout.Service = Service
+	pkg.RegistryService("lockout", Service)
+}
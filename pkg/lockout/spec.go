@@ -0,0 +1,69 @@
+package lockout
+
+import (
+	"fmt"
+
+	"github.com/go-playground/validator/v10"
+
+	"github.com/infraboard/keyauth/pkg/token"
+	"github.com/infraboard/keyauth/pkg/user/types"
+)
+
+// use a single instance of Validate, it caches struct info
+var (
+	validate = validator.New()
+)
+
+// Service 登录失败锁定服务
+type Service interface {
+	// 登录前检查账号和来源IP是否已被锁定
+	CheckLogin(*LoginRequest) error
+	// 记录登录失败, 超过阈值后锁定账号或者来源IP
+	RecordFailure(*LoginRequest) error
+	// 登录成功后清除账号的失败记录
+	RecordSuccess(*LoginRequest) error
+	// 管理员解除账号锁定
+	UnlockAccount(*UnlockAccountRequest) error
+}
+
+// NewLoginRequest todo
+func NewLoginRequest(account, ip string) *LoginRequest {
+	return &LoginRequest{
+		Account: account,
+		IP:      ip,
+	}
+}
+
+// LoginRequest 登录的账号和来源IP, 没有账号的授权类型只统计来源IP
+type LoginRequest struct {
+	Account string `json:"account"`
+	IP      string `json:"ip"`
+}
+
+// NewUnlockAccountRequest todo
+func NewUnlockAccountRequest(account string) *UnlockAccountRequest {
+	return &UnlockAccountRequest{
+		Session: token.NewSession(),
+		Account: account,
+	}
+}
+
+// UnlockAccountRequest 解除账号锁定
+type UnlockAccountRequest struct {
+	*token.Session `json:"-"`
+	Account        string `json:"account" validate:"required,lte=60"`
+}
+
+// Validate 只有超级管理员和主账号可以解除锁定
+func (req *UnlockAccountRequest) Validate() error {
+	tk := req.GetToken()
+	if tk == nil {
+		return fmt.Errorf("token required")
+	}
+
+	if !tk.UserType.Is(types.SupperAccount, types.PrimaryAccount) {
+		return fmt.Errorf("%s user can't unlock account", tk.UserType)
+	}
+
+	return validate.Struct(req)
+}
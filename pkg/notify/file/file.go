@@ -0,0 +1,60 @@
+package file
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/infraboard/mcube/logger"
+	"github.com/infraboard/mcube/logger/zap"
+
+	"github.com/infraboard/keyauth/pkg/notify"
+)
+
+// NewNotifier 把通知追加写入文件, 每行一条JSON记录, path为空时输出到日志, 用于开发和测试
+func NewNotifier(path string) notify.Notifier {
+	return &notifier{
+		path: path,
+		log:  zap.L().Named("Notify"),
+	}
+}
+
+type notifier struct {
+	path string
+	log  logger.Logger
+	mu   sync.Mutex
+}
+
+type record struct {
+	*notify.Message
+	SendAt int64 `json:"send_at"`
+}
+
+func (n *notifier) Send(msg *notify.Message) error {
+	if n.path == "" {
+		n.log.Infof("[%s] to: %s, subject: %s, content: %s", msg.Channel, msg.To, msg.Subject, msg.Content)
+		return nil
+	}
+
+	data, err := json.Marshal(&record{Message: msg, SendAt: time.Now().Unix()})
+	if err != nil {
+		return err
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	f, err := os.OpenFile(n.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("open notify file error, %s", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("write notify file error, %s", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,43 @@
+package file_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/infraboard/keyauth/pkg/notify"
+	"github.com/infraboard/keyauth/pkg/notify/file"
+)
+
+func TestSend(t *testing.T) {
+	should := require.New(t)
+
+	dir, err := ioutil.TempDir("", "notify")
+	should.NoError(err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "notify.log")
+	n := file.NewNotifier(path)
+	should.NoError(n.Send(&notify.Message{Channel: notify.Email, To: "a@example.com", Content: "123456"}))
+	should.NoError(n.Send(&notify.Message{Channel: notify.SMS, To: "13800000000", Content: "654321"}))
+
+	f, err := os.Open(path)
+	should.NoError(err)
+	defer f.Close()
+
+	msgs := []*notify.Message{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		msg := &notify.Message{}
+		should.NoError(json.Unmarshal(scanner.Bytes(), msg))
+		msgs = append(msgs, msg)
+	}
+	should.Len(msgs, 2)
+	should.Equal("13800000000", msgs[1].To)
+	should.Equal("654321", msgs[1].Content)
+}
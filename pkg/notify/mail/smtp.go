@@ -0,0 +1,135 @@
+package mail
+
+import (
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"time"
+
+	"github.com/infraboard/keyauth/pkg/notify"
+)
+
+const (
+	// 465端口使用隐式TLS, 其他端口在服务端支持时使用STARTTLS
+	implicitTLSPort = 465
+)
+
+// Config SMTP服务配置
+type Config struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+// Validate todo
+func (c *Config) Validate() error {
+	if c.Host == "" || c.Port == 0 {
+		return errors.New("smtp host and port required")
+	}
+	if c.From == "" {
+		return errors.New("smtp from address required")
+	}
+
+	return nil
+}
+
+func (c *Config) addr() string {
+	return net.JoinHostPort(c.Host, strconv.Itoa(c.Port))
+}
+
+// NewNotifier 通过SMTP发送邮件通知
+func NewNotifier(conf *Config) (notify.Notifier, error) {
+	if err := conf.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &notifier{conf: conf}, nil
+}
+
+type notifier struct {
+	conf *Config
+}
+
+func (n *notifier) Send(msg *notify.Message) error {
+	if msg.To == "" {
+		return errors.New("mail recipient required")
+	}
+
+	body := BuildMessage(n.conf.From, msg.To, msg.Subject, msg.Content, time.Now())
+
+	var auth smtp.Auth
+	if n.conf.Username != "" {
+		auth = smtp.PlainAuth("", n.conf.Username, n.conf.Password, n.conf.Host)
+	}
+
+	if n.conf.Port != implicitTLSPort {
+		if err := smtp.SendMail(n.conf.addr(), auth, n.conf.From, []string{msg.To}, body); err != nil {
+			return fmt.Errorf("send mail error, %s", err)
+		}
+		return nil
+	}
+
+	return n.sendWithTLS(auth, msg.To, body)
+}
+
+func (n *notifier) sendWithTLS(auth smtp.Auth, to string, body []byte) error {
+	conn, err := tls.Dial("tcp", n.conf.addr(), &tls.Config{ServerName: n.conf.Host})
+	if err != nil {
+		return fmt.Errorf("dial smtp server error, %s", err)
+	}
+
+	c, err := smtp.NewClient(conn, n.conf.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("create smtp client error, %s", err)
+	}
+	defer c.Close()
+
+	if auth != nil {
+		if err := c.Auth(auth); err != nil {
+			return fmt.Errorf("smtp auth error, %s", err)
+		}
+	}
+	if err := c.Mail(n.conf.From); err != nil {
+		return err
+	}
+	if err := c.Rcpt(to); err != nil {
+		return err
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(body); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	return c.Quit()
+}
+
+// BuildMessage 构造纯文本邮件, 主题使用RFC 2047编码以支持中文
+func BuildMessage(from, to, subject, content string, date time.Time) []byte {
+	buf := bytes.NewBuffer(nil)
+	fmt.Fprintf(buf, "From: %s\r\n", from)
+	fmt.Fprintf(buf, "To: %s\r\n", to)
+	fmt.Fprintf(buf, "Subject: %s\r\n", mime.BEncoding.Encode("UTF-8", subject))
+	fmt.Fprintf(buf, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	buf.WriteString("\r\n")
+	buf.WriteString(content)
+	buf.WriteString("\r\n")
+	return buf.Bytes()
+}
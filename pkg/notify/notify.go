@@ -0,0 +1,40 @@
+package notify
+
+import (
+	"errors"
+)
+
+// Channel 通知渠道
+type Channel string
+
+const (
+	// Email 邮件
+	Email Channel = "email"
+	// SMS 短信
+	SMS Channel = "sms"
+)
+
+// ParseChannelFromString todo
+func ParseChannelFromString(str string) (Channel, error) {
+	switch str {
+	case "email":
+		return Email, nil
+	case "sms":
+		return SMS, nil
+	default:
+		return "", errors.New("unknown channel: " + str)
+	}
+}
+
+// Message 通知内容, To为邮箱地址或者手机号码
+type Message struct {
+	Channel Channel `json:"channel"`
+	To      string  `json:"to"`
+	Subject string  `json:"subject,omitempty"`
+	Content string  `json:"content"`
+}
+
+// Notifier 通知发送接口, 不同的渠道可以使用不同的实现
+type Notifier interface {
+	Send(*Message) error
+}
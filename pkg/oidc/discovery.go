@@ -0,0 +1,63 @@
+package oidc
+
+import (
+	"strings"
+)
+
+var (
+	grantTypesSupported = []string{
+		"authorization_code", "refresh_token", "password", "client_credentials",
+		"urn:ietf:params:oauth:grant-type:device_code",
+	}
+)
+
+// NewConfiguration 服务发现文档, 所有地址都基于issuer
+func NewConfiguration(issuer, authorizeURL string) *Configuration {
+	issuer = strings.TrimSuffix(issuer, "/")
+	if authorizeURL == "" {
+		authorizeURL = issuer + "/oauth2/authorize"
+	}
+
+	return &Configuration{
+		Issuer:                            issuer,
+		AuthorizationEndpoint:             authorizeURL,
+		TokenEndpoint:                     issuer + "/oauth2/token",
+		UserinfoEndpoint:                  issuer + "/userinfo",
+		IntrospectionEndpoint:             issuer + "/oauth2/introspect",
+		RevocationEndpoint:                issuer + "/oauth2/revoke",
+		DeviceAuthorizationEndpoint:       issuer + "/oauth2/device_authorization",
+		JwksURI:                           issuer + "/.well-known/jwks.json",
+		ScopesSupported:                   []string{ScopeOpenID, ScopeProfile, ScopeEmail, ScopePhone, ScopeAddress},
+		ResponseTypesSupported:            []string{"code"},
+		GrantTypesSupported:               grantTypesSupported,
+		SubjectTypesSupported:             []string{"public"},
+		IDTokenSigningAlgValuesSupported:  []string{"RS256", "ES256"},
+		TokenEndpointAuthMethodsSupported: []string{"client_secret_basic", "client_secret_post", "none"},
+		CodeChallengeMethodsSupported:     []string{"plain", "S256"},
+		ClaimsSupported: []string{
+			"sub", "iss", "aud", "exp", "iat", "auth_time", "nonce",
+			"name", "nickname", "preferred_username", "picture", "gender", "locale", "updated_at",
+			"email", "phone_number", "address",
+		},
+	}
+}
+
+// Configuration https://openid.net/specs/openid-connect-discovery-1_0.html#ProviderMetadata
+type Configuration struct {
+	Issuer                            string   `json:"issuer"`
+	AuthorizationEndpoint             string   `json:"authorization_endpoint"`
+	TokenEndpoint                     string   `json:"token_endpoint"`
+	UserinfoEndpoint                  string   `json:"userinfo_endpoint"`
+	IntrospectionEndpoint             string   `json:"introspection_endpoint,omitempty"`
+	RevocationEndpoint                string   `json:"revocation_endpoint,omitempty"`
+	DeviceAuthorizationEndpoint       string   `json:"device_authorization_endpoint,omitempty"`
+	JwksURI                           string   `json:"jwks_uri"`
+	ScopesSupported                   []string `json:"scopes_supported"`
+	ResponseTypesSupported            []string `json:"response_types_supported"`
+	GrantTypesSupported               []string `json:"grant_types_supported"`
+	SubjectTypesSupported             []string `json:"subject_types_supported"`
+	IDTokenSigningAlgValuesSupported  []string `json:"id_token_signing_alg_values_supported"`
+	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported"`
+	CodeChallengeMethodsSupported     []string `json:"code_challenge_methods_supported"`
+	ClaimsSupported                   []string `json:"claims_supported"`
+}
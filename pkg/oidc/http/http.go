@@ -0,0 +1,42 @@
+package http
+
+import (
+	"errors"
+
+	"github.com/infraboard/mcube/http/router"
+
+	"github.com/infraboard/keyauth/pkg"
+	"github.com/infraboard/keyauth/pkg/user"
+)
+
+var (
+	api = &handler{}
+)
+
+type handler struct {
+	user user.Service
+}
+
+// Registry 注册HTTP服务路由
+func (h *handler) Registry(router router.SubRouter) {
+	r := router.ResourceRouter("oidc")
+	r.BasePath("/.well-known")
+	r.Handle("GET", "/openid-configuration", h.Discovery).DisableAuth()
+
+	r.BasePath("/userinfo")
+	r.Handle("GET", "/", h.UserInfo)
+	r.Handle("POST", "/", h.UserInfo)
+}
+
+func (h *handler) Config() error {
+	if pkg.User == nil {
+		return errors.New("denpence user service is nil")
+	}
+
+	h.user = pkg.User
+	return nil
+}
+
+func init() {
+	pkg.RegistryHTTPV1("oidc", api)
+}
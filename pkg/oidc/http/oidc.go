@@ -0,0 +1,49 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/infraboard/mcube/exception"
+	"github.com/infraboard/mcube/http/response"
+
+	"github.com/infraboard/keyauth/conf"
+	"github.com/infraboard/keyauth/pkg"
+	"github.com/infraboard/keyauth/pkg/oidc"
+	"github.com/infraboard/keyauth/pkg/user"
+)
+
+// Discovery OIDC服务发现, 按照协议直接返回, 不做统一包装
+func (h *handler) Discovery(w http.ResponseWriter, r *http.Request) {
+	c := conf.C()
+	writeJSON(w, oidc.NewConfiguration(c.JWT.Issuer, c.OIDC.AuthorizeURL))
+	return
+}
+
+// UserInfo https://openid.net/specs/openid-connect-core-1_0.html#UserInfo
+func (h *handler) UserInfo(w http.ResponseWriter, r *http.Request) {
+	tk, err := pkg.GetTokenFromContext(r)
+	if err != nil {
+		response.Failed(w, err)
+		return
+	}
+
+	if !tk.HasScope(oidc.ScopeOpenID) || tk.IsApplicationToken() {
+		response.Failed(w, exception.NewPermissionDeny("token has no openid scope"))
+		return
+	}
+
+	u, err := h.user.DescribeAccount(user.NewDescriptAccountRequestWithAccount(tk.Account))
+	if err != nil {
+		response.Failed(w, err)
+		return
+	}
+
+	writeJSON(w, oidc.NewUserInfo(u, tk.Scope))
+	return
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,95 @@
+package oidc
+
+import (
+	"github.com/infraboard/keyauth/pkg/token"
+	"github.com/infraboard/keyauth/pkg/token/jwt"
+	"github.com/infraboard/keyauth/pkg/user"
+)
+
+// OIDC标准范围: https://openid.net/specs/openid-connect-core-1_0.html#ScopeClaims
+const (
+	ScopeOpenID  = "openid"
+	ScopeProfile = "profile"
+	ScopeEmail   = "email"
+	ScopePhone   = "phone"
+	ScopeAddress = "address"
+)
+
+// NewUserInfo 根据授权的范围将用户信息映射为标准声明
+func NewUserInfo(u *user.User, scope string) *UserInfo {
+	info := &UserInfo{
+		Subject: u.Account,
+	}
+	if u.Profile == nil {
+		return info
+	}
+
+	for _, s := range token.SplitScope(scope) {
+		switch s {
+		case ScopeProfile:
+			info.Name = u.RealName
+			info.NickName = u.NickName
+			info.PreferredUsername = u.Account
+			info.Picture = u.Avatar
+			info.Locale = u.Language
+			if u.Gender != user.Unknown {
+				info.Gender = u.Gender.String()
+			}
+			if u.UpdateAt.Timestamp() != 0 {
+				info.UpdatedAt = u.UpdateAt.T().Unix()
+			}
+		case ScopeEmail:
+			info.Email = u.Email
+		case ScopePhone:
+			info.PhoneNumber = u.Mobile
+		case ScopeAddress:
+			if u.Address != "" || u.City != "" || u.Province != "" {
+				info.Address = &Address{
+					Formatted: u.Address,
+					Locality:  u.City,
+					Region:    u.Province,
+				}
+			}
+		}
+	}
+
+	return info
+}
+
+// UserInfo 标准声明: https://openid.net/specs/openid-connect-core-1_0.html#StandardClaims
+type UserInfo struct {
+	Subject           string   `json:"sub"`
+	Name              string   `json:"name,omitempty"`
+	NickName          string   `json:"nickname,omitempty"`
+	PreferredUsername string   `json:"preferred_username,omitempty"`
+	Picture           string   `json:"picture,omitempty"`
+	Gender            string   `json:"gender,omitempty"`
+	Locale            string   `json:"locale,omitempty"`
+	UpdatedAt         int64    `json:"updated_at,omitempty"`
+	Email             string   `json:"email,omitempty"`
+	PhoneNumber       string   `json:"phone_number,omitempty"`
+	Address           *Address `json:"address,omitempty"`
+}
+
+// Address 地址声明
+type Address struct {
+	Formatted string `json:"formatted,omitempty"`
+	Locality  string `json:"locality,omitempty"`
+	Region    string `json:"region,omitempty"`
+}
+
+// IDToken 身份令牌声明: https://openid.net/specs/openid-connect-core-1_0.html#IDToken
+type IDToken struct {
+	Issuer    string `json:"iss"`
+	Audience  string `json:"aud"`
+	ExpiresAt int64  `json:"exp"`
+	IssuedAt  int64  `json:"iat"`
+	AuthTime  int64  `json:"auth_time,omitempty"`
+	Nonce     string `json:"nonce,omitempty"`
+	*UserInfo
+}
+
+// Sign 签名后的id_token
+func (t *IDToken) Sign(key *jwt.Key) (string, error) {
+	return jwt.Sign(key, t)
+}
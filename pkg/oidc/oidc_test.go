@@ -0,0 +1,28 @@
+package oidc_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/infraboard/keyauth/pkg/oidc"
+	"github.com/infraboard/keyauth/pkg/user"
+)
+
+func TestNewUserInfoScope(t *testing.T) {
+	should := require.New(t)
+
+	u := user.NewDefaultUser()
+	u.Account = "alice"
+	u.NickName = "Alice"
+	u.Email = "alice@example.com"
+
+	// 与令牌的范围一样, 支持空格和逗号分隔
+	for _, scope := range []string{"openid profile email", "openid,profile,email"} {
+		info := oidc.NewUserInfo(u, scope)
+		should.Equal("alice", info.Subject)
+		should.Equal("Alice", info.NickName)
+		should.Equal("alice@example.com", info.Email)
+		should.Empty(info.PhoneNumber)
+	}
+}
@@ -0,0 +1,55 @@
+package permission
+
+import (
+	"github.com/infraboard/mcube/exception"
+
+	"github.com/infraboard/keyauth/pkg/role"
+)
+
+// 鉴权被拒绝的原因
+const (
+	// DenyNamespaceRequired 没有指定空间
+	DenyNamespaceRequired = "namespace_required"
+	// DenyTokenScope 令牌范围不包含该接口
+	DenyTokenScope = "token_scope_not_allow"
+	// DenyEndpointNotFound 接口没有注册
+	DenyEndpointNotFound = "endpoint_not_found"
+	// DenyNoPermission 没有任何策略授予该接口的权限
+	DenyNoPermission = "no_permission"
+	// DenyExplicit 被拒绝权限明确禁止
+	DenyExplicit = "explicit_deny"
+	// DenyConditionNotSatisfied 授予权限的策略条件不满足
+	DenyConditionNotSatisfied = "condition_not_satisfied"
+)
+
+// NewDenyError 鉴权拒绝, reason作为响应中的reason返回
+func NewDenyError(reason, format string, a ...interface{}) *DenyError {
+	return &DenyError{
+		APIException: exception.NewPermissionDeny(format, a...),
+		DenyReason:   reason,
+	}
+}
+
+// DenyError 携带拒绝原因的鉴权异常
+type DenyError struct {
+	exception.APIException
+	DenyReason string
+	Decision   *role.Decision
+}
+
+// Reason 覆盖异常的默认原因
+func (e *DenyError) Reason() string {
+	return e.DenyReason
+}
+
+// WithDecision 做出拒绝决定的角色和权限
+func (e *DenyError) WithDecision(d *role.Decision) *DenyError {
+	e.Decision = d
+	return e
+}
+
+// IsDenyError 判断是否是鉴权拒绝
+func IsDenyError(err error) (*DenyError, bool) {
+	e, ok := err.(*DenyError)
+	return e, ok
+}
@@ -0,0 +1,138 @@
+package engine
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/rs/xid"
+
+	"github.com/infraboard/keyauth/pkg/endpoint"
+	"github.com/infraboard/keyauth/pkg/permission"
+	"github.com/infraboard/keyauth/pkg/policy"
+	"github.com/infraboard/keyauth/pkg/role"
+)
+
+const (
+	// 缓存代数, 角色, 策略或者端点变更时生成新的代数, 旧代数的缓存自然失效
+	decisionGenerationKey = "permission.decision.generation"
+	// 授权结果的缓存时长, 只对redis缓存生效, mcube的memory缓存会忽略PutWithTTL传入的TTL,
+	// 统一使用配置的cache.memory.ttl
+	decisionCacheTTL = 5 * time.Minute
+)
+
+// policyDecision 策略中角色对端点的授权结果, 与令牌和请求上下文无关, 可以缓存
+type policyDecision struct {
+	Policy   *policy.Policy `json:"policy"`
+	Decision *role.Decision `json:"decision"`
+}
+
+// endpointDecisions 用户在空间中对端点的所有授权结果
+type endpointDecisions struct {
+	Endpoint *endpoint.Endpoint `json:"endpoint"`
+	Items    []*policyDecision  `json:"items"`
+}
+
+// ExpireDecisionCache 生成新的代数使缓存失效, 代数在redis中不过期,
+// memory缓存忽略传入的TTL, 代数到期后同样会重新生成, 只是多了一次缓存失效
+func (s *service) ExpireDecisionCache() error {
+	if err := s.cache.PutWithTTL(decisionGenerationKey, xid.New().String(), 0); err != nil {
+		return fmt.Errorf("expire permission decision cache error, %s", err)
+	}
+
+	return nil
+}
+
+func (s *service) generation() string {
+	var gen string
+	if err := s.cache.Get(decisionGenerationKey, &gen); err == nil && gen != "" {
+		return gen
+	}
+
+	gen = xid.New().String()
+	s.cache.PutWithTTL(decisionGenerationKey, gen, 0)
+	return gen
+}
+
+func (s *service) decisionCacheKey(req *permission.CheckPermissionrequest) string {
+	tk := req.GetToken()
+	subject := tk.Account
+	if tk.IsApplicationToken() {
+		subject = "app:" + tk.ApplicationID
+	}
+
+	return fmt.Sprintf("permission.decision.%s.%s.%s.%s.%s",
+		s.generation(), tk.Domain, req.NamespaceID, subject, req.EnpointID)
+}
+
+// loadDecisions 加载端点以及用户所有策略对该端点的授权结果, 优先从缓存中获取
+func (s *service) loadDecisions(req *permission.CheckPermissionrequest) (*endpointDecisions, error) {
+	key := s.decisionCacheKey(req)
+
+	ed := &endpointDecisions{}
+	if err := s.cache.Get(key, ed); err == nil && ed.Endpoint != nil {
+		return ed, nil
+	}
+
+	ep, err := s.endpoint.DescribeEndpoint(endpoint.NewDescribeEndpointRequestWithID(req.EnpointID))
+	if err != nil {
+		return nil, err
+	}
+
+	prs, err := s.loadPolicyRoles(req.QueryPermissionRequest)
+	if err != nil {
+		return nil, err
+	}
+	ed = evaluateDecisions(ep, prs)
+
+	// 缓存失败不影响鉴权
+	s.cache.PutWithTTL(key, ed, decisionCacheTTL)
+	return ed, nil
+}
+
+// policyRole 策略以及策略授予的角色
+type policyRole struct {
+	policy *policy.Policy
+	role   *role.Role
+}
+
+// loadPolicyRoles 加载用户在空间中的所有策略, 多个策略授予同一个角色时只查询一次
+func (s *service) loadPolicyRoles(req *permission.QueryPermissionRequest) ([]*policyRole, error) {
+	policySet, err := s.policy.QueryPolicy(newQueryPolicyRequest(req))
+	if err != nil {
+		return nil, err
+	}
+
+	roles := map[string]*role.Role{}
+	prs := make([]*policyRole, 0, policySet.Length())
+	for _, item := range policySet.Items {
+		r, ok := roles[item.RoleID]
+		if !ok {
+			descRole := role.NewDescribeRoleRequestWithID(item.RoleID)
+			descRole.WithPermissions = true
+			r, err = s.role.DescribeRole(descRole)
+			if err != nil {
+				return nil, err
+			}
+			roles[item.RoleID] = r
+		}
+
+		prs = append(prs, &policyRole{policy: item, role: r})
+	}
+
+	return prs, nil
+}
+
+// evaluateDecisions 计算每个策略的角色对端点的授权结果, 忽略没有匹配权限的策略
+func evaluateDecisions(ep *endpoint.Endpoint, prs []*policyRole) *endpointDecisions {
+	ed := &endpointDecisions{Endpoint: ep, Items: []*policyDecision{}}
+	for _, pr := range prs {
+		d := pr.role.Evaluate(ep)
+		if d == nil {
+			continue
+		}
+		d.PolicyID = pr.policy.ID
+		ed.Items = append(ed.Items, &policyDecision{Policy: pr.policy, Decision: d})
+	}
+
+	return ed
+}
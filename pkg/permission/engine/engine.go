@@ -3,7 +3,10 @@ package engine
 import (
 	"errors"
 
+	"github.com/infraboard/mcube/cache"
+
 	"github.com/infraboard/keyauth/pkg"
+	"github.com/infraboard/keyauth/pkg/audit"
 	"github.com/infraboard/keyauth/pkg/endpoint"
 	"github.com/infraboard/keyauth/pkg/permission"
 	"github.com/infraboard/keyauth/pkg/policy"
@@ -19,6 +22,8 @@ type service struct {
 	policy   policy.Service
 	role     role.Service
 	endpoint endpoint.Service
+	audit    audit.Service
+	cache    cache.Cache
 }
 
 func (s *service) Config() error {
@@ -37,6 +42,17 @@ func (s *service) Config() error {
 	}
 	s.endpoint = pkg.Endpoint
 
+	if pkg.Audit == nil {
+		return errors.New("denpence audit service is nil")
+	}
+	s.audit = pkg.Audit
+
+	c := cache.C()
+	if c == nil {
+		return errors.New("denpence cache service is nil")
+	}
+	s.cache = c
+
 	return nil
 }
 
@@ -4,10 +4,12 @@ import (
 	"github.com/infraboard/mcube/exception"
 	"github.com/infraboard/mcube/http/request"
 
+	"github.com/infraboard/keyauth/pkg/audit"
 	"github.com/infraboard/keyauth/pkg/endpoint"
 	"github.com/infraboard/keyauth/pkg/permission"
 	"github.com/infraboard/keyauth/pkg/policy"
 	"github.com/infraboard/keyauth/pkg/role"
+	"github.com/infraboard/keyauth/pkg/token"
 )
 
 func (s *service) QueryPermission(req *permission.QueryPermissionRequest) (
@@ -16,14 +18,8 @@ func (s *service) QueryPermission(req *permission.QueryPermissionRequest) (
 		return nil, exception.NewBadRequest("validate param error, %s", err)
 	}
 
-	tk := req.GetToken()
-
 	// 获取用户的策略列表
-	preq := policy.NewQueryPolicyRequest(request.NewPageRequest(100, 1))
-	preq.Account = tk.Account
-	preq.NamespaceID = req.NamespaceID
-
-	policySet, err := s.policy.QueryPolicy(preq)
+	policySet, err := s.policy.QueryPolicy(newQueryPolicyRequest(req))
 	if err != nil {
 		return nil, err
 	}
@@ -43,14 +39,8 @@ func (s *service) QueryRoles(req *permission.QueryPermissionRequest) (
 		return nil, exception.NewBadRequest("validate param error, %s", err)
 	}
 
-	tk := req.GetToken()
-
 	// 获取用户的策略列表
-	preq := policy.NewQueryPolicyRequest(request.NewPageRequest(100, 1))
-	preq.Account = tk.Account
-	preq.NamespaceID = req.NamespaceID
-
-	policySet, err := s.policy.QueryPolicy(preq)
+	policySet, err := s.policy.QueryPolicy(newQueryPolicyRequest(req))
 	if err != nil {
 		return nil, err
 	}
@@ -58,29 +48,176 @@ func (s *service) QueryRoles(req *permission.QueryPermissionRequest) (
 	return policySet.GetRoles(s.role)
 }
 
-func (s *service) CheckPermission(req *permission.CheckPermissionrequest) (*role.Permission, error) {
+// 应用令牌(client_credentials)使用绑定在应用上的策略
+func newQueryPolicyRequest(req *permission.QueryPermissionRequest) *policy.QueryPolicyRequest {
+	tk := req.GetToken()
+
+	preq := policy.NewQueryPolicyRequest(request.NewPageRequest(100, 1))
+	preq.WithToken(tk)
+	preq.NamespaceID = req.NamespaceID
+	preq.DomainOnly = req.NamespaceID == ""
+	if tk.IsApplicationToken() {
+		preq.ApplicationID = tk.ApplicationID
+	} else {
+		preq.Account = tk.Account
+	}
+
+	return preq
+}
+
+func (s *service) CheckPermission(req *permission.CheckPermissionrequest) (*role.Decision, error) {
 	if err := req.Validate(); err != nil {
 		return nil, exception.NewBadRequest("validate param error, %s", err)
 	}
 
-	rset, err := s.QueryRoles(req.QueryPermissionRequest)
+	ed, err := s.loadDecisions(req)
 	if err != nil {
+		if exception.IsNotFoundError(err) {
+			return nil, permission.NewDenyError(permission.DenyEndpointNotFound, err.Error())
+		}
 		return nil, err
 	}
 
-	ep, err := s.endpoint.DescribeEndpoint(endpoint.NewDescribeEndpointRequestWithID(req.EnpointID))
+	ctx := policy.NewConditionContext(req.GetToken(), req.RemoteIP)
+	d, err := checkPermission(ctx, ed)
+
+	// 代表用户行事的调用, 同时记录用户和实际的调用方
+	if req.GetToken().IsDelegated() {
+		s.saveDelegatedLog(req, ed.Endpoint, err)
+	}
+
 	if err != nil {
 		return nil, err
 	}
 
-	p, ok, err := rset.HasPermission(ep)
+	return d, nil
+}
+
+// checkPermission 令牌范围与角色权限取交集, 范围之外的接口即使角色允许也无法访问
+// 所有策略的角色按照拒绝优先计算, 没有权限时返回携带原因的DenyError
+func checkPermission(ctx *policy.ConditionContext, ed *endpointDecisions) (*role.Decision, error) {
+	ep := ed.Endpoint
+	tkScope, err := token.ParseScope(ctx.Token.Scope)
+	if err != nil {
+		return nil, exception.NewBadRequest("parse token scope error, %s", err)
+	}
+	if !tkScope.Allow(&ep.Entry) {
+		return nil, permission.NewDenyError(permission.DenyTokenScope, "token scope not allow %s", ep.Resource)
+	}
+
+	var (
+		allow *role.Decision
+		unmet error
+	)
+	for _, item := range ed.Items {
+		// 策略的范围只限制该策略授予的角色
+		pScope, err := token.ParseScope(item.Policy.Scope)
+		if err != nil {
+			return nil, exception.NewInternalServerError("parse policy %s scope error, %s", item.Policy.ID, err)
+		}
+		if !pScope.Allow(&ep.Entry) {
+			continue
+		}
+
+		// 过期或者条件不满足的策略不生效, 包括其中的拒绝权限
+		if err := item.Policy.CheckCondition(ctx); err != nil {
+			unmet = err
+			continue
+		}
+
+		if !item.Decision.IsAllowed() {
+			return nil, permission.NewDenyError(permission.DenyExplicit, "%s", item.Decision).
+				WithDecision(item.Decision)
+		}
+		if allow == nil {
+			allow = item.Decision
+		}
+	}
+
+	if allow != nil {
+		return allow, nil
+	}
+	if unmet != nil {
+		return nil, permission.NewDenyError(permission.DenyConditionNotSatisfied,
+			"policy condition not satisfied, %s", unmet)
+	}
+
+	return nil, permission.NewDenyError(permission.DenyNoPermission, "no permission for %s", ep.Resource)
+}
+
+func (s *service) BatchCheckPermission(req *permission.BatchCheckPermissionRequest) (
+	*permission.CheckResultSet, error) {
+	if err := req.Validate(); err != nil {
+		return nil, exception.NewBadRequest("validate param error, %s", err)
+	}
+
+	// 所有对象共用同一份策略和角色
+	prs, err := s.loadPolicyRoles(req.QueryPermissionRequest)
 	if err != nil {
 		return nil, err
 	}
 
-	if !ok {
-		return nil, exception.NewNotFound("not perm for this enpind")
+	ctx := policy.NewConditionContext(req.GetToken(), req.RemoteIP)
+	set := permission.NewCheckResultSet()
+	for _, item := range req.Items {
+		result := permission.NewCheckResult(item)
+		set.Add(result)
+
+		ep, err := s.describeCheckItem(item)
+		if err != nil {
+			if exception.IsNotFoundError(err) {
+				result.Deny(permission.NewDenyError(permission.DenyEndpointNotFound, err.Error()))
+				continue
+			}
+			return nil, err
+		}
+
+		d, err := checkPermission(ctx, evaluateDecisions(ep, prs))
+		if err != nil {
+			e, ok := permission.IsDenyError(err)
+			if !ok {
+				return nil, err
+			}
+			result.Deny(e)
+			continue
+		}
+
+		result.Allow(d)
+	}
+
+	return set, nil
+}
+
+// describeCheckItem 资源和标签组成的对象没有对应的端点, 直接构造用于匹配权限
+func (s *service) describeCheckItem(item *permission.CheckItem) (*endpoint.Endpoint, error) {
+	if item.EndpointID != "" {
+		return s.endpoint.DescribeEndpoint(endpoint.NewDescribeEndpointRequestWithID(item.EndpointID))
+	}
+
+	ep := &endpoint.Endpoint{}
+	ep.Resource = item.Resource
+	ep.Labels = item.Labels
+	return ep, nil
+}
+
+func (s *service) saveDelegatedLog(req *permission.CheckPermissionrequest, ep *endpoint.Endpoint, checkErr error) {
+	tk := req.GetToken()
+
+	data := audit.NewDefaultOperateLogData()
+	data.Account = tk.Account
+	data.ApplicationID = tk.ApplicationID
+	data.ApplicationName = tk.ApplicationName
+	data.ResourceType = "endpoint"
+	data.ResourceID = ep.ID
+	data.ResourceName = ep.Path
+	data.Action = ep.Method
+	data.Actor = tk.Actor.String()
+	data.Result = audit.Success
+	if checkErr != nil {
+		data.Result = audit.Failed
+		data.Comment = checkErr.Error()
 	}
 
-	return p, nil
+	data.WithToken(tk)
+	s.audit.SaveOperateRecord(data)
 }
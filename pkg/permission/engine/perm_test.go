@@ -0,0 +1,226 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/infraboard/mcube/exception"
+	"github.com/infraboard/mcube/http/router"
+	"github.com/stretchr/testify/require"
+
+	"github.com/infraboard/keyauth/pkg/endpoint"
+	"github.com/infraboard/keyauth/pkg/permission"
+	"github.com/infraboard/keyauth/pkg/policy"
+	"github.com/infraboard/keyauth/pkg/role"
+	"github.com/infraboard/keyauth/pkg/token"
+)
+
+func newTestEndpoint(id, resource, action string) *endpoint.Endpoint {
+	ep := &endpoint.Endpoint{ID: id}
+	ep.Entry = router.Entry{
+		Resource: resource,
+		Labels:   map[string]string{"action": action},
+	}
+	return ep
+}
+
+func newTestRole(id string, perms ...*role.Permission) *role.Role {
+	r := role.NewDefaultRole()
+	r.ID = id
+	r.Name = id
+	r.Permissions = perms
+	return r
+}
+
+func newTestPermission(effect role.EffectType, resource string, actions ...string) *role.Permission {
+	return &role.Permission{
+		Effect:       effect,
+		ResourceName: resource,
+		LabelKey:     "action",
+		MatchAll:     len(actions) == 0,
+		LabelValues:  actions,
+	}
+}
+
+func newTestPolicy(id, roleID string, cond *policy.Condition) *policy.Policy {
+	return &policy.Policy{
+		ID: id,
+		CreatePolicyRequest: &policy.CreatePolicyRequest{
+			NamespaceID: "ns",
+			Account:     "alice",
+			RoleID:      roleID,
+			Condition:   cond,
+		},
+	}
+}
+
+var (
+	hostAdmin  = newTestRole("host-admin", newTestPermission(role.Allow, "host"))
+	hostNoDrop = newTestRole("host-no-delete", newTestPermission(role.Deny, "host", "delete"))
+	officeOnly = &policy.Condition{SourceIPs: []string{"10.0.0.0/8"}}
+)
+
+func TestCheckPermission(t *testing.T) {
+	cases := []struct {
+		name     string
+		scope    string
+		remoteIP string
+		endpoint *endpoint.Endpoint
+		policies []*policyRole
+		reason   string
+	}{
+		{
+			name:     "allow",
+			endpoint: newTestEndpoint("e1", "host", "delete"),
+			policies: []*policyRole{{newTestPolicy("p1", "host-admin", nil), hostAdmin}},
+		},
+		{
+			name:     "token scope deny",
+			scope:    "host-ro",
+			endpoint: newTestEndpoint("e1", "host", "delete"),
+			policies: []*policyRole{{newTestPolicy("p1", "host-admin", nil), hostAdmin}},
+			reason:   permission.DenyTokenScope,
+		},
+		{
+			name:     "explicit deny beats allow",
+			endpoint: newTestEndpoint("e1", "host", "delete"),
+			policies: []*policyRole{
+				{newTestPolicy("p1", "host-admin", nil), hostAdmin},
+				{newTestPolicy("p2", "host-no-delete", nil), hostNoDrop},
+			},
+			reason: permission.DenyExplicit,
+		},
+		{
+			name:     "deny in unmet policy not effective",
+			remoteIP: "1.2.3.4",
+			endpoint: newTestEndpoint("e1", "host", "delete"),
+			policies: []*policyRole{
+				{newTestPolicy("p1", "host-admin", nil), hostAdmin},
+				{newTestPolicy("p2", "host-no-delete", officeOnly), hostNoDrop},
+			},
+		},
+		{
+			name:     "condition not satisfied",
+			remoteIP: "1.2.3.4",
+			endpoint: newTestEndpoint("e1", "host", "delete"),
+			policies: []*policyRole{{newTestPolicy("p1", "host-admin", officeOnly), hostAdmin}},
+			reason:   permission.DenyConditionNotSatisfied,
+		},
+		{
+			name:     "condition satisfied",
+			remoteIP: "10.1.2.3",
+			endpoint: newTestEndpoint("e1", "host", "delete"),
+			policies: []*policyRole{{newTestPolicy("p1", "host-admin", officeOnly), hostAdmin}},
+		},
+		{
+			name:     "no permission",
+			endpoint: newTestEndpoint("e2", "user", "get"),
+			policies: []*policyRole{{newTestPolicy("p1", "host-admin", nil), hostAdmin}},
+			reason:   permission.DenyNoPermission,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			should := require.New(t)
+
+			tk := &token.Token{Account: "alice", Scope: c.scope}
+			ctx := policy.NewConditionContext(tk, c.remoteIP)
+			d, err := checkPermission(ctx, evaluateDecisions(c.endpoint, c.policies))
+			if c.reason == "" {
+				should.NoError(err)
+				should.True(d.IsAllowed())
+				return
+			}
+
+			de, ok := permission.IsDenyError(err)
+			should.True(ok, "expect deny error, got %v", err)
+			should.Equal(c.reason, de.Reason())
+		})
+	}
+}
+
+// 以下测试替身只实现鉴权需要的查询方法
+type testPolicyService struct {
+	policy.Service
+	set *policy.Set
+}
+
+func (s *testPolicyService) QueryPolicy(*policy.QueryPolicyRequest) (*policy.Set, error) {
+	return s.set, nil
+}
+
+type testRoleService struct {
+	role.Service
+	roles map[string]*role.Role
+}
+
+func (s *testRoleService) DescribeRole(req *role.DescribeRoleRequest) (*role.Role, error) {
+	r, ok := s.roles[req.ID]
+	if !ok {
+		return nil, exception.NewNotFound("role %s not found", req.ID)
+	}
+	return r, nil
+}
+
+type testEndpointService struct {
+	endpoint.Service
+	endpoints map[string]*endpoint.Endpoint
+}
+
+func (s *testEndpointService) DescribeEndpoint(req *endpoint.DescribeEndpointRequest) (*endpoint.Endpoint, error) {
+	ep, ok := s.endpoints[req.ID]
+	if !ok {
+		return nil, exception.NewNotFound("endpoint %s not found", req.ID)
+	}
+	return ep, nil
+}
+
+func TestBatchCheckPermission(t *testing.T) {
+	should := require.New(t)
+
+	set := policy.NewPolicySet(nil)
+	set.Add(newTestPolicy("p1", "host-admin", nil))
+	set.Add(newTestPolicy("p2", "host-no-delete", nil))
+	svc := &service{
+		policy: &testPolicyService{set: set},
+		role: &testRoleService{roles: map[string]*role.Role{
+			"host-admin":     hostAdmin,
+			"host-no-delete": hostNoDrop,
+		}},
+		endpoint: &testEndpointService{endpoints: map[string]*endpoint.Endpoint{
+			"host-get":    newTestEndpoint("host-get", "host", "get"),
+			"host-delete": newTestEndpoint("host-delete", "host", "delete"),
+		}},
+	}
+
+	req := permission.NewBatchCheckPermissionRequest()
+	req.WithToken(&token.Token{Account: "alice", Domain: "default"})
+	req.NamespaceID = "ns"
+	req.Items = []*permission.CheckItem{
+		{EndpointID: "host-get"},
+		{EndpointID: "host-delete"},
+		{EndpointID: "missing"},
+		{Resource: "host", Labels: map[string]string{"action": "list"}},
+		{Resource: "user", Labels: map[string]string{"action": "list"}},
+	}
+
+	results, err := svc.BatchCheckPermission(req)
+	should.NoError(err)
+	should.Len(results.Items, len(req.Items))
+
+	expect := []struct {
+		allowed bool
+		reason  string
+	}{
+		{true, ""},
+		{false, permission.DenyExplicit},
+		{false, permission.DenyEndpointNotFound},
+		{true, ""},
+		{false, permission.DenyNoPermission},
+	}
+	for i, e := range expect {
+		should.Equal(e.allowed, results.Items[i].Allowed, "item %d", i)
+		should.Equal(e.reason, results.Items[i].Reason, "item %d", i)
+	}
+	should.Equal("host-no-delete", results.Items[1].Decision.RoleID)
+}
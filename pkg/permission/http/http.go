@@ -24,6 +24,13 @@ func (h *handler) Registry(router router.SubRouter) {
 	r.BasePath("namespaces")
 	r.Handle("GET", "/:id/permissions", h.List).AddLabel(label.List)
 	r.Handle("GET", "/:id/permissions/endpoints/:eid", h.Get).AddLabel(label.Get)
+	r.Handle("POST", "/:id/permissions/check", h.BatchCheck).AddLabel(label.Get)
+
+	// 域级别的权限, 不属于任何空间
+	r.BasePath("permissions")
+	r.Handle("GET", "/", h.List).AddLabel(label.List)
+	r.Handle("GET", "/endpoints/:eid", h.Get).AddLabel(label.Get)
+	r.Handle("POST", "/check", h.BatchCheck).AddLabel(label.Get)
 }
 
 func (h *handler) Config() error {
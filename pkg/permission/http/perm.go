@@ -9,6 +9,7 @@ import (
 
 	"github.com/infraboard/keyauth/pkg"
 	"github.com/infraboard/keyauth/pkg/permission"
+	"github.com/infraboard/keyauth/pkg/token"
 )
 
 func (h *handler) List(w http.ResponseWriter, r *http.Request) {
@@ -46,6 +47,7 @@ func (h *handler) Get(w http.ResponseWriter, r *http.Request) {
 	req := permission.NewCheckPermissionrequest()
 	req.NamespaceID = rctx.PS.ByName("id")
 	req.EnpointID = rctx.PS.ByName("eid")
+	req.RemoteIP = token.GetRemoteIPFromHTTP(r)
 	req.WithToken(tk)
 
 	d, err := h.service.CheckPermission(req)
@@ -57,3 +59,31 @@ func (h *handler) Get(w http.ResponseWriter, r *http.Request) {
 	response.Success(w, d)
 	return
 }
+
+func (h *handler) BatchCheck(w http.ResponseWriter, r *http.Request) {
+	tk, err := pkg.GetTokenFromContext(r)
+	if err != nil {
+		response.Failed(w, err)
+		return
+	}
+
+	rctx := context.GetContext(r)
+
+	req := permission.NewBatchCheckPermissionRequest()
+	if err := request.GetDataFromRequest(r, req); err != nil {
+		response.Failed(w, err)
+		return
+	}
+	req.NamespaceID = rctx.PS.ByName("id")
+	req.RemoteIP = token.GetRemoteIPFromHTTP(r)
+	req.WithToken(tk)
+
+	set, err := h.service.BatchCheckPermission(req)
+	if err != nil {
+		response.Failed(w, err)
+		return
+	}
+
+	response.Success(w, set)
+	return
+}
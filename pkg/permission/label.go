@@ -0,0 +1,21 @@
+package permission
+
+import (
+	"github.com/infraboard/mcube/http/router"
+)
+
+const (
+	// NamespaceLableKey 端点是否属于空间的标签
+	NamespaceLableKey = "namespace"
+)
+
+var (
+	// Namespaced 端点的资源属于空间, 鉴权时必须指定空间, 使用空间中的策略
+	// 没有该标签的端点为域级别的端点, 比如域、用户、应用的管理, 使用域级别的策略
+	Namespaced = router.NewLable(NamespaceLableKey, "true")
+)
+
+// IsNamespaced 端点是否属于空间
+func IsNamespaced(entry *router.Entry) bool {
+	return entry.GetLableValue(NamespaceLableKey) == Namespaced.Value()
+}
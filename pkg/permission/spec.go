@@ -8,11 +8,18 @@ import (
 	"github.com/infraboard/mcube/http/request"
 )
 
+const (
+	// MaxBatchCheckItems 批量鉴权单次最多的对象个数
+	MaxBatchCheckItems = 200
+)
+
 // Service 权限查询API
 type Service interface {
 	QueryPermission(req *QueryPermissionRequest) (*role.PermissionSet, error)
 	QueryRoles(req *QueryPermissionRequest) (*role.Set, error)
-	CheckPermission(req *CheckPermissionrequest) (*role.Permission, error)
+	CheckPermission(req *CheckPermissionrequest) (*role.Decision, error)
+	BatchCheckPermission(req *BatchCheckPermissionRequest) (*CheckResultSet, error)
+	ExpireDecisionCache() error
 }
 
 // NewQueryPermissionRequest todo
@@ -27,7 +34,7 @@ func NewQueryPermissionRequest(page *request.PageRequest) *QueryPermissionReques
 type QueryPermissionRequest struct {
 	*token.Session
 	*request.PageRequest
-	NamespaceID string
+	NamespaceID string // 为空时只使用域级别的策略
 }
 
 // Validate 校验请求合法
@@ -37,10 +44,6 @@ func (req *QueryPermissionRequest) Validate() error {
 		return fmt.Errorf("token required")
 	}
 
-	if req.NamespaceID == "" {
-		return fmt.Errorf("namespace required")
-	}
-
 	return nil
 }
 
@@ -56,6 +59,7 @@ func NewCheckPermissionrequest() *CheckPermissionrequest {
 type CheckPermissionrequest struct {
 	*QueryPermissionRequest
 	EnpointID string
+	RemoteIP  string // 调用方的IP, 用于判断策略的来源网段条件
 }
 
 // Validate 校验请求合法
@@ -70,3 +74,107 @@ func (req *CheckPermissionrequest) Validate() error {
 
 	return nil
 }
+
+// NewBatchCheckPermissionRequest todo
+func NewBatchCheckPermissionRequest() *BatchCheckPermissionRequest {
+	query := NewQueryPermissionRequest(request.NewPageRequest(100, 1))
+	return &BatchCheckPermissionRequest{
+		QueryPermissionRequest: query,
+		Items:                  []*CheckItem{},
+	}
+}
+
+// BatchCheckPermissionRequest 批量鉴权, 一次请求判断多个端点或者资源的权限
+type BatchCheckPermissionRequest struct {
+	*QueryPermissionRequest `json:"-"`
+	RemoteIP                string       `json:"-"`
+	Items                   []*CheckItem `json:"items"`
+}
+
+// Validate 校验请求合法
+func (req *BatchCheckPermissionRequest) Validate() error {
+	if err := req.QueryPermissionRequest.Validate(); err != nil {
+		return err
+	}
+
+	if len(req.Items) == 0 {
+		return fmt.Errorf("items required")
+	}
+	if len(req.Items) > MaxBatchCheckItems {
+		return fmt.Errorf("items max %d", MaxBatchCheckItems)
+	}
+
+	for i := range req.Items {
+		if err := req.Items[i].Validate(); err != nil {
+			return fmt.Errorf("item %d %s", i, err)
+		}
+	}
+
+	return nil
+}
+
+// CheckItem 需要鉴权的对象, 端点ID与资源标签二选一
+type CheckItem struct {
+	EndpointID string            `json:"endpoint_id,omitempty"`
+	Resource   string            `json:"resource,omitempty"`
+	Labels     map[string]string `json:"labels,omitempty"`
+}
+
+// Validate 校验
+func (i *CheckItem) Validate() error {
+	if i.EndpointID == "" && i.Resource == "" {
+		return fmt.Errorf("endpoint_id or resource required")
+	}
+	if i.EndpointID != "" && i.Resource != "" {
+		return fmt.Errorf("endpoint_id and resource only one can be set")
+	}
+
+	return nil
+}
+
+// NewCheckResultSet todo
+func NewCheckResultSet() *CheckResultSet {
+	return &CheckResultSet{
+		Items: []*CheckResult{},
+	}
+}
+
+// CheckResultSet 批量鉴权结果, 与请求的顺序一致
+type CheckResultSet struct {
+	Items []*CheckResult `json:"items"`
+}
+
+// Add todo
+func (s *CheckResultSet) Add(item *CheckResult) {
+	s.Items = append(s.Items, item)
+}
+
+// NewCheckResult todo
+func NewCheckResult(item *CheckItem) *CheckResult {
+	return &CheckResult{
+		CheckItem: item,
+	}
+}
+
+// CheckResult 单个对象的鉴权结果
+type CheckResult struct {
+	*CheckItem
+	Allowed  bool           `json:"allowed"`
+	Decision *role.Decision `json:"decision,omitempty"`
+	Reason   string         `json:"reason,omitempty"`  // 拒绝的原因
+	Message  string         `json:"message,omitempty"` // 拒绝的详细信息
+}
+
+// Allow 允许访问
+func (r *CheckResult) Allow(d *role.Decision) {
+	r.Allowed = true
+	r.Decision = d
+}
+
+// Deny 拒绝访问
+func (r *CheckResult) Deny(e *DenyError) {
+	r.Allowed = false
+	r.Decision = e.Decision
+	r.Reason = e.DenyReason
+	r.Message = e.Error()
+}
@@ -0,0 +1,96 @@
+package policy
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/infraboard/mcube/types/ftime"
+
+	"github.com/infraboard/keyauth/pkg/geoip"
+	"github.com/infraboard/keyauth/pkg/token"
+)
+
+// Condition 策略的生效条件, 设置的条件需要同时满足, 未设置的条件不做限制
+type Condition struct {
+	NotBefore  ftime.Time        `bson:"not_before" json:"not_before,omitempty"`   // 生效时间
+	NotAfter   ftime.Time        `bson:"not_after" json:"not_after,omitempty"`     // 失效时间
+	SourceIPs  []string          `bson:"source_ips" json:"source_ips,omitempty"`   // 允许的来源网段, 例如: 10.0.0.0/8
+	GrantTypes []token.GrantType `bson:"grant_types" json:"grant_types,omitempty"` // 允许的令牌授权类型
+	RequireMFA bool              `bson:"require_mfa" json:"require_mfa"`           // 是否要求令牌通过了多因素认证
+}
+
+// Validate 校验条件
+func (c *Condition) Validate() error {
+	if c.NotBefore.Timestamp() != 0 && c.NotAfter.Timestamp() != 0 {
+		if !c.NotBefore.T().Before(c.NotAfter.T()) {
+			return fmt.Errorf("condition not_before must before not_after")
+		}
+	}
+
+	for i := range c.SourceIPs {
+		if _, err := geoip.ParseCIDR(c.SourceIPs[i]); err != nil {
+			return fmt.Errorf("condition source_ips %s", err)
+		}
+	}
+
+	return nil
+}
+
+// NewConditionContext todo
+func NewConditionContext(tk *token.Token, remoteIP string) *ConditionContext {
+	return &ConditionContext{
+		Token:    tk,
+		RemoteIP: remoteIP,
+		Time:     time.Now(),
+	}
+}
+
+// ConditionContext 条件判断时的请求上下文
+type ConditionContext struct {
+	Token    *token.Token
+	RemoteIP string
+	Time     time.Time
+}
+
+// Check 检查请求上下文是否满足条件, 不满足时返回原因
+func (c *Condition) Check(ctx *ConditionContext) error {
+	if c.NotBefore.Timestamp() != 0 && ctx.Time.Before(c.NotBefore.T()) {
+		return fmt.Errorf("policy not effective until %s", c.NotBefore.T())
+	}
+	if c.NotAfter.Timestamp() != 0 && ctx.Time.After(c.NotAfter.T()) {
+		return fmt.Errorf("policy not effective after %s", c.NotAfter.T())
+	}
+
+	if len(c.SourceIPs) > 0 {
+		if ctx.RemoteIP == "" {
+			return fmt.Errorf("remote ip required")
+		}
+		ok, err := geoip.ContainsIP(c.SourceIPs, ctx.RemoteIP)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("remote ip %s not allowed", ctx.RemoteIP)
+		}
+	}
+
+	if len(c.GrantTypes) > 0 && !c.allowGrantType(ctx.Token.GetStartGrantType()) {
+		return fmt.Errorf("grant type %s not allowed", ctx.Token.GetStartGrantType())
+	}
+
+	if c.RequireMFA && !ctx.Token.MFA {
+		return fmt.Errorf("mfa required")
+	}
+
+	return nil
+}
+
+func (c *Condition) allowGrantType(gt token.GrantType) bool {
+	for i := range c.GrantTypes {
+		if c.GrantTypes[i] == gt {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,64 @@
+package policy_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/infraboard/mcube/types/ftime"
+	"github.com/stretchr/testify/require"
+
+	"github.com/infraboard/keyauth/pkg/policy"
+	"github.com/infraboard/keyauth/pkg/token"
+)
+
+func TestConditionCheck(t *testing.T) {
+	should := require.New(t)
+
+	c := &policy.Condition{
+		NotBefore:  ftime.T(time.Now().Add(-time.Hour)),
+		NotAfter:   ftime.T(time.Now().Add(time.Hour)),
+		SourceIPs:  []string{"10.0.0.0/8", "192.168.1.10"},
+		GrantTypes: []token.GrantType{token.PASSWORD},
+		RequireMFA: true,
+	}
+	should.NoError(c.Validate())
+
+	tk := &token.Token{GrantType: token.PASSWORD, MFA: true}
+	should.NoError(c.Check(policy.NewConditionContext(tk, "10.1.2.3")))
+	should.NoError(c.Check(policy.NewConditionContext(tk, "192.168.1.10")))
+	should.Error(c.Check(policy.NewConditionContext(tk, "192.168.1.11")))
+	should.Error(c.Check(policy.NewConditionContext(tk, "")))
+
+	// 刷新的令牌按照最开始的授权类型判断
+	refreshed := &token.Token{GrantType: token.REFRESH, StartGrantType: token.PASSWORD, MFA: true}
+	should.NoError(c.Check(policy.NewConditionContext(refreshed, "10.1.2.3")))
+	should.Error(c.Check(policy.NewConditionContext(&token.Token{GrantType: token.ACCESS, MFA: true}, "10.1.2.3")))
+
+	should.Error(c.Check(policy.NewConditionContext(&token.Token{GrantType: token.PASSWORD}, "10.1.2.3")))
+
+	ctx := policy.NewConditionContext(tk, "10.1.2.3")
+	ctx.Time = time.Now().Add(2 * time.Hour)
+	should.Error(c.Check(ctx))
+}
+
+func TestConditionValidate(t *testing.T) {
+	should := require.New(t)
+
+	should.Error((&policy.Condition{SourceIPs: []string{"10.0.0.0/33"}}).Validate())
+	should.Error((&policy.Condition{
+		NotBefore: ftime.T(time.Now()),
+		NotAfter:  ftime.T(time.Now().Add(-time.Hour)),
+	}).Validate())
+}
+
+func TestPolicyExpired(t *testing.T) {
+	should := require.New(t)
+
+	p := policy.NewDefaultPolicy()
+	ctx := policy.NewConditionContext(&token.Token{}, "")
+	should.NoError(p.CheckCondition(ctx))
+
+	p.ExpiredTime = ftime.T(time.Now().Add(-time.Minute))
+	should.True(p.IsExpired())
+	should.Error(p.CheckCondition(ctx))
+}
@@ -9,7 +9,9 @@ import (
 
 	"github.com/infraboard/keyauth/conf"
 	"github.com/infraboard/keyauth/pkg"
+	"github.com/infraboard/keyauth/pkg/application"
 	"github.com/infraboard/keyauth/pkg/namespace"
+	"github.com/infraboard/keyauth/pkg/permission"
 	"github.com/infraboard/keyauth/pkg/policy"
 	"github.com/infraboard/keyauth/pkg/role"
 	"github.com/infraboard/keyauth/pkg/user"
@@ -28,6 +30,8 @@ type service struct {
 	namespace namespace.Service
 	user      user.Service
 	role      role.Service
+	app       application.Service
+	perm      permission.Service
 }
 
 func (s *service) Config() error {
@@ -46,6 +50,16 @@ func (s *service) Config() error {
 	}
 	s.role = pkg.Role
 
+	if pkg.Application == nil {
+		return fmt.Errorf("dependence application service is nil, please load first")
+	}
+	s.app = pkg.Application
+
+	if pkg.Permission == nil {
+		return fmt.Errorf("dependence permission service is nil, please load first")
+	}
+	s.perm = pkg.Permission
+
 	db := conf.C().Mongo.GetDB()
 	col := db.Collection("policy")
 
@@ -18,17 +18,23 @@ func (s *service) CreatePolicy(t policy.Type, req *policy.CreatePolicyRequest) (
 		return nil, exception.NewBadRequest(err.Error())
 	}
 
-	u, err := ins.CheckDependence(s.user, s.role, s.namespace)
+	u, err := ins.CheckDependence(s.user, s.app, s.role, s.namespace)
 	if err != nil {
 		return nil, err
 	}
-	ins.UserType = u.Type
+	if u != nil {
+		ins.UserType = u.Type
+	}
 
 	if _, err := s.col.InsertOne(context.TODO(), ins); err != nil {
 		return nil, exception.NewInternalServerError("inserted policy(%s) document error, %s",
 			ins.ID, err)
 	}
 
+	if err := s.perm.ExpireDecisionCache(); err != nil {
+		return nil, exception.NewInternalServerError(err.Error())
+	}
+
 	return ins, nil
 }
 
@@ -61,8 +67,8 @@ func (s *service) QueryPolicy(req *policy.QueryPolicyRequest) (
 			}
 		}
 
-		// 关联空间信息
-		if req.WithNamespace {
+		// 关联空间信息, 域级别的策略没有空间
+		if req.WithNamespace && !ins.IsDomainPolicy() {
 			descNS := namespace.NewNewDescriptNamespaceRequestWithID(ins.NamespaceID)
 			ins.Namespace, err = s.namespace.DescribeNamespace(descNS)
 			if err != nil {
@@ -65,7 +65,9 @@ func (r *queryPolicyRequest) FindFilter() bson.M {
 	filter := bson.M{}
 	filter["domain"] = tk.Domain
 
-	if r.NamespaceID != "" {
+	if r.DomainOnly {
+		filter["namespace_id"] = ""
+	} else if r.NamespaceID != "" {
 		filter["namespace_id"] = r.NamespaceID
 	}
 	if r.RoleID != "" {
@@ -74,6 +76,9 @@ func (r *queryPolicyRequest) FindFilter() bson.M {
 	if r.Account != "" {
 		filter["account"] = r.Account
 	}
+	if r.ApplicationID != "" {
+		filter["application_id"] = r.ApplicationID
+	}
 	if r.Type != nil {
 		filter["type"] = r.Type
 	}
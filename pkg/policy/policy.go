@@ -3,10 +3,12 @@ package policy
 import (
 	"fmt"
 	"hash/fnv"
+	"time"
 
 	"github.com/infraboard/mcube/http/request"
 	"github.com/infraboard/mcube/types/ftime"
 
+	"github.com/infraboard/keyauth/pkg/application"
 	"github.com/infraboard/keyauth/pkg/namespace"
 	"github.com/infraboard/keyauth/pkg/role"
 	"github.com/infraboard/keyauth/pkg/token"
@@ -59,18 +61,55 @@ type Policy struct {
 
 func (p *Policy) genID() {
 	h := fnv.New32a()
+	subject := p.Account
+	if p.IsApplicationPolicy() {
+		subject = "app:" + p.ApplicationID
+	}
 	hashedStr := fmt.Sprintf("%s-%s-%s-%s",
-		p.Domain, p.NamespaceID, p.Account, p.RoleID)
+		p.Domain, p.NamespaceID, subject, p.RoleID)
 
 	h.Write([]byte(hashedStr))
 	p.ID = fmt.Sprintf("%x", h.Sum32())
 }
 
-// CheckDependence todo
-func (req *CreatePolicyRequest) CheckDependence(u user.Service, r role.Service, ns namespace.Service) (*user.User, error) {
-	account, err := u.DescribeAccount(user.NewDescriptAccountRequestWithAccount(req.Account))
-	if err != nil {
-		return nil, fmt.Errorf("check user error, %s", err)
+// IsExpired 策略是否已经过期, 未设置过期时间的策略永不过期
+func (p *Policy) IsExpired() bool {
+	if p.ExpiredTime.Timestamp() == 0 {
+		return false
+	}
+
+	return p.ExpiredTime.T().Before(time.Now())
+}
+
+// CheckCondition 检查策略在当前请求上下文中是否生效
+func (p *Policy) CheckCondition(ctx *ConditionContext) error {
+	if p.ExpiredTime.Timestamp() != 0 && p.ExpiredTime.T().Before(ctx.Time) {
+		return fmt.Errorf("policy expired at %s", p.ExpiredTime.T())
+	}
+
+	if p.Condition == nil {
+		return nil
+	}
+
+	return p.Condition.Check(ctx)
+}
+
+// CheckDependence todo, 应用策略时返回的用户为nil
+func (req *CreatePolicyRequest) CheckDependence(u user.Service, app application.Service, r role.Service, ns namespace.Service) (*user.User, error) {
+	var (
+		account *user.User
+		err     error
+	)
+	if req.IsApplicationPolicy() {
+		_, err = app.DescriptionApplication(application.NewDescriptApplicationRequestWithID(req.ApplicationID))
+		if err != nil {
+			return nil, fmt.Errorf("check application error, %s", err)
+		}
+	} else {
+		account, err = u.DescribeAccount(user.NewDescriptAccountRequestWithAccount(req.Account))
+		if err != nil {
+			return nil, fmt.Errorf("check user error, %s", err)
+		}
 	}
 
 	_, err = r.DescribeRole(role.NewDescribeRoleRequestWithID(req.RoleID))
@@ -78,9 +117,11 @@ func (req *CreatePolicyRequest) CheckDependence(u user.Service, r role.Service,
 		return nil, fmt.Errorf("check role error, %s", err)
 	}
 
-	_, err = ns.DescribeNamespace(namespace.NewNewDescriptNamespaceRequestWithID(req.NamespaceID))
-	if err != nil {
-		return nil, fmt.Errorf("check role error, %s", err)
+	if !req.IsDomainPolicy() {
+		_, err = ns.DescribeNamespace(namespace.NewNewDescriptNamespaceRequestWithID(req.NamespaceID))
+		if err != nil {
+			return nil, fmt.Errorf("check namespace error, %s", err)
+		}
 	}
 
 	return account, nil
@@ -96,15 +137,49 @@ func NewCreatePolicyRequest() *CreatePolicyRequest {
 // CreatePolicyRequest 创建策略的请求
 type CreatePolicyRequest struct {
 	*token.Session `bson:"-" json:"-"`
-	NamespaceID    string     `bson:"namespace_id" json:"namespace_id" validate:"lte=120"` // 范围
-	Account        string     `bson:"account" json:"account" validate:"required,lte=120"`  // 用户ID
-	RoleID         string     `bson:"role_id" json:"role_id" validate:"required,lte=40"`   // 角色名称
-	Scope          string     `bson:"scope" json:"scope"`                                  // 范围控制
-	ExpiredTime    ftime.Time `bson:"expired_time" json:"expired_time"`                    // 策略过期时间
+	NamespaceID    string     `bson:"namespace_id" json:"namespace_id" validate:"lte=120"`              // 范围, 为空时为域级别的策略
+	Account        string     `bson:"account" json:"account" validate:"lte=120"`                        // 用户ID
+	ApplicationID  string     `bson:"application_id" json:"application_id,omitempty" validate:"lte=64"` // 应用ID, 策略绑定给应用时使用, 与用户二选一
+	RoleID         string     `bson:"role_id" json:"role_id" validate:"required,lte=40"`                // 角色名称
+	Scope          string     `bson:"scope" json:"scope"`                                               // 范围控制, 格式与令牌范围相同, 为空时不限制
+	ExpiredTime    ftime.Time `bson:"expired_time" json:"expired_time"`                                 // 策略过期时间
+	Condition      *Condition `bson:"condition" json:"condition,omitempty"`                             // 策略生效的条件
+}
+
+// IsApplicationPolicy 策略是否绑定在应用上
+func (req *CreatePolicyRequest) IsApplicationPolicy() bool {
+	return req.ApplicationID != ""
+}
+
+// IsDomainPolicy 策略不属于任何空间, 用于域级别端点的鉴权
+func (req *CreatePolicyRequest) IsDomainPolicy() bool {
+	return req.NamespaceID == ""
 }
 
 // Validate 校验请求合法
 func (req *CreatePolicyRequest) Validate() error {
+	// 域级别的策略可以授予用户、应用等管理权限, 只有域管理员可以创建
+	if req.IsDomainPolicy() {
+		tk := req.GetToken()
+		if tk == nil || !tk.UserType.Is(types.SupperAccount, types.PrimaryAccount) {
+			return fmt.Errorf("only domain admin can create domain policy")
+		}
+	}
+	if req.Account == "" && req.ApplicationID == "" {
+		return fmt.Errorf("account or application_id required")
+	}
+	if req.Account != "" && req.ApplicationID != "" {
+		return fmt.Errorf("account and application_id only one can be set")
+	}
+	if _, err := token.ParseScope(req.Scope); err != nil {
+		return err
+	}
+	if req.Condition != nil {
+		if err := req.Condition.Validate(); err != nil {
+			return err
+		}
+	}
+
 	return validate.Struct(req)
 }
 
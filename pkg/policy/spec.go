@@ -28,8 +28,10 @@ func NewQueryPolicyRequestFromHTTP(r *http.Request) *QueryPolicyRequest {
 
 	qs := r.URL.Query()
 	req.Account = qs.Get("account")
+	req.ApplicationID = qs.Get("application_id")
 	req.RoleID = qs.Get("role_id")
 	req.NamespaceID = qs.Get("namespace_id")
+	req.DomainOnly = qs.Get("domain_only") == "true"
 	req.WithRole = qs.Get("with_role") == "true"
 	req.WithNamespace = qs.Get("with_namespace") == "true"
 	return req
@@ -53,8 +55,10 @@ type QueryPolicyRequest struct {
 	*token.Session
 
 	Account       string `json:"account,omitempty"`
+	ApplicationID string `json:"application_id,omitempty"`
 	RoleID        string `json:"role_id,omitempty"`
 	NamespaceID   string `json:"namespace_id,omitempty"`
+	DomainOnly    bool   `json:"domain_only,omitempty"` // 只查询不属于任何空间的域级别策略
 	Type          *Type  `json:"type,omitempty"`
 	WithRole      bool   `json:"with_role,omitempty"`
 	WithNamespace bool   `json:"with_namespace,omitempty"`
@@ -0,0 +1,40 @@
+package http
+
+import (
+	"errors"
+
+	"github.com/infraboard/mcube/http/label"
+	"github.com/infraboard/mcube/http/router"
+
+	"github.com/infraboard/keyauth/pkg"
+	"github.com/infraboard/keyauth/pkg/reset"
+)
+
+var (
+	api = &handler{}
+)
+
+type handler struct {
+	service reset.Service
+}
+
+// Registry 注册HTTP服务路由, 找回密码时用户无法登录, 不需要认证
+func (h *handler) Registry(router router.SubRouter) {
+	r := router.ResourceRouter("password_reset")
+	r.BasePath("password_reset")
+	r.Handle("POST", "/code", h.SendCode).AddLabel(label.Create).DisableAuth()
+	r.Handle("POST", "/", h.ResetPassword).AddLabel(label.Update).DisableAuth()
+}
+
+func (h *handler) Config() error {
+	if pkg.Reset == nil {
+		return errors.New("denpence reset service is nil")
+	}
+
+	h.service = pkg.Reset
+	return nil
+}
+
+func init() {
+	pkg.RegistryHTTPV1("password_reset", api)
+}
@@ -0,0 +1,45 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/infraboard/mcube/http/request"
+	"github.com/infraboard/mcube/http/response"
+
+	"github.com/infraboard/keyauth/pkg/reset"
+)
+
+// SendCode 发送找回密码的验证码
+func (h *handler) SendCode(w http.ResponseWriter, r *http.Request) {
+	req := reset.NewSendCodeRequest()
+	if err := request.GetDataFromRequest(r, req); err != nil {
+		response.Failed(w, err)
+		return
+	}
+	req.WithRemoteIPFromHTTP(r)
+
+	if err := h.service.SendCode(req); err != nil {
+		response.Failed(w, err)
+		return
+	}
+
+	response.Success(w, "code sent if the account exists")
+	return
+}
+
+// ResetPassword 使用验证码重置密码
+func (h *handler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	req := reset.NewResetPasswordRequest()
+	if err := request.GetDataFromRequest(r, req); err != nil {
+		response.Failed(w, err)
+		return
+	}
+
+	if err := h.service.ResetPassword(req); err != nil {
+		response.Failed(w, err)
+		return
+	}
+
+	response.Success(w, "reset ok")
+	return
+}
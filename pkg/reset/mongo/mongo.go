@@ -0,0 +1,103 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/infraboard/mcube/logger"
+	"github.com/infraboard/mcube/logger/zap"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/x/bsonx"
+
+	"github.com/infraboard/keyauth/conf"
+	"github.com/infraboard/keyauth/pkg"
+	"github.com/infraboard/keyauth/pkg/notify"
+	"github.com/infraboard/keyauth/pkg/notify/file"
+	"github.com/infraboard/keyauth/pkg/notify/mail"
+	"github.com/infraboard/keyauth/pkg/reset"
+	"github.com/infraboard/keyauth/pkg/user"
+)
+
+var (
+	// Service 服务实例
+	Service = &service{}
+)
+
+type service struct {
+	code      *mongo.Collection
+	limit     *mongo.Collection
+	log       logger.Logger
+	user      user.Service
+	key       string
+	notifiers map[notify.Channel]notify.Notifier
+}
+
+func (s *service) Config() error {
+	if pkg.User == nil {
+		return errors.New("denpence user service is nil")
+	}
+	s.user = pkg.User
+
+	c := conf.C()
+	email, err := newNotifier(c.Notify.Email)
+	if err != nil {
+		return fmt.Errorf("new email notifier error, %s", err)
+	}
+	sms, err := newNotifier(c.Notify.SMS)
+	if err != nil {
+		return fmt.Errorf("new sms notifier error, %s", err)
+	}
+	s.notifiers = map[notify.Channel]notify.Notifier{
+		notify.Email: email,
+		notify.SMS:   sms,
+	}
+
+	db := c.Mongo.GetDB()
+	indexs := []mongo.IndexModel{
+		{
+			Keys:    bsonx.Doc{{Key: "expire_at", Value: bsonx.Int32(1)}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	}
+
+	code := db.Collection("password_reset_code")
+	if _, err := code.Indexes().CreateMany(context.Background(), indexs); err != nil {
+		return err
+	}
+	limit := db.Collection("password_reset_limit")
+	if _, err := limit.Indexes().CreateMany(context.Background(), indexs); err != nil {
+		return err
+	}
+
+	s.code = code
+	s.limit = limit
+	s.key = c.App.Key
+	s.log = zap.L().Named("Reset")
+	return nil
+}
+
+// newNotifier 短信暂时只支持写入文件, 需要对接短信服务商时在这里扩展
+func newNotifier(kind string) (notify.Notifier, error) {
+	c := conf.C().Notify
+	switch kind {
+	case "file":
+		return file.NewNotifier(c.File), nil
+	case "smtp":
+		return mail.NewNotifier(&mail.Config{
+			Host:     c.SMTP.Host,
+			Port:     c.SMTP.Port,
+			Username: c.SMTP.Username,
+			Password: c.SMTP.Password,
+			From:     c.SMTP.From,
+		})
+	default:
+		return nil, fmt.Errorf("unknown notifier %s", kind)
+	}
+}
+
+func init() {
+	var _ reset.Service = Service
+	pkg.RegistryService("reset", Service)
+}
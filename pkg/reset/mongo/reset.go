@@ -0,0 +1,218 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/infraboard/mcube/exception"
+	"github.com/infraboard/mcube/types/ftime"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/infraboard/keyauth/conf"
+	"github.com/infraboard/keyauth/pkg/notify"
+	"github.com/infraboard/keyauth/pkg/reset"
+	"github.com/infraboard/keyauth/pkg/user"
+)
+
+func (s *service) SendCode(req *reset.SendCodeRequest) error {
+	if err := req.Validate(); err != nil {
+		return exception.NewBadRequest("check send code request error, %s", err)
+	}
+
+	// 先限制来源IP, 同一来源对大量账号的请求不会耗尽这些账号的发送次数
+	if req.IP != "" {
+		if err := s.chargeLimit(reset.IPLimit, req.IP, reset.MaxIPSends, 0); err != nil {
+			return err
+		}
+	}
+
+	// 账号是否存在都需要计数, 避免通过频率限制的差异枚举账号
+	if err := s.chargeLimit(reset.AccountLimit, req.Account, reset.MaxAccountSends, reset.SendInterval); err != nil {
+		return err
+	}
+
+	u, err := s.user.DescribeAccount(user.NewDescriptAccountRequestWithAccount(req.Account))
+	if err != nil {
+		if exception.IsNotFoundError(err) {
+			s.log.Debugf("reset password account %s not found, skip send code", req.Account)
+			return nil
+		}
+		return err
+	}
+
+	to := getDestination(u, req.Channel)
+	if to == "" {
+		s.log.Debugf("account %s has no %s, skip send code", u.Account, req.Channel)
+		return nil
+	}
+
+	code, err := reset.NewCode()
+	if err != nil {
+		return exception.NewInternalServerError(err.Error())
+	}
+	record := reset.NewCodeRecord(u.Account, req.Channel, s.key, code)
+	if err := s.saveCode(record); err != nil {
+		return err
+	}
+
+	msg := newCodeMessage(req.Channel, to, code)
+	if err := s.notifiers[req.Channel].Send(msg); err != nil {
+		return exception.NewInternalServerError("send %s code to %s error, %s", req.Channel, u.Account, err)
+	}
+
+	return nil
+}
+
+func (s *service) ResetPassword(req *reset.ResetPasswordRequest) error {
+	if err := req.Validate(); err != nil {
+		return exception.NewBadRequest("check reset password request error, %s", err)
+	}
+
+	// 先占用一次校验次数再比对, 并发的校验请求不能超过次数限制
+	c, err := s.incAttempts(req.Account)
+	if err != nil {
+		return err
+	}
+
+	if err := c.Verify(s.key, req.Code); err != nil {
+		return exception.NewBadRequest(err.Error())
+	}
+
+	// 新密码不满足密码策略时保留验证码, 用户可以在有效期内重试
+	if err := s.user.ResetAccountPassword(c.Account, req.NewPassword); err != nil {
+		return err
+	}
+
+	return s.deleteCode(c.Account)
+}
+
+func getDestination(u *user.User, channel notify.Channel) string {
+	switch channel {
+	case notify.Email:
+		return u.Email
+	case notify.SMS:
+		return u.Mobile
+	default:
+		return ""
+	}
+}
+
+func newCodeMessage(channel notify.Channel, to, code string) *notify.Message {
+	app := conf.C().App.Name
+	return &notify.Message{
+		Channel: channel,
+		To:      to,
+		Subject: fmt.Sprintf("[%s] password reset code", app),
+		Content: fmt.Sprintf("Your %s password reset code is %s, valid for %d minutes. "+
+			"If you did not request it, please ignore this message.", app, code, int(reset.CodeTTL.Minutes())),
+	}
+}
+
+func (s *service) saveCode(c *reset.Code) error {
+	_, err := s.code.ReplaceOne(context.TODO(), bson.M{"_id": c.Account}, c, options.Replace().SetUpsert(true))
+	if err != nil {
+		return exception.NewInternalServerError("save reset code %s error, %s", c.Account, err)
+	}
+
+	return nil
+}
+
+// incAttempts 原子的累加校验次数, 已经达到次数限制或者过期的验证码不再返回
+func (s *service) incAttempts(account string) (*reset.Code, error) {
+	c := &reset.Code{}
+	err := s.code.FindOneAndUpdate(context.TODO(),
+		bson.M{
+			"_id":       account,
+			"attempts":  bson.M{"$lt": reset.MaxVerifyAttempts},
+			"expire_at": bson.M{"$gt": time.Now()},
+		},
+		bson.M{"$inc": bson.M{"attempts": 1}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(c)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, exception.NewBadRequest("code not correct or expired")
+		}
+		return nil, exception.NewInternalServerError("inc reset code %s attempts error, %s", account, err)
+	}
+
+	return c, nil
+}
+
+func (s *service) deleteCode(account string) error {
+	if _, err := s.code.DeleteOne(context.TODO(), bson.M{"_id": account}); err != nil {
+		return exception.NewInternalServerError("delete reset code %s error, %s", account, err)
+	}
+
+	return nil
+}
+
+// chargeLimit 原子的检查并累加发送次数, 并发的请求不能超过次数限制, interval为0时不限制发送间隔
+func (s *service) chargeLimit(kind reset.LimitKind, key string, max int, interval time.Duration) error {
+	l := reset.NewLimit(kind, key)
+	now := time.Now()
+	windowStart := ftime.T(now.Add(-reset.SendWindow))
+
+	// 统计窗口内未达到次数限制并且满足发送间隔时直接累加
+	filter := bson.M{
+		"_id":          l.ID,
+		"window_start": bson.M{"$gte": windowStart},
+		"count":        bson.M{"$lt": max},
+	}
+	if interval > 0 {
+		filter["last_send_at"] = bson.M{"$lte": ftime.T(now.Add(-interval))}
+	}
+	err := s.limit.FindOneAndUpdate(context.TODO(), filter,
+		bson.M{"$inc": bson.M{"count": 1}, "$set": bson.M{"last_send_at": ftime.T(now)}},
+	).Err()
+	if err == nil {
+		return nil
+	}
+	if err != mongo.ErrNoDocuments {
+		return exception.NewInternalServerError("inc reset limit %s error, %s", l.ID, err)
+	}
+
+	// 没有记录或者窗口已过期时开启新的统计窗口, 窗口内的记录与upsert冲突, 说明已经被限制
+	err = s.limit.FindOneAndUpdate(context.TODO(),
+		bson.M{"_id": l.ID, "window_start": bson.M{"$lt": windowStart}},
+		bson.M{
+			"$set": bson.M{
+				"count":        1,
+				"window_start": ftime.T(now),
+				"last_send_at": ftime.T(now),
+				"expire_at":    now.Add(reset.SendWindow),
+			},
+			"$setOnInsert": bson.M{"kind": l.Kind, "key": l.Key},
+		},
+		options.FindOneAndUpdate().SetUpsert(true),
+	).Err()
+	// 没有设置返回更新后的文档, 新插入时返回ErrNoDocuments
+	if err == nil || err == mongo.ErrNoDocuments {
+		return nil
+	}
+	if !isDuplicateKeyError(err) {
+		return exception.NewInternalServerError("start reset limit %s window error, %s", l.ID, err)
+	}
+
+	if err := s.limit.FindOne(context.TODO(), bson.M{"_id": l.ID}).Decode(l); err != nil {
+		return exception.NewInternalServerError("find reset limit %s error, %s", l.ID, err)
+	}
+	if err := l.Allow(max, interval); err != nil {
+		return exception.NewBadRequest(err.Error())
+	}
+
+	// 并发请求刚刚开启了新的窗口, 重新累加
+	return s.chargeLimit(kind, key, max, interval)
+}
+
+// duplicateKeyErrorCode mongodb唯一索引冲突的错误码
+const duplicateKeyErrorCode = 11000
+
+// findAndModify的唯一索引冲突返回CommandError
+func isDuplicateKeyError(err error) bool {
+	cmdErr, ok := err.(mongo.CommandError)
+	return ok && cmdErr.Code == duplicateKeyErrorCode
+}
@@ -0,0 +1,163 @@
+package reset
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/infraboard/mcube/types/ftime"
+
+	"github.com/infraboard/keyauth/pkg/notify"
+)
+
+const (
+	// CodeLength 验证码位数
+	CodeLength = 6
+	// CodeTTL 验证码有效期
+	CodeTTL = 10 * time.Minute
+	// MaxVerifyAttempts 验证码最多可以校验的次数, 超过后需要重新申请
+	MaxVerifyAttempts = 5
+
+	// SendInterval 同一个账号两次发送的最小间隔
+	SendInterval = time.Minute
+	// SendWindow 发送次数的统计窗口
+	SendWindow = time.Hour
+	// MaxAccountSends 统计窗口内每个账号最多发送的次数
+	MaxAccountSends = 5
+	// MaxIPSends 统计窗口内每个来源IP最多发送的次数
+	MaxIPSends = 20
+)
+
+// NewCode 生成随机的数字验证码
+func NewCode() (string, error) {
+	max := big.NewInt(10)
+	code := make([]byte, CodeLength)
+	for i := range code {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", fmt.Errorf("generate code error, %s", err)
+		}
+		code[i] = byte('0' + n.Int64())
+	}
+
+	return string(code), nil
+}
+
+// HashCode 验证码只有6位, 直接哈希可以被穷举, 所以使用服务端的秘钥做HMAC
+func HashCode(key, account, code string) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(account + ":" + code))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// NewCodeRecord todo
+func NewCodeRecord(account string, channel notify.Channel, key, code string) *Code {
+	now := time.Now()
+	return &Code{
+		Account:  account,
+		Channel:  channel,
+		Hash:     HashCode(key, account, code),
+		CreateAt: ftime.T(now),
+		ExpireAt: now.Add(CodeTTL),
+	}
+}
+
+// Code 找回密码的验证码, 每个账号只保留最新的一个, 只保存哈希值
+type Code struct {
+	Account  string         `bson:"_id" json:"account"`         // 账号
+	Channel  notify.Channel `bson:"channel" json:"channel"`     // 发送渠道
+	Hash     string         `bson:"hash" json:"-"`              // 验证码的哈希
+	Attempts int            `bson:"attempts" json:"attempts"`   // 已经校验的次数, 包含进行中的校验
+	CreateAt ftime.Time     `bson:"create_at" json:"create_at"` // 发送时间
+	ExpireAt time.Time      `bson:"expire_at" json:"expire_at"` // 过期时间, 通过TTL索引清理
+}
+
+// IsExpired todo
+func (c *Code) IsExpired() bool {
+	return !time.Now().Before(c.ExpireAt)
+}
+
+// Verify 校验验证码, 调用方需要在校验前原子的累加校验次数, Attempts已包含本次校验
+func (c *Code) Verify(key, code string) error {
+	if c.IsExpired() {
+		return errors.New("code expired, please request a new one")
+	}
+	if c.Attempts > MaxVerifyAttempts {
+		return errors.New("too many attempts, please request a new one")
+	}
+
+	if !hmac.Equal([]byte(c.Hash), []byte(HashCode(key, c.Account, code))) {
+		return errors.New("code not correct")
+	}
+
+	return nil
+}
+
+// LimitKind 发送频率的统计维度
+type LimitKind string
+
+const (
+	// AccountLimit 按照账号统计
+	AccountLimit LimitKind = "account"
+	// IPLimit 按照来源IP统计
+	IPLimit LimitKind = "ip"
+)
+
+// LimitID todo
+func LimitID(kind LimitKind, key string) string {
+	return string(kind) + ":" + key
+}
+
+// NewLimit todo
+func NewLimit(kind LimitKind, key string) *Limit {
+	return &Limit{
+		ID:   LimitID(kind, key),
+		Kind: kind,
+		Key:  key,
+	}
+}
+
+// Limit 验证码的发送记录, 用于限制发送频率
+type Limit struct {
+	ID          string     `bson:"_id" json:"id"`
+	Kind        LimitKind  `bson:"kind" json:"kind"`                 // 统计维度
+	Key         string     `bson:"key" json:"key"`                   // 账号或者IP
+	Count       int        `bson:"count" json:"count"`               // 统计窗口内的发送次数
+	WindowStart ftime.Time `bson:"window_start" json:"window_start"` // 统计窗口的开始时间
+	LastSendAt  ftime.Time `bson:"last_send_at" json:"last_send_at"` // 最近一次发送时间
+	ExpireAt    time.Time  `bson:"expire_at" json:"-"`               // 记录的过期时间, 通过TTL索引清理
+}
+
+// Allow 检查是否允许再次发送, interval为0时不限制发送间隔
+func (l *Limit) Allow(max int, interval time.Duration) error {
+	now := time.Now()
+	if interval > 0 && l.LastSendAt.Timestamp() > 0 {
+		if wait := l.LastSendAt.T().Add(interval).Sub(now); wait > 0 {
+			return fmt.Errorf("send too frequently, retry after %d seconds", int(wait.Seconds())+1)
+		}
+	}
+
+	if l.WindowStart.Timestamp() > 0 && now.Sub(l.WindowStart.T()) <= SendWindow && l.Count >= max {
+		return fmt.Errorf("send too many times, retry after %s",
+			l.WindowStart.T().Add(SendWindow).Format(time.RFC3339))
+	}
+
+	return nil
+}
+
+// Inc 记录一次发送
+func (l *Limit) Inc() {
+	now := time.Now()
+	if l.WindowStart.Timestamp() <= 0 || now.Sub(l.WindowStart.T()) > SendWindow {
+		l.Count = 0
+		l.WindowStart = ftime.T(now)
+	}
+	l.Count++
+	l.LastSendAt = ftime.T(now)
+	l.ExpireAt = l.WindowStart.T().Add(SendWindow)
+}
@@ -0,0 +1,56 @@
+package reset_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/infraboard/mcube/types/ftime"
+	"github.com/stretchr/testify/require"
+
+	"github.com/infraboard/keyauth/pkg/notify"
+	"github.com/infraboard/keyauth/pkg/reset"
+)
+
+func TestCodeVerify(t *testing.T) {
+	should := require.New(t)
+
+	code, err := reset.NewCode()
+	should.NoError(err)
+	should.Len(code, reset.CodeLength)
+
+	c := reset.NewCodeRecord("alice", notify.Email, "key", code)
+	should.NotContains(c.Hash, code)
+	should.Error(c.Verify("other", code))
+	should.NoError(c.Verify("key", code))
+
+	// 超过校验次数后正确的验证码也不能使用
+	c = reset.NewCodeRecord("alice", notify.Email, "key", code)
+	c.Attempts = reset.MaxVerifyAttempts
+	should.NoError(c.Verify("key", code))
+	c.Attempts++
+	should.Error(c.Verify("key", code))
+
+	c = reset.NewCodeRecord("alice", notify.Email, "key", code)
+	c.ExpireAt = time.Now().Add(-time.Second)
+	should.Error(c.Verify("key", code))
+}
+
+func TestLimit(t *testing.T) {
+	should := require.New(t)
+
+	l := reset.NewLimit(reset.AccountLimit, "alice")
+	should.NoError(l.Allow(2, reset.SendInterval))
+	l.Inc()
+	should.Error(l.Allow(2, reset.SendInterval))
+	should.NoError(l.Allow(2, 0))
+
+	l.Inc()
+	should.Error(l.Allow(2, 0))
+
+	// 统计窗口过期后重新计数
+	l.WindowStart = ftime.T(time.Now().Add(-reset.SendWindow - time.Minute))
+	l.LastSendAt = l.WindowStart
+	should.NoError(l.Allow(2, reset.SendInterval))
+	l.Inc()
+	should.Equal(1, l.Count)
+}
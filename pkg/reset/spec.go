@@ -0,0 +1,69 @@
+package reset
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+
+	"github.com/infraboard/keyauth/pkg/notify"
+	"github.com/infraboard/keyauth/pkg/token"
+)
+
+// use a single instance of Validate, it caches struct info
+var (
+	validate = validator.New()
+)
+
+// Service 自助找回密码服务
+type Service interface {
+	// 发送验证码到用户的邮箱或者手机
+	SendCode(*SendCodeRequest) error
+	// 校验验证码并设置新密码
+	ResetPassword(*ResetPasswordRequest) error
+}
+
+// NewSendCodeRequest todo
+func NewSendCodeRequest() *SendCodeRequest {
+	return &SendCodeRequest{
+		Channel: notify.Email,
+	}
+}
+
+// SendCodeRequest 申请验证码, 为了防止账号被枚举, 账号不存在时也返回成功
+type SendCodeRequest struct {
+	Account string         `json:"account" validate:"required,lte=60"`
+	Channel notify.Channel `json:"channel" validate:"required,oneof=email sms"`
+	IP      string         `json:"-"`
+}
+
+// WithRemoteIPFromHTTP 用于按来源IP限制发送频率
+func (req *SendCodeRequest) WithRemoteIPFromHTTP(r *http.Request) {
+	req.IP = token.GetRemoteIPFromHTTP(r)
+}
+
+// Validate todo
+func (req *SendCodeRequest) Validate() error {
+	return validate.Struct(req)
+}
+
+// NewResetPasswordRequest todo
+func NewResetPasswordRequest() *ResetPasswordRequest {
+	return &ResetPasswordRequest{}
+}
+
+// ResetPasswordRequest 使用验证码重置密码
+type ResetPasswordRequest struct {
+	Account     string `json:"account" validate:"required,lte=60"`
+	Code        string `json:"code" validate:"required,lte=20"`
+	NewPassword string `json:"new_password" validate:"required,lte=80"`
+}
+
+// Validate todo
+func (req *ResetPasswordRequest) Validate() error {
+	if req.Code != "" && len(req.Code) != CodeLength {
+		return fmt.Errorf("code length must be %d", CodeLength)
+	}
+
+	return validate.Struct(req)
+}
@@ -2,6 +2,7 @@ package mongo
 
 import (
 	"context"
+	"errors"
 
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
@@ -9,6 +10,7 @@ import (
 
 	"github.com/infraboard/keyauth/conf"
 	"github.com/infraboard/keyauth/pkg"
+	"github.com/infraboard/keyauth/pkg/permission"
 	"github.com/infraboard/keyauth/pkg/role"
 )
 
@@ -21,9 +23,16 @@ type service struct {
 	col           *mongo.Collection
 	enableCache   bool
 	notifyCachPre string
+
+	perm permission.Service
 }
 
 func (s *service) Config() error {
+	if pkg.Permission == nil {
+		return errors.New("denpence permission service is nil")
+	}
+	s.perm = pkg.Permission
+
 	db := conf.C().Mongo.GetDB()
 	col := db.Collection("role")
 
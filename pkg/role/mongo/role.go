@@ -20,6 +20,10 @@ func (s *service) CreateRole(t role.Type, req *role.CreateRoleRequest) (*role.Ro
 			r.Name, err)
 	}
 
+	if err := s.perm.ExpireDecisionCache(); err != nil {
+		return nil, exception.NewInternalServerError(err.Error())
+	}
+
 	return r, nil
 }
 
@@ -81,5 +85,9 @@ func (s *service) DeleteRole(id string) error {
 		return exception.NewNotFound("role(%s) not found", id)
 	}
 
+	if err := s.perm.ExpireDecisionCache(); err != nil {
+		return exception.NewInternalServerError(err.Error())
+	}
+
 	return nil
 }
@@ -56,17 +56,32 @@ type Role struct {
 
 // HasPermission 权限判断
 func (r *Role) HasPermission(ep *endpoint.Endpoint) (*Permission, bool, error) {
-	var (
-		rok, lok bool
-	)
+	d := r.Evaluate(ep)
+	if d == nil {
+		return nil, false, nil
+	}
+
+	return d.Permission, d.IsAllowed(), nil
+}
+
+// Evaluate 按照拒绝优先的原则计算角色对该端点的授权结果, 没有匹配的权限时返回nil
+func (r *Role) Evaluate(ep *endpoint.Endpoint) *Decision {
+	var allow *Decision
 	for i := range r.Permissions {
-		rok = r.Permissions[i].MatchResource(ep.Resource)
-		lok = r.Permissions[i].MatchLabel(ep.Labels)
-		if rok && lok {
-			return r.Permissions[i], true, nil
+		p := r.Permissions[i]
+		if !p.MatchResource(ep.Resource) || !p.MatchLabel(ep.Labels) {
+			continue
+		}
+
+		if p.IsDeny() {
+			return NewDecision(r, p)
+		}
+		if allow == nil {
+			allow = NewDecision(r, p)
 		}
 	}
-	return nil, false, nil
+
+	return allow
 }
 
 // NewCreateRoleRequest 实例化请求
@@ -151,17 +166,61 @@ func (s *Set) Add(item *Role) {
 
 // HasPermission todo
 func (s *Set) HasPermission(ep *endpoint.Endpoint) (*Permission, bool, error) {
+	d := s.Evaluate(ep)
+	if d == nil {
+		return nil, false, nil
+	}
+
+	return d.Permission, d.IsAllowed(), nil
+}
+
+// Evaluate 任意角色的拒绝都优先于其他角色的允许
+func (s *Set) Evaluate(ep *endpoint.Endpoint) *Decision {
+	var allow *Decision
 	for i := range s.Items {
-		p, ok, err := s.Items[i].HasPermission(ep)
-		if err != nil {
-			return nil, false, err
+		d := s.Items[i].Evaluate(ep)
+		if d == nil {
+			continue
 		}
-		if ok {
-			return p, ok, nil
+
+		if !d.IsAllowed() {
+			return d
 		}
+		if allow == nil {
+			allow = d
+		}
+	}
+
+	return allow
+}
+
+// NewDecision todo
+func NewDecision(r *Role, p *Permission) *Decision {
+	return &Decision{
+		Effect:     p.GetEffect(),
+		RoleID:     r.ID,
+		RoleName:   r.Name,
+		Permission: p,
 	}
+}
 
-	return nil, false, nil
+// Decision 鉴权结果, 记录做出决定的角色和权限
+type Decision struct {
+	Effect     EffectType  `json:"effect"`
+	PolicyID   string      `json:"policy_id,omitempty"`
+	RoleID     string      `json:"role_id"`
+	RoleName   string      `json:"role_name"`
+	Permission *Permission `json:"permission"`
+}
+
+// IsAllowed 是否允许访问
+func (d *Decision) IsAllowed() bool {
+	return d.Effect == Allow
+}
+
+// String todo
+func (d *Decision) String() string {
+	return fmt.Sprintf("%s by role %s(%s)", d.Effect, d.RoleName, d.RoleID)
 }
 
 // NewDefaultPermission todo
@@ -173,21 +232,56 @@ func NewDefaultPermission() *Permission {
 
 // Permission 权限
 type Permission struct {
-	Effect       EffectType `bson:"effect" json:"effect,omitempty"`               // 效力
-	ResourceName string     `bson:"resource_name" json:"resource_name,omitempty"` // 资源列表
-	LabelKey     string     `bson:"label_key" json:"label_key,omitempty"`         // 维度
-	MatchAll     bool       `bson:"match_all" json:"match_all"`                   // 适配所有值
-	LabelValues  []string   `bson:"label_values" json:"label_values,omitempty"`   // 标识值
+	Effect       EffectType       `bson:"effect" json:"effect,omitempty"`               // 效力
+	ResourceName string           `bson:"resource_name" json:"resource_name,omitempty"` // 资源列表
+	LabelKey     string           `bson:"label_key" json:"label_key,omitempty"`         // 维度
+	MatchAll     bool             `bson:"match_all" json:"match_all"`                   // 适配所有值
+	LabelValues  []string         `bson:"label_values" json:"label_values,omitempty"`   // 标识值
+	Selectors    []*LabelSelector `bson:"selectors" json:"selectors,omitempty"`         // 标签选择器, 需要同时满足
+}
+
+// GetEffect 历史数据没有设置效力时, 视为允许
+func (p *Permission) GetEffect() EffectType {
+	if p.Effect == 0 {
+		return Allow
+	}
+
+	return p.Effect
+}
+
+// IsDeny 是否是拒绝权限
+func (p *Permission) IsDeny() bool {
+	return p.GetEffect() == Deny
 }
 
 // Validate todo
 func (p *Permission) Validate() error {
-	if p.ResourceName == "" || p.LabelKey == "" {
-		return fmt.Errorf("permisson required resource_name and label_key")
+	if p.ResourceName == "" {
+		return fmt.Errorf("permisson required resource_name")
+	}
+	if err := validatePattern(p.ResourceName); err != nil {
+		return err
+	}
+
+	if p.LabelKey == "" && len(p.Selectors) == 0 {
+		return fmt.Errorf("permisson required label_key or selectors")
+	}
+
+	if p.LabelKey != "" {
+		if len(p.LabelValues) == 0 {
+			return fmt.Errorf("permission label_values required")
+		}
+		for i := range p.LabelValues {
+			if err := validatePattern(p.LabelValues[i]); err != nil {
+				return err
+			}
+		}
 	}
 
-	if len(p.LabelValues) == 0 {
-		return fmt.Errorf("permission label_values required")
+	for i := range p.Selectors {
+		if err := p.Selectors[i].Validate(); err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -198,25 +292,39 @@ func (p *Permission) ID(namespace string) string {
 	return namespace + "." + p.ResourceName
 }
 
-// MatchResource 检测资源是否匹配
+// MatchResource 检测资源是否匹配, 支持通配符, 例如: host.*
 func (p *Permission) MatchResource(r string) bool {
-	if p.ResourceName == "*" {
-		return true
-	}
-	return p.ResourceName == r
+	return matchPattern(p.ResourceName, r)
 }
 
-// MatchLabel 匹配Label
+// MatchLabel 匹配Label, label_key和所有的选择器需要同时满足
 func (p *Permission) MatchLabel(label map[string]string) bool {
+	// 没有任何标签条件的权限不匹配任何端点
+	if p.LabelKey == "" && len(p.Selectors) == 0 {
+		return false
+	}
+
+	if p.LabelKey != "" && !p.matchLabelKey(label) {
+		return false
+	}
+
+	for i := range p.Selectors {
+		if !p.Selectors[i].Match(label) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (p *Permission) matchLabelKey(label map[string]string) bool {
 	for k, v := range label {
 		if p.LabelKey == "*" || p.LabelKey == k {
 			if p.MatchAll {
 				return true
 			}
-			for i := range p.LabelValues {
-				if p.LabelValues[i] == v {
-					return true
-				}
+			if matchAny(p.LabelValues, v) {
+				return true
 			}
 		}
 	}
@@ -0,0 +1,77 @@
+package role_test
+
+import (
+	"testing"
+
+	"github.com/infraboard/mcube/http/router"
+	"github.com/stretchr/testify/require"
+
+	"github.com/infraboard/keyauth/pkg/endpoint"
+	"github.com/infraboard/keyauth/pkg/role"
+)
+
+func newEndpoint(resource, action string) *endpoint.Endpoint {
+	return &endpoint.Endpoint{
+		Entry: router.Entry{
+			Resource: resource,
+			Labels:   map[string]string{"action": action},
+		},
+	}
+}
+
+func newPermission(effect role.EffectType, resource string, values ...string) *role.Permission {
+	return &role.Permission{
+		Effect:       effect,
+		ResourceName: resource,
+		LabelKey:     "action",
+		MatchAll:     len(values) == 0,
+		LabelValues:  values,
+	}
+}
+
+func newRole(id string, perms ...*role.Permission) *role.Role {
+	r := role.NewDefaultRole()
+	r.ID = id
+	r.Name = id
+	r.Permissions = perms
+	return r
+}
+
+func TestRoleDenyOverrides(t *testing.T) {
+	should := require.New(t)
+
+	r := newRole("r1",
+		newPermission(role.Allow, "*"),
+		newPermission(role.Deny, "host", "delete"),
+	)
+
+	d := r.Evaluate(newEndpoint("host", "get"))
+	should.NotNil(d)
+	should.True(d.IsAllowed())
+
+	d = r.Evaluate(newEndpoint("host", "delete"))
+	should.NotNil(d)
+	should.False(d.IsAllowed())
+	should.Equal("r1", d.RoleID)
+	should.Equal("host", d.Permission.ResourceName)
+
+	r = newRole("r2", newPermission(role.Allow, "host", "get"))
+	should.Nil(r.Evaluate(newEndpoint("user", "get")))
+}
+
+func TestSetDenyOverrides(t *testing.T) {
+	should := require.New(t)
+
+	set := role.NewRoleSet(nil)
+	set.Add(newRole("admin", &role.Permission{ResourceName: "*", LabelKey: "*", MatchAll: true}))
+	set.Add(newRole("no_delete", newPermission(role.Deny, "*", "delete")))
+
+	d := set.Evaluate(newEndpoint("user", "delete"))
+	should.NotNil(d)
+	should.Equal(role.Deny, d.Effect)
+	should.Equal("no_delete", d.RoleID)
+
+	_, ok, err := set.HasPermission(newEndpoint("user", "list"))
+	should.NoError(err)
+	should.True(ok)
+}
@@ -0,0 +1,105 @@
+package role
+
+import (
+	"fmt"
+	"path"
+)
+
+// 标签选择器的操作符, 参考kubernetes的标签选择器
+const (
+	// In 标签存在, 且值匹配其中之一
+	In Operator = "in"
+	// NotIn 标签不存在, 或者值不匹配任何一个
+	NotIn Operator = "not_in"
+	// Exists 标签存在
+	Exists Operator = "exists"
+	// NotExists 标签不存在
+	NotExists Operator = "not_exists"
+)
+
+// Operator 选择器操作符
+type Operator string
+
+// LabelSelector 标签选择器, 值支持通配符, 例如: list*
+type LabelSelector struct {
+	Key      string   `bson:"key" json:"key"`                 // 标签的键
+	Operator Operator `bson:"operator" json:"operator"`       // 操作符
+	Values   []string `bson:"values" json:"values,omitempty"` // 标签的值
+}
+
+// Validate todo
+func (s *LabelSelector) Validate() error {
+	if s.Key == "" {
+		return fmt.Errorf("selector key required")
+	}
+
+	switch s.Operator {
+	case In, NotIn:
+		if len(s.Values) == 0 {
+			return fmt.Errorf("selector %s operator %s values required", s.Key, s.Operator)
+		}
+	case Exists, NotExists:
+		if len(s.Values) > 0 {
+			return fmt.Errorf("selector %s operator %s values must be empty", s.Key, s.Operator)
+		}
+	default:
+		return fmt.Errorf("unknown selector operator %s", s.Operator)
+	}
+
+	for i := range s.Values {
+		if err := validatePattern(s.Values[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Match 判断标签是否满足该选择器
+func (s *LabelSelector) Match(labels map[string]string) bool {
+	v, ok := labels[s.Key]
+	switch s.Operator {
+	case In:
+		return ok && matchAny(s.Values, v)
+	case NotIn:
+		return !ok || !matchAny(s.Values, v)
+	case Exists:
+		return ok
+	case NotExists:
+		return !ok
+	default:
+		return false
+	}
+}
+
+func matchAny(patterns []string, v string) bool {
+	for i := range patterns {
+		if matchPattern(patterns[i], v) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchPattern 通配符匹配, 语法与path.Match相同, 例如: host.*
+func matchPattern(pattern, v string) bool {
+	if pattern == "*" || pattern == v {
+		return true
+	}
+
+	ok, err := path.Match(pattern, v)
+	if err != nil {
+		return false
+	}
+
+	return ok
+}
+
+func validatePattern(pattern string) error {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return fmt.Errorf("invalid pattern %s, %s", pattern, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,114 @@
+package role_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/infraboard/keyauth/pkg/role"
+)
+
+func TestLabelSelectorMatch(t *testing.T) {
+	labels := map[string]string{"action": "list", "env": "prod"}
+
+	cases := []struct {
+		name     string
+		selector *role.LabelSelector
+		expect   bool
+	}{
+		{"in hit", &role.LabelSelector{Key: "action", Operator: role.In, Values: []string{"get", "list"}}, true},
+		{"in miss", &role.LabelSelector{Key: "action", Operator: role.In, Values: []string{"get"}}, false},
+		{"in glob", &role.LabelSelector{Key: "action", Operator: role.In, Values: []string{"li*"}}, true},
+		{"in absent key", &role.LabelSelector{Key: "region", Operator: role.In, Values: []string{"*"}}, false},
+		{"not in hit", &role.LabelSelector{Key: "env", Operator: role.NotIn, Values: []string{"prod"}}, false},
+		{"not in miss", &role.LabelSelector{Key: "env", Operator: role.NotIn, Values: []string{"dev", "test"}}, true},
+		{"not in glob", &role.LabelSelector{Key: "env", Operator: role.NotIn, Values: []string{"pro?"}}, false},
+		{"not in absent key", &role.LabelSelector{Key: "region", Operator: role.NotIn, Values: []string{"hz"}}, true},
+		{"exists", &role.LabelSelector{Key: "env", Operator: role.Exists}, true},
+		{"exists absent key", &role.LabelSelector{Key: "region", Operator: role.Exists}, false},
+		{"not exists", &role.LabelSelector{Key: "env", Operator: role.NotExists}, false},
+		{"not exists absent key", &role.LabelSelector{Key: "region", Operator: role.NotExists}, true},
+		{"unknown operator", &role.LabelSelector{Key: "env", Operator: "eq", Values: []string{"prod"}}, false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			require.Equal(t, c.expect, c.selector.Match(labels))
+		})
+	}
+}
+
+func TestLabelSelectorValidate(t *testing.T) {
+	should := require.New(t)
+
+	should.NoError((&role.LabelSelector{Key: "action", Operator: role.In, Values: []string{"get"}}).Validate())
+	should.NoError((&role.LabelSelector{Key: "action", Operator: role.Exists}).Validate())
+	should.Error((&role.LabelSelector{Operator: role.In, Values: []string{"get"}}).Validate())
+	should.Error((&role.LabelSelector{Key: "action", Operator: role.NotIn}).Validate())
+	should.Error((&role.LabelSelector{Key: "action", Operator: role.NotExists, Values: []string{"get"}}).Validate())
+	should.Error((&role.LabelSelector{Key: "action", Operator: "eq", Values: []string{"get"}}).Validate())
+	should.Error((&role.LabelSelector{Key: "action", Operator: role.In, Values: []string{"[get"}}).Validate())
+}
+
+func TestPermissionMatchResource(t *testing.T) {
+	cases := []struct {
+		pattern  string
+		resource string
+		expect   bool
+	}{
+		{"*", "host", true},
+		{"host", "host", true},
+		{"host", "host.disk", false},
+		{"host.*", "host.disk", true},
+		{"host.*", "host", false},
+		{"host.*", "user", false},
+		{"host?", "hosts", true},
+		{"[hu]*", "user", true},
+	}
+
+	for _, c := range cases {
+		p := &role.Permission{ResourceName: c.pattern}
+		require.Equal(t, c.expect, p.MatchResource(c.resource), "%s ~ %s", c.pattern, c.resource)
+	}
+}
+
+func TestPermissionMatchLabel(t *testing.T) {
+	inAction := &role.LabelSelector{Key: "action", Operator: role.In, Values: []string{"get", "list"}}
+	notProd := &role.LabelSelector{Key: "env", Operator: role.NotIn, Values: []string{"prod"}}
+
+	cases := []struct {
+		name   string
+		perm   *role.Permission
+		labels map[string]string
+		expect bool
+	}{
+		{"no condition", &role.Permission{}, map[string]string{"action": "get"}, false},
+		{"label key", &role.Permission{LabelKey: "action", LabelValues: []string{"get"}}, map[string]string{"action": "get"}, true},
+		{"label key glob", &role.Permission{LabelKey: "action", LabelValues: []string{"*list"}}, map[string]string{"action": "batch_list"}, true},
+		{"label key match all", &role.Permission{LabelKey: "*", MatchAll: true}, map[string]string{"action": "delete"}, true},
+		{"label key miss", &role.Permission{LabelKey: "action", LabelValues: []string{"get"}}, map[string]string{"action": "delete"}, false},
+		{"selectors all hit", &role.Permission{Selectors: []*role.LabelSelector{inAction, notProd}}, map[string]string{"action": "get", "env": "dev"}, true},
+		{"selectors one miss", &role.Permission{Selectors: []*role.LabelSelector{inAction, notProd}}, map[string]string{"action": "get", "env": "prod"}, false},
+		{"selectors negated absent", &role.Permission{Selectors: []*role.LabelSelector{inAction, notProd}}, map[string]string{"action": "list"}, true},
+		{"label key and selectors hit", &role.Permission{LabelKey: "action", MatchAll: true, Selectors: []*role.LabelSelector{notProd}}, map[string]string{"action": "delete", "env": "test"}, true},
+		{"label key hit selectors miss", &role.Permission{LabelKey: "action", MatchAll: true, Selectors: []*role.LabelSelector{notProd}}, map[string]string{"action": "delete", "env": "prod"}, false},
+		{"label key miss selectors hit", &role.Permission{LabelKey: "action", LabelValues: []string{"get"}, Selectors: []*role.LabelSelector{notProd}}, map[string]string{"action": "delete", "env": "dev"}, false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			require.Equal(t, c.expect, c.perm.MatchLabel(c.labels))
+		})
+	}
+}
+
+func TestPermissionValidate(t *testing.T) {
+	should := require.New(t)
+
+	should.NoError((&role.Permission{ResourceName: "host.*", LabelKey: "action", LabelValues: []string{"get"}}).Validate())
+	should.NoError((&role.Permission{ResourceName: "host", Selectors: []*role.LabelSelector{{Key: "env", Operator: role.Exists}}}).Validate())
+	should.Error((&role.Permission{ResourceName: "host"}).Validate())
+	should.Error((&role.Permission{ResourceName: "host[", LabelKey: "action", LabelValues: []string{"get"}}).Validate())
+	should.Error((&role.Permission{ResourceName: "host", LabelKey: "action"}).Validate())
+	should.Error((&role.Permission{ResourceName: "host", Selectors: []*role.LabelSelector{{Key: "env", Operator: role.In}}}).Validate())
+}
@@ -5,17 +5,22 @@ import (
 
 	"github.com/infraboard/keyauth/pkg/application"
 	"github.com/infraboard/keyauth/pkg/audit"
+	"github.com/infraboard/keyauth/pkg/authcode"
 	"github.com/infraboard/keyauth/pkg/counter"
 	"github.com/infraboard/keyauth/pkg/department"
+	"github.com/infraboard/keyauth/pkg/device"
 	"github.com/infraboard/keyauth/pkg/domain"
 	"github.com/infraboard/keyauth/pkg/endpoint"
 	"github.com/infraboard/keyauth/pkg/geoip"
 	"github.com/infraboard/keyauth/pkg/ip2region"
+	"github.com/infraboard/keyauth/pkg/keystore"
+	"github.com/infraboard/keyauth/pkg/lockout"
 	"github.com/infraboard/keyauth/pkg/micro"
 	"github.com/infraboard/keyauth/pkg/namespace"
 	"github.com/infraboard/keyauth/pkg/permission"
 	"github.com/infraboard/keyauth/pkg/policy"
 	"github.com/infraboard/keyauth/pkg/provider"
+	"github.com/infraboard/keyauth/pkg/reset"
 	"github.com/infraboard/keyauth/pkg/role"
 	"github.com/infraboard/keyauth/pkg/storage"
 	"github.com/infraboard/keyauth/pkg/token"
@@ -57,6 +62,16 @@ var (
 	Storage storage.Service
 	// Audit 审计服务
 	Audit audit.Service
+	// AuthCode 授权码服务
+	AuthCode authcode.Service
+	// KeyStore 令牌签名秘钥
+	KeyStore keystore.Service
+	// Device 设备授权服务
+	Device device.Service
+	// Lockout 登录失败锁定服务
+	Lockout lockout.Service
+	// Reset 找回密码服务
+	Reset reset.Service
 )
 
 var (
@@ -184,6 +199,36 @@ func RegistryService(name string, svr Service) {
 		}
 		Audit = value
 		addService(name, svr)
+	case authcode.Service:
+		if AuthCode != nil {
+			registryError(name)
+		}
+		AuthCode = value
+		addService(name, svr)
+	case keystore.Service:
+		if KeyStore != nil {
+			registryError(name)
+		}
+		KeyStore = value
+		addService(name, svr)
+	case device.Service:
+		if Device != nil {
+			registryError(name)
+		}
+		Device = value
+		addService(name, svr)
+	case lockout.Service:
+		if Lockout != nil {
+			registryError(name)
+		}
+		Lockout = value
+		addService(name, svr)
+	case reset.Service:
+		if Reset != nil {
+			registryError(name)
+		}
+		Reset = value
+		addService(name, svr)
 	default:
 		panic(fmt.Sprintf("unknown service type %s", name))
 	}
@@ -0,0 +1,47 @@
+package token
+
+import (
+	"strings"
+)
+
+// token type identifiers: https://tools.ietf.org/html/rfc8693#section-3
+const (
+	// AccessTokenType 访问令牌
+	AccessTokenType = "urn:ietf:params:oauth:token-type:access_token"
+)
+
+// NewActor 通过调用方的令牌构造
+func NewActor(tk *Token) *Actor {
+	a := &Actor{
+		Subject:  tk.Account,
+		ClientID: tk.ClientID,
+	}
+
+	// 应用令牌的主体为应用自身
+	if tk.IsApplicationToken() {
+		a.Subject = tk.ClientID
+	}
+
+	return a
+}
+
+// Actor 代表令牌主体行事的实际调用方: https://tools.ietf.org/html/rfc8693#section-4.1
+type Actor struct {
+	Subject  string `bson:"sub" json:"sub"`                       // 调用方主体, 用户为账号, 应用为client_id
+	ClientID string `bson:"client_id" json:"client_id,omitempty"` // 调用方使用的客户端
+	Actor    *Actor `bson:"act,omitempty" json:"act,omitempty"`   // 上一级调用方, 多次交换时形成调用链
+}
+
+// String 调用链, 最近的调用方在前
+func (a *Actor) String() string {
+	if a == nil {
+		return ""
+	}
+
+	chain := []string{}
+	for act := a; act != nil; act = act.Actor {
+		chain = append(chain, act.Subject)
+	}
+
+	return strings.Join(chain, " <- ")
+}
@@ -0,0 +1,33 @@
+package token_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/infraboard/keyauth/pkg/token"
+)
+
+func TestActorChain(t *testing.T) {
+	should := require.New(t)
+
+	act := &token.Actor{Subject: "svc-b", Actor: &token.Actor{Subject: "svc-a"}}
+	should.Equal("svc-b <- svc-a", act.String())
+
+	var empty *token.Actor
+	should.Equal("", empty.String())
+}
+
+func TestDelegatedTokenNotRefreshable(t *testing.T) {
+	should := require.New(t)
+
+	tk := &token.Token{GrantType: token.PASSWORD}
+	should.NoError(tk.CheckRefreshable())
+
+	tk = &token.Token{GrantType: token.EXCHANGE, Actor: &token.Actor{Subject: "svc-a"}}
+	should.Error(tk.CheckRefreshable())
+
+	// 历史上由交换令牌刷新得到的令牌同样不能再刷新
+	tk = &token.Token{GrantType: token.REFRESH, Actor: &token.Actor{Subject: "svc-a"}}
+	should.Error(tk.CheckRefreshable())
+}
@@ -26,8 +26,21 @@ func (h *handler) Registry(router router.SubRouter) {
 	r.Handle("GET", "/", h.ValidateToken)
 	r.Handle("DELETE", "/", h.RevolkToken)
 
+	// 标准oauth2协议接口, 表单格式请求, 直接返回协议定义的数据结构
+	r.BasePath("/oauth2/token")
+	r.Handle("POST", "/", h.OAuth2Token).DisableAuth()
+	r.BasePath("/oauth2/introspect")
+	r.Handle("POST", "/", h.OAuth2Introspect).DisableAuth()
+	r.BasePath("/oauth2/revoke")
+	r.Handle("POST", "/", h.OAuth2Revoke).DisableAuth()
+
 	r.BasePath("/applications/:id")
 	r.Handle("GET", "/tokens", h.QueryApplicationToken).AddLabel(label.List)
+
+	// 批量撤销令牌, 需要管理权限
+	r.BasePath("/tokens")
+	r.Permission(true)
+	r.Handle("POST", "/revolk", h.BatchRevolkToken).AddLabel(label.Delete)
 }
 
 func (h *handler) Config() error {
@@ -0,0 +1,101 @@
+package http
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/infraboard/keyauth/pkg/token"
+)
+
+// https://tools.ietf.org/html/rfc6749#section-5.1
+type accessTokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	Scope        string `json:"scope,omitempty"`
+	IDToken      string `json:"id_token,omitempty"`
+	// https://tools.ietf.org/html/rfc8693#section-2.2.1
+	IssuedTokenType string `json:"issued_token_type,omitempty"`
+}
+
+func newAccessTokenResponse(tk *token.Token) *accessTokenResponse {
+	resp := &accessTokenResponse{
+		AccessToken:  tk.AccessToken,
+		TokenType:    "Bearer",
+		RefreshToken: tk.RefreshToken,
+		Scope:        tk.Scope,
+		IDToken:      tk.IDToken,
+	}
+
+	if tk.GrantType.Is(token.EXCHANGE) {
+		resp.IssuedTokenType = token.AccessTokenType
+		resp.RefreshToken = ""
+	}
+
+	if tk.AccessExpiredAt.Timestamp() != 0 {
+		resp.ExpiresIn = int64(time.Until(tk.AccessExpiredAt.T()).Seconds())
+	}
+
+	return resp
+}
+
+// OAuth2Token 标准的令牌颁发接口
+func (h *handler) OAuth2Token(w http.ResponseWriter, r *http.Request) {
+	req, err := token.NewIssueTokenRequestFromForm(r)
+	if err != nil {
+		token.WriteOAuthError(w, token.NewInvalidRequestError("%s", err))
+		return
+	}
+
+	tk, err := h.service.IssueToken(req)
+	if err != nil {
+		token.WriteOAuthError(w, err)
+		return
+	}
+
+	token.WriteOAuthJSON(w, http.StatusOK, newAccessTokenResponse(tk))
+	return
+}
+
+// OAuth2Introspect 令牌内省接口, 供网关等资源服务器校验令牌
+func (h *handler) OAuth2Introspect(w http.ResponseWriter, r *http.Request) {
+	req, err := token.NewIntrospectTokenRequestFromForm(r)
+	if err != nil {
+		token.WriteOAuthError(w, token.NewInvalidRequestError("%s", err))
+		return
+	}
+
+	resp, err := h.service.IntrospectToken(req)
+	if err != nil {
+		token.WriteOAuthError(w, err)
+		return
+	}
+
+	token.WriteOAuthJSON(w, http.StatusOK, resp)
+	return
+}
+
+// OAuth2Revoke 令牌撤销接口, 令牌不存在时同样返回成功
+func (h *handler) OAuth2Revoke(w http.ResponseWriter, r *http.Request) {
+	req, err := token.NewRevolkTokenRequestFromForm(r)
+	if err != nil {
+		token.WriteOAuthError(w, token.NewInvalidRequestError("%s", err))
+		return
+	}
+
+	if req.Token == "" {
+		token.WriteOAuthError(w, token.NewInvalidRequestError("token required"))
+		return
+	}
+
+	if err := h.service.RevolkToken(req); err != nil {
+		token.WriteOAuthError(w, err)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	return
+}
@@ -1,13 +1,16 @@
 package http
 
 import (
+	"encoding/json"
 	"net/http"
 
 	"github.com/infraboard/mcube/http/context"
 	"github.com/infraboard/mcube/http/request"
 	"github.com/infraboard/mcube/http/response"
 
+	"github.com/infraboard/keyauth/pkg"
 	"github.com/infraboard/keyauth/pkg/token"
+	"github.com/infraboard/keyauth/pkg/user/types"
 )
 
 // IssueToken 颁发资源访问令牌
@@ -25,6 +28,10 @@ func (h *handler) IssueToken(w http.ResponseWriter, r *http.Request) {
 
 	d, err := h.service.IssueToken(req)
 	if err != nil {
+		if e, ok := token.IsMFARequired(err); ok {
+			writeMFARequired(w, e)
+			return
+		}
 		response.Failed(w, err)
 		return
 	}
@@ -33,6 +40,22 @@ func (h *handler) IssueToken(w http.ResponseWriter, r *http.Request) {
 	return
 }
 
+// writeMFARequired 与response.Failed格式相同, 通过data返回挑战ID
+func writeMFARequired(w http.ResponseWriter, e *token.OAuthError) {
+	code := e.ErrorCode()
+	resp := response.Data{
+		Code:      &code,
+		Namespace: e.Namespace(),
+		Reason:    e.Reason(),
+		Message:   e.Error(),
+		Data:      e,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(resp)
+}
+
 // IssueToken 颁发资源访问令牌
 func (h *handler) ValidateToken(w http.ResponseWriter, r *http.Request) {
 	req := token.NewValidateTokenRequest()
@@ -67,6 +90,34 @@ func (h *handler) RevolkToken(w http.ResponseWriter, r *http.Request) {
 	return
 }
 
+// BatchRevolkToken 批量撤销令牌, 非超级管理员只能撤销本域的令牌
+func (h *handler) BatchRevolkToken(w http.ResponseWriter, r *http.Request) {
+	tk, err := pkg.GetTokenFromContext(r)
+	if err != nil {
+		response.Failed(w, err)
+		return
+	}
+
+	req := token.NewBatchRevolkTokenRequest()
+	if err := request.GetDataFromRequest(r, req); err != nil {
+		response.Failed(w, err)
+		return
+	}
+
+	if !tk.UserType.Is(types.SupperAccount) {
+		req.Domain = tk.Domain
+	}
+
+	count, err := h.service.BatchRevolkToken(req)
+	if err != nil {
+		response.Failed(w, err)
+		return
+	}
+
+	response.Success(w, count)
+	return
+}
+
 // QueryApplicationToken 获取应用访问凭证
 func (h *handler) QueryApplicationToken(w http.ResponseWriter, r *http.Request) {
 	rctx := context.GetContext(r)
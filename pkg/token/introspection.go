@@ -0,0 +1,57 @@
+package token
+
+// NewInactiveIntrospection 无效令牌只返回active=false, 不泄露其他信息
+func NewInactiveIntrospection() *Introspection {
+	return &Introspection{Active: false}
+}
+
+// NewIntrospection 通过令牌构造内省结果
+func NewIntrospection(tk *Token, hint TokenTypeHint) *Introspection {
+	resp := &Introspection{
+		Active:    true,
+		Scope:     tk.Scope,
+		ClientID:  tk.ClientID,
+		Username:  tk.Account,
+		TokenType: string(Bearer),
+		IssuedAt:  tk.CreatedAt.T().Unix(),
+		Subject:   tk.Account,
+		Audience:  tk.ClientID,
+		Domain:    tk.Domain,
+		Actor:     tk.Actor,
+	}
+
+	if tk.Type == JWT {
+		resp.TokenType = string(JWT)
+	}
+
+	// 应用令牌的主体为应用自身
+	if tk.IsApplicationToken() {
+		resp.Subject = tk.ClientID
+	}
+
+	switch hint {
+	case RefreshTokenHint:
+		resp.ExpiresAt = tk.RefreshExpiredAt.T().Unix()
+	default:
+		if tk.AccessExpiredAt.Timestamp() != 0 {
+			resp.ExpiresAt = tk.AccessExpiredAt.T().Unix()
+		}
+	}
+
+	return resp
+}
+
+// Introspection 令牌内省结果: https://tools.ietf.org/html/rfc7662#section-2.2
+type Introspection struct {
+	Active    bool   `json:"active"`
+	Scope     string `json:"scope,omitempty"`
+	ClientID  string `json:"client_id,omitempty"`
+	Username  string `json:"username,omitempty"`
+	TokenType string `json:"token_type,omitempty"`
+	ExpiresAt int64  `json:"exp,omitempty"`
+	IssuedAt  int64  `json:"iat,omitempty"`
+	Subject   string `json:"sub,omitempty"`
+	Audience  string `json:"aud,omitempty"`
+	Domain    string `json:"domain,omitempty"`
+	Actor     *Actor `json:"act,omitempty"`
+}
@@ -1,6 +1,8 @@
 package issuer
 
 import (
+	"fmt"
+
 	"github.com/infraboard/keyauth/pkg/application"
 )
 
@@ -18,3 +20,19 @@ func (i *issuer) CheckClient(clientID, clientSecret string) (*application.Applic
 
 	return app, nil
 }
+
+// checkPublicClient 公开客户端无法保存secret, 由PKCE保证授权码不被盗用
+func (i *issuer) checkPublicClient(clientID string) (*application.Application, error) {
+	req := application.NewDescriptApplicationRequest()
+	req.ClientID = clientID
+	app, err := i.app.DescriptionApplication(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if app.ClientType != application.Public {
+		return nil, fmt.Errorf("client_secret required for %s client", app.ClientType)
+	}
+
+	return app, nil
+}
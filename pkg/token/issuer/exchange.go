@@ -0,0 +1,61 @@
+package issuer
+
+import (
+	"github.com/infraboard/keyauth/pkg/application"
+	"github.com/infraboard/keyauth/pkg/token"
+)
+
+// exchangeToken 服务代表用户调用其他服务时, 使用用户令牌换取一个范围更小的令牌, 同时记录实际的调用方
+func (i *issuer) exchangeToken(app *application.Application, req *token.IssueTokenRequest) (*token.Token, error) {
+	subject, err := i.validateExchangeToken(req.SubjectToken)
+	if err != nil {
+		return nil, token.NewInvalidGrantError("invalid subject_token, %s", err)
+	}
+	if subject.IsApplicationToken() {
+		return nil, token.NewInvalidGrantError("subject_token must be issued to user")
+	}
+
+	scope, err := token.DownScope(subject.Scope, req.Scope)
+	if err != nil {
+		return nil, err
+	}
+
+	// 未携带调用方令牌时, 调用方为客户端自身
+	actor := &token.Actor{Subject: app.ClientID, ClientID: app.ClientID}
+	if req.ActorToken != "" {
+		at, err := i.validateExchangeToken(req.ActorToken)
+		if err != nil {
+			return nil, token.NewInvalidGrantError("invalid actor_token, %s", err)
+		}
+		actor = token.NewActor(at)
+	}
+	actor.Actor = subject.Actor
+
+	u, err := i.getUser(subject.Account)
+	if err != nil {
+		return nil, err
+	}
+
+	tk := i.issueUserToken(app, u, token.EXCHANGE)
+	tk.Domain = subject.Domain
+	tk.Scope = scope
+	tk.Actor = actor
+	tk.MFA = subject.MFA
+	tk.StartGrantType = subject.GetStartGrantType()
+
+	// 交换的令牌不能比主体令牌的有效期更长, 刷新令牌不会返回给客户端, 刷新时也会被拒绝
+	if subject.AccessExpiredAt.Timestamp() != 0 {
+		if tk.AccessExpiredAt.Timestamp() == 0 || tk.AccessExpiredAt.T().After(subject.AccessExpiredAt.T()) {
+			tk.AccessExpiredAt = subject.AccessExpiredAt
+		}
+	}
+	tk.RefreshExpiredAt = tk.AccessExpiredAt
+
+	return tk, nil
+}
+
+func (i *issuer) validateExchangeToken(accessToken string) (*token.Token, error) {
+	req := token.NewValidateTokenRequest()
+	req.AccessToken = accessToken
+	return i.token.ValidateToken(req)
+}
@@ -11,19 +11,25 @@ import (
 	"github.com/infraboard/mcube/logger"
 	"github.com/infraboard/mcube/logger/zap"
 	"github.com/infraboard/mcube/types/ftime"
+	"github.com/rs/xid"
 
 	"github.com/infraboard/keyauth/pkg"
 	"github.com/infraboard/keyauth/pkg/application"
+	"github.com/infraboard/keyauth/pkg/authcode"
+	"github.com/infraboard/keyauth/pkg/device"
 	"github.com/infraboard/keyauth/pkg/domain"
+	"github.com/infraboard/keyauth/pkg/lockout"
+	"github.com/infraboard/keyauth/pkg/oidc"
 	"github.com/infraboard/keyauth/pkg/provider"
 	"github.com/infraboard/keyauth/pkg/provider/ldap"
 	"github.com/infraboard/keyauth/pkg/token"
+	"github.com/infraboard/keyauth/pkg/token/jwt"
 	"github.com/infraboard/keyauth/pkg/user"
 	"github.com/infraboard/keyauth/pkg/user/types"
 )
 
 // NewTokenIssuer todo
-func NewTokenIssuer() (Issuer, error) {
+func NewTokenIssuer(keys jwt.KeyProvider, challenges MFAChallengeStore) (Issuer, error) {
 	if pkg.Application == nil {
 		return nil, fmt.Errorf("dependence service application is nil")
 	}
@@ -39,28 +45,47 @@ func NewTokenIssuer() (Issuer, error) {
 	if pkg.LDAP == nil {
 		return nil, fmt.Errorf("dependence ldap application is nil")
 	}
+	if pkg.AuthCode == nil {
+		return nil, fmt.Errorf("dependence authcode service is nil")
+	}
+	if pkg.Device == nil {
+		return nil, fmt.Errorf("dependence device service is nil")
+	}
+	if pkg.Lockout == nil {
+		return nil, fmt.Errorf("dependence lockout service is nil")
+	}
 
 	issuer := &issuer{
-		user:    pkg.User,
-		domain:  pkg.Domain,
-		token:   pkg.Token,
-		ldap:    pkg.LDAP,
-		app:     pkg.Application,
-		emailRE: regexp.MustCompile(`([a-zA-Z0-9]+)@([a-zA-Z0-9\.]+)\.([a-zA-Z0-9]+)`),
-		log:     zap.L().Named("Token Issuer"),
+		user:       pkg.User,
+		domain:     pkg.Domain,
+		token:      pkg.Token,
+		ldap:       pkg.LDAP,
+		app:        pkg.Application,
+		code:       pkg.AuthCode,
+		device:     pkg.Device,
+		lockout:    pkg.Lockout,
+		challenges: challenges,
+		keys:       keys,
+		emailRE:    regexp.MustCompile(`([a-zA-Z0-9]+)@([a-zA-Z0-9\.]+)\.([a-zA-Z0-9]+)`),
+		log:        zap.L().Named("Token Issuer"),
 	}
 	return issuer, nil
 }
 
 // TokenIssuer 基于该数据进行扩展
 type issuer struct {
-	app     application.Service
-	token   token.Service
-	user    user.Service
-	domain  domain.Service
-	ldap    provider.LDAP
-	emailRE *regexp.Regexp
-	log     logger.Logger
+	app        application.Service
+	token      token.Service
+	user       user.Service
+	domain     domain.Service
+	ldap       provider.LDAP
+	code       authcode.Service
+	device     device.Service
+	lockout    lockout.Service
+	challenges MFAChallengeStore
+	keys       jwt.KeyProvider
+	emailRE    *regexp.Regexp
+	log        logger.Logger
 }
 
 func (i *issuer) checkUser(user, pass string) (*user.User, error) {
@@ -77,7 +102,29 @@ func (i *issuer) checkUser(user, pass string) (*user.User, error) {
 func (i *issuer) getUser(name string) (*user.User, error) {
 	req := user.NewDescriptAccountRequest()
 	req.Account = name
-	return i.user.DescribeAccount(req)
+	u, err := i.user.DescribeAccount(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// 冻结的用户不能再获取令牌, 包括刷新令牌
+	if u.Status != nil && u.Status.IsLocked() {
+		return nil, exception.NewPermissionDeny("account %s is locked", u.Account)
+	}
+
+	return u, nil
+}
+
+// setUserDomain 主账号使用其创建的域, 子账号和服务账号继承主账号的域
+func (i *issuer) setUserDomain(u *user.User, tk *token.Token) error {
+	switch u.Type {
+	case types.SupperAccount, types.PrimaryAccount:
+		return i.setTokenDomain(tk)
+	case types.ServiceAccount, types.SubAccount:
+		tk.Domain = u.Domain
+	}
+
+	return nil
 }
 
 func (i *issuer) setTokenDomain(tk *token.Token) error {
@@ -97,17 +144,81 @@ func (i *issuer) setTokenDomain(tk *token.Token) error {
 	return nil
 }
 
+// 应用令牌使用应用所在的域, 老的应用没有记录域, 使用应用创建者所在的域
+func (i *issuer) setApplicationDomain(app *application.Application, tk *token.Token) error {
+	if app.Domain != "" {
+		tk.Domain = app.Domain
+		return nil
+	}
+
+	u, err := i.getUser(app.User)
+	if err != nil {
+		return err
+	}
+
+	switch u.Type {
+	case types.SupperAccount, types.PrimaryAccount:
+		owner := i.newBearToken(app, token.CLIENT)
+		owner.Account = u.Account
+		owner.UserType = u.Type
+		if err := i.setTokenDomain(owner); err != nil {
+			return fmt.Errorf("set token domain error, %s", err)
+		}
+		tk.Domain = owner.Domain
+	default:
+		tk.Domain = u.Domain
+	}
+
+	return nil
+}
+
 // IssueToken 颁发token
 func (i *issuer) IssueToken(req *token.IssueTokenRequest) (*token.Token, error) {
 	if err := req.Validate(); err != nil {
-		return nil, err
+		return nil, token.NewInvalidRequestError("%s", err)
+	}
+
+	// 令牌范围在颁发时校验, 鉴权时按照范围限制访问
+	if _, err := token.ParseScope(req.Scope); err != nil {
+		return nil, token.NewInvalidScopeError("%s", err)
+	}
+
+	var (
+		app *application.Application
+		err error
+	)
+	if req.IsPublicClient() {
+		app, err = i.checkPublicClient(req.ClientID)
+	} else {
+		app, err = i.CheckClient(req.ClientID, req.ClientSecret)
+	}
+	if err != nil {
+		return nil, token.NewInvalidClientError("%s", err)
 	}
 
-	app, err := i.CheckClient(req.ClientID, req.ClientSecret)
+	tk, err := i.issueToken(app, req)
 	if err != nil {
-		return nil, exception.NewUnauthorized(err.Error())
+		return nil, err
+	}
+
+	// 申请了openid范围的用户令牌, 同时颁发id_token
+	if tk.HasScope(oidc.ScopeOpenID) && !tk.IsApplicationToken() {
+		if err := i.signIDToken(tk, req.GetNonce()); err != nil {
+			return nil, exception.NewInternalServerError("sign id_token error, %s", err)
+		}
+	}
+
+	// 应用开启JWT后, 访问令牌使用签名后的JWT
+	if app.IsJWT() {
+		if err := i.signJWT(tk); err != nil {
+			return nil, exception.NewInternalServerError("sign jwt error, %s", err)
+		}
 	}
 
+	return tk, nil
+}
+
+func (i *issuer) issueToken(app *application.Application, req *token.IssueTokenRequest) (*token.Token, error) {
 	switch req.GrantType {
 	case token.PASSWORD:
 		u, checkErr := i.checkUser(req.Username, req.Password)
@@ -116,18 +227,30 @@ func (i *issuer) IssueToken(req *token.IssueTokenRequest) (*token.Token, error)
 			return nil, exception.NewUnauthorized("user or password not connrect")
 		}
 
+		// 密码过期后需要先修改密码
+		if u.HashedPassword.IsExpired() {
+			return nil, token.NewPasswordExpiredError("password expired, must change")
+		}
+
+		// 启用了多因素认证的用户, 需要再提交动态码才能获取令牌
+		if u.IsMFAEnabled() {
+			return nil, i.newMFAChallenge(app, u, req)
+		}
+		if u.Type.Is(types.SupperAccount, types.PrimaryAccount) {
+			i.log.Warnf("admin account %s login without mfa", u.Account)
+		}
+
 		tk := i.issueUserToken(app, u, token.PASSWORD)
-		switch u.Type {
-		case types.SupperAccount, types.PrimaryAccount:
-			err := i.setTokenDomain(tk)
-			if err != nil {
-				return nil, fmt.Errorf("set token domain error, %s", err)
-			}
-		case types.ServiceAccount, types.SubAccount:
-			tk.Domain = u.Domain
+		tk.Scope = req.Scope
+		if err := i.setUserDomain(u, tk); err != nil {
+			return nil, fmt.Errorf("set token domain error, %s", err)
 		}
 
 		return tk, nil
+	case token.MFAOTP:
+		return i.completeMFAChallenge(app, req)
+	case token.WEBAUTHN:
+		return i.issueWebAuthnToken(app, req)
 	case token.REFRESH:
 		validateReq := token.NewValidateTokenRequest()
 		validateReq.RefreshToken = req.RefreshToken
@@ -135,20 +258,41 @@ func (i *issuer) IssueToken(req *token.IssueTokenRequest) (*token.Token, error)
 		if err != nil {
 			return nil, err
 		}
-		if tk.AccessToken != req.AccessToken {
+		// 刷新令牌与颁发的客户端绑定, 其他客户端不能使用: https://tools.ietf.org/html/rfc6749#section-6
+		if tk.ClientID != app.ClientID {
+			return nil, token.NewInvalidGrantError("refresh_token is not issued to client %s", app.ClientID)
+		}
+		if err := tk.CheckRefreshable(); err != nil {
+			return nil, token.NewInvalidGrantError("%s", err)
+		}
+		// 携带了access_token时需要与刷新令牌匹配, 标准协议中刷新时只需要refresh_token
+		if req.AccessToken != "" && tk.AccessToken != req.AccessToken {
 			return nil, exception.NewPermissionDeny("refresh_token's access_tken not connrect")
 		}
 
-		u, err := i.getUser(tk.Account)
-		if err != nil {
-			return nil, err
+		var newTK *token.Token
+		if tk.IsApplicationToken() {
+			newTK = i.newBearToken(app, token.REFRESH)
+		} else {
+			u, err := i.getUser(tk.Account)
+			if err != nil {
+				return nil, err
+			}
+			newTK = i.issueUserToken(app, u, token.REFRESH)
 		}
-		newTK := i.issueUserToken(app, u, token.REFRESH)
 		newTK.Domain = tk.Domain
-		newTK.StartGrantType = tk.GrantType
+		newTK.Scope = tk.Scope
+		newTK.Actor = tk.Actor
+		newTK.MFA = tk.MFA
+		newTK.StartGrantType = tk.GetStartGrantType()
+		// 刷新产生的令牌继承原有令牌族, 历史令牌没有令牌族时以原令牌作为族ID
+		newTK.FamilyID = tk.FamilyID
+		if newTK.FamilyID == "" {
+			newTK.FamilyID = tk.AccessToken
+		}
 
 		revolkReq := token.NewRevolkTokenRequest(app.ClientID, app.ClientSecret)
-		revolkReq.AccessToken = req.AccessToken
+		revolkReq.AccessToken = tk.AccessToken
 		if err := i.token.RevolkToken(revolkReq); err != nil {
 			return nil, err
 		}
@@ -193,13 +337,56 @@ func (i *issuer) IssueToken(req *token.IssueTokenRequest) (*token.Token, error)
 		}
 		newTK := i.issueUserToken(app, u, token.LDAP)
 		newTK.Domain = ldapConf.Domain
+		newTK.Scope = req.Scope
 		return newTK, nil
 	case token.CLIENT:
-		return nil, exception.NewInternalServerError("not impl")
+		if err := app.CheckClientCredentialsGrant(); err != nil {
+			return nil, token.NewUnauthorizedClientError("%s", err)
+		}
+
+		newTK := i.newBearToken(app, token.CLIENT)
+		newTK.Scope = req.Scope
+		if err := i.setApplicationDomain(app, newTK); err != nil {
+			return nil, err
+		}
+		return newTK, nil
 	case token.AUTHCODE:
-		return nil, exception.NewInternalServerError("not impl")
+		checkReq := authcode.NewCheckCodeRequest(req.AuthCode, app.ClientID, req.RedirectURI, req.CodeVerifier)
+		code, err := i.code.CheckCode(checkReq)
+		if err != nil {
+			return nil, err
+		}
+		u, err := i.getUser(code.Account)
+		if err != nil {
+			return nil, err
+		}
+		newTK := i.issueUserToken(app, u, token.AUTHCODE)
+		newTK.Domain = code.Domain
+		newTK.Scope = code.Scope
+		req.WithNonce(code.Nonce)
+		return newTK, nil
+	case token.DEVICE:
+		// 用户未授权时返回authorization_pending/slow_down, 设备继续轮询
+		code, err := i.device.CheckDeviceCode(device.NewCheckDeviceCodeRequest(req.DeviceCode, app.ClientID))
+		if err != nil {
+			return nil, err
+		}
+		u, err := i.getUser(code.Account)
+		if err != nil {
+			return nil, err
+		}
+		newTK := i.issueUserToken(app, u, token.DEVICE)
+		newTK.Domain = code.Domain
+		newTK.Scope = code.Scope
+		return newTK, nil
+	case token.EXCHANGE:
+		// 只有能够保存凭证的服务才能代表用户调用其他服务
+		if err := app.CheckClientCredentialsGrant(); err != nil {
+			return nil, token.NewUnauthorizedClientError("%s", err)
+		}
+		return i.exchangeToken(app, req)
 	default:
-		return nil, exception.NewInternalServerError("unknown grant type %s", req.GrantType)
+		return nil, token.NewUnsupportedGrantTypeError("unknown grant type %s", req.GrantType)
 	}
 }
 
@@ -264,6 +451,7 @@ func (i *issuer) newBearToken(app *application.Application, gt token.GrantType)
 		Type:            token.Bearer,
 		AccessToken:     token.MakeBearer(24),
 		RefreshToken:    token.MakeBearer(32),
+		FamilyID:        xid.New().String(),
 		CreatedAt:       ftime.T(now),
 		ClientID:        app.ClientID,
 		GrantType:       gt,
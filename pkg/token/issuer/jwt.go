@@ -0,0 +1,99 @@
+package issuer
+
+import (
+	"time"
+
+	"github.com/infraboard/keyauth/conf"
+	"github.com/infraboard/keyauth/pkg/oidc"
+	"github.com/infraboard/keyauth/pkg/token"
+	"github.com/infraboard/keyauth/pkg/token/jwt"
+)
+
+// signJWT 使用当前生效的秘钥对令牌签名, 签名结果作为access_token
+func (i *issuer) signJWT(tk *token.Token) error {
+	key, err := i.keys.SigningKey()
+	if err != nil {
+		return err
+	}
+
+	at, err := jwt.Sign(key, newJWTClaims(tk))
+	if err != nil {
+		return err
+	}
+
+	tk.AccessToken = at
+	tk.Type = token.JWT
+	return nil
+}
+
+// signIDToken OIDC身份令牌
+func (i *issuer) signIDToken(tk *token.Token, nonce string) error {
+	u, err := i.getUser(tk.Account)
+	if err != nil {
+		return err
+	}
+
+	key, err := i.keys.SigningKey()
+	if err != nil {
+		return err
+	}
+
+	now := tk.CreatedAt.T()
+	exp := now.Add(time.Hour)
+	if tk.AccessExpiredAt.Timestamp() != 0 {
+		exp = tk.AccessExpiredAt.T()
+	}
+
+	idt := &oidc.IDToken{
+		Issuer:    conf.C().JWT.Issuer,
+		Audience:  tk.ClientID,
+		IssuedAt:  now.Unix(),
+		AuthTime:  now.Unix(),
+		ExpiresAt: exp.Unix(),
+		Nonce:     nonce,
+		UserInfo:  oidc.NewUserInfo(u, tk.Scope),
+	}
+
+	tk.IDToken, err = idt.Sign(key)
+	return err
+}
+
+func newJWTClaims(tk *token.Token) *jwt.Claims {
+	claims := &jwt.Claims{
+		Issuer:        conf.C().JWT.Issuer,
+		Subject:       tk.Account,
+		Audience:      tk.ClientID,
+		IssuedAt:      tk.CreatedAt.T().Unix(),
+		ID:            token.MakeBearer(16),
+		Account:       tk.Account,
+		Domain:        tk.Domain,
+		UserType:      tk.UserType,
+		ApplicationID: tk.ApplicationID,
+		ClientID:      tk.ClientID,
+		Scope:         tk.Scope,
+	}
+
+	// 应用令牌的主体为应用自身
+	if tk.IsApplicationToken() {
+		claims.Subject = tk.ClientID
+	}
+
+	if tk.AccessExpiredAt.Timestamp() != 0 {
+		claims.ExpiresAt = tk.AccessExpiredAt.T().Unix()
+	}
+
+	claims.Actor = newJWTActor(tk.Actor)
+	return claims
+}
+
+func newJWTActor(a *token.Actor) *jwt.Actor {
+	if a == nil {
+		return nil
+	}
+
+	return &jwt.Actor{
+		Subject:  a.Subject,
+		ClientID: a.ClientID,
+		Actor:    newJWTActor(a.Actor),
+	}
+}
@@ -0,0 +1,101 @@
+package issuer
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/infraboard/keyauth/pkg/application"
+	"github.com/infraboard/keyauth/pkg/lockout"
+	"github.com/infraboard/keyauth/pkg/token"
+	"github.com/infraboard/keyauth/pkg/user"
+)
+
+const (
+	// 密码校验通过后, 需要在有效期内完成多因素认证
+	mfaChallengeTTL = 5 * time.Minute
+	// MaxMFAChallengeAttempts 动态码错误超过该次数后挑战失效, 需要重新输入密码
+	MaxMFAChallengeAttempts = 5
+)
+
+// MFAChallengeStore 多因素认证挑战的存储, 校验次数需要原子的累加, 并发的猜测不能超过次数限制
+type MFAChallengeStore interface {
+	SaveMFAChallenge(*MFAChallenge) error
+	// 占用一次校验次数, 挑战不存在、已过期或者次数已用完时返回错误
+	IncMFAChallengeAttempts(id string) (*MFAChallenge, error)
+	DeleteMFAChallenge(id string) error
+}
+
+// MFAChallenge 等待完成多因素认证的登录请求
+type MFAChallenge struct {
+	ID       string    `bson:"_id" json:"id"`
+	Account  string    `bson:"account" json:"account"`
+	ClientID string    `bson:"client_id" json:"client_id"`
+	Scope    string    `bson:"scope" json:"scope"`
+	Attempts int       `bson:"attempts" json:"attempts"`   // 已经校验的次数, 包含进行中的校验
+	ExpireAt time.Time `bson:"expire_at" json:"expire_at"` // 过期时间, 通过TTL索引清理
+}
+
+// newMFAChallenge 保存挑战, 通过mfa_required错误返回挑战ID
+func (i *issuer) newMFAChallenge(app *application.Application, u *user.User, req *token.IssueTokenRequest) error {
+	c := &MFAChallenge{
+		ID:       token.MakeBearer(32),
+		Account:  u.Account,
+		ClientID: app.ClientID,
+		Scope:    req.Scope,
+		ExpireAt: time.Now().Add(mfaChallengeTTL),
+	}
+
+	if err := i.challenges.SaveMFAChallenge(c); err != nil {
+		return err
+	}
+
+	return token.NewMFARequiredError(c.ID)
+}
+
+// completeMFAChallenge 校验动态码, 通过后颁发密码模式的令牌
+func (i *issuer) completeMFAChallenge(app *application.Application, req *token.IssueTokenRequest) (*token.Token, error) {
+	c, err := i.challenges.IncMFAChallengeAttempts(req.ChallengeID)
+	if err != nil {
+		i.log.Debugf("inc mfa challenge attempts error, %s", err)
+		return nil, token.NewInvalidGrantError("mfa challenge not found or expired")
+	}
+
+	if c.ClientID != app.ClientID {
+		return nil, token.NewInvalidGrantError("mfa challenge not issued to client %s", app.ClientID)
+	}
+
+	// 动态码错误与密码错误一样计入账号的失败次数, 重新登录获取新的挑战也不能绕过锁定
+	lr := lockout.NewLoginRequest(c.Account, req.GetRemoteIP())
+	if err := i.lockout.CheckLogin(lr); err != nil {
+		return nil, err
+	}
+
+	u, err := i.getUser(c.Account)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := i.user.VerifyMFA(user.NewVerifyMFARequest(c.Account, req.OTP)); err != nil {
+		if err := i.lockout.RecordFailure(lr); err != nil {
+			i.log.Errorf("record mfa failure error, %s", err)
+		}
+		return nil, token.NewInvalidGrantError("verify mfa code error, %s", err)
+	}
+
+	// 挑战只能使用一次
+	if err := i.challenges.DeleteMFAChallenge(c.ID); err != nil {
+		return nil, err
+	}
+	if err := i.lockout.RecordSuccess(lr); err != nil {
+		i.log.Errorf("record mfa success error, %s", err)
+	}
+
+	tk := i.issueUserToken(app, u, token.PASSWORD)
+	tk.Scope = c.Scope
+	tk.MFA = true
+	if err := i.setUserDomain(u, tk); err != nil {
+		return nil, fmt.Errorf("set token domain error, %s", err)
+	}
+
+	return tk, nil
+}
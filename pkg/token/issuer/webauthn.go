@@ -0,0 +1,34 @@
+package issuer
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/infraboard/keyauth/pkg/application"
+	"github.com/infraboard/keyauth/pkg/token"
+	"github.com/infraboard/keyauth/pkg/user"
+	"github.com/infraboard/keyauth/pkg/user/webauthn"
+)
+
+// issueWebAuthnToken 校验认证器的断言, 认证器已经校验了用户身份, 视为完成了多因素认证
+func (i *issuer) issueWebAuthnToken(app *application.Application, req *token.IssueTokenRequest) (*token.Token, error) {
+	assertion := &webauthn.AssertionResponse{}
+	if err := json.Unmarshal([]byte(req.Assertion), assertion); err != nil {
+		return nil, token.NewInvalidRequestError("unmarshal assertion error, %s", err)
+	}
+
+	u, err := i.user.VerifyWebAuthnLogin(user.NewVerifyWebAuthnLoginRequest(req.Username, req.ChallengeID, assertion))
+	if err != nil {
+		i.log.Debugf("verify webauthn assertion error, %s", err)
+		return nil, token.NewInvalidGrantError("webauthn assertion not correct")
+	}
+
+	tk := i.issueUserToken(app, u, token.WEBAUTHN)
+	tk.Scope = req.Scope
+	tk.MFA = true
+	if err := i.setUserDomain(u, tk); err != nil {
+		return nil, fmt.Errorf("set token domain error, %s", err)
+	}
+
+	return tk, nil
+}
@@ -0,0 +1,42 @@
+package jwt
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/infraboard/keyauth/pkg/user/types"
+)
+
+// Claims 访问令牌携带的声明: https://tools.ietf.org/html/rfc7519#section-4
+type Claims struct {
+	Issuer    string `json:"iss,omitempty"`
+	Subject   string `json:"sub,omitempty"`
+	Audience  string `json:"aud,omitempty"`
+	ExpiresAt int64  `json:"exp,omitempty"`
+	IssuedAt  int64  `json:"iat,omitempty"`
+	ID        string `json:"jti,omitempty"`
+
+	Account       string     `json:"account,omitempty"`
+	Domain        string     `json:"domain,omitempty"`
+	UserType      types.Type `json:"user_type,omitempty"`
+	ApplicationID string     `json:"application_id,omitempty"`
+	ClientID      string     `json:"client_id,omitempty"`
+	Scope         string     `json:"scope,omitempty"`
+	Actor         *Actor     `json:"act,omitempty"`
+}
+
+// Actor 代表主体行事的调用方: https://tools.ietf.org/html/rfc8693#section-4.1
+type Actor struct {
+	Subject  string `json:"sub"`
+	ClientID string `json:"client_id,omitempty"`
+	Actor    *Actor `json:"act,omitempty"`
+}
+
+// Valid 校验声明是否过期
+func (c *Claims) Valid() error {
+	if c.ExpiresAt != 0 && time.Now().Unix() > c.ExpiresAt {
+		return fmt.Errorf("token is expired")
+	}
+
+	return nil
+}
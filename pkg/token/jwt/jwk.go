@@ -0,0 +1,141 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+)
+
+// NewJWKSet todo
+func NewJWKSet() *JWKSet {
+	return &JWKSet{
+		Keys: []*JWK{},
+	}
+}
+
+// JWKSet https://tools.ietf.org/html/rfc7517#section-5
+type JWKSet struct {
+	Keys []*JWK `json:"keys"`
+}
+
+// Add 添加公钥
+func (s *JWKSet) Add(item *JWK) {
+	s.Keys = append(s.Keys, item)
+}
+
+// SigningKey JWKSet只包含公钥, 不能用于签名
+func (s *JWKSet) SigningKey() (*Key, error) {
+	return nil, fmt.Errorf("jwk set has no private key")
+}
+
+// VerifyKey 根据kid获取公钥, 资源服务可以使用JWKSet离线校验令牌
+func (s *JWKSet) VerifyKey(kid string) (*Key, error) {
+	for i := range s.Keys {
+		if s.Keys[i].KeyID == kid {
+			return s.Keys[i].Key()
+		}
+	}
+
+	return nil, fmt.Errorf("key %s not found", kid)
+}
+
+// JWK 公钥的JSON表示: https://tools.ietf.org/html/rfc7517#section-4
+type JWK struct {
+	KeyType   string `json:"kty"`
+	Use       string `json:"use,omitempty"`
+	KeyID     string `json:"kid,omitempty"`
+	Algorithm string `json:"alg,omitempty"`
+
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+
+	// EC
+	Curve string `json:"crv,omitempty"`
+	X     string `json:"x,omitempty"`
+	Y     string `json:"y,omitempty"`
+}
+
+// NewJWK 导出秘钥的公钥部分
+func NewJWK(k *Key) (*JWK, error) {
+	jwk := &JWK{
+		Use:       "sig",
+		KeyID:     k.ID,
+		Algorithm: string(k.Algorithm),
+	}
+
+	switch pub := k.PublicKey.(type) {
+	case *rsa.PublicKey:
+		jwk.KeyType = "RSA"
+		jwk.N = encodeSegment(pub.N.Bytes())
+		jwk.E = encodeSegment(big.NewInt(int64(pub.E)).Bytes())
+	case *ecdsa.PublicKey:
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		jwk.KeyType = "EC"
+		jwk.Curve = pub.Curve.Params().Name
+		jwk.X = encodeSegment(padBytes(pub.X.Bytes(), size))
+		jwk.Y = encodeSegment(padBytes(pub.Y.Bytes(), size))
+	default:
+		return nil, fmt.Errorf("unsupported public key type %T", pub)
+	}
+
+	return jwk, nil
+}
+
+// Key 转换为仅包含公钥的秘钥
+func (j *JWK) Key() (*Key, error) {
+	alg, err := ParseAlgorithm(j.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	k := &Key{ID: j.KeyID, Algorithm: alg}
+	switch j.KeyType {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(j.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(j.E)
+		if err != nil {
+			return nil, err
+		}
+		k.PublicKey = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	case "EC":
+		if j.Curve != elliptic.P256().Params().Name {
+			return nil, fmt.Errorf("unsupported curve %s", j.Curve)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(j.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(j.Y)
+		if err != nil {
+			return nil, err
+		}
+		k.PublicKey = &ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}
+	default:
+		return nil, fmt.Errorf("unsupported key type %s", j.KeyType)
+	}
+
+	return k, nil
+}
+
+func padBytes(b []byte, size int) []byte {
+	if len(b) >= size {
+		return b
+	}
+	buf := make([]byte, size)
+	copy(buf[size-len(b):], b)
+	return buf
+}
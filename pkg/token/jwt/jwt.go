@@ -0,0 +1,147 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// Header JOSE Header: https://tools.ietf.org/html/rfc7515#section-4
+type Header struct {
+	Algorithm Algorithm `json:"alg"`
+	Type      string    `json:"typ,omitempty"`
+	KeyID     string    `json:"kid,omitempty"`
+}
+
+// IsJWT 判断字符串是否是JWS Compact格式
+func IsJWT(token string) bool {
+	return strings.Count(token, ".") == 2
+}
+
+// Sign 使用秘钥签名, 返回 JWS Compact Serialization
+func Sign(key *Key, claims interface{}) (string, error) {
+	if !key.CanSign() {
+		return "", errors.New("key has no private key")
+	}
+
+	header, err := json.Marshal(&Header{Algorithm: key.Algorithm, Type: "JWT", KeyID: key.ID})
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := encodeSegment(header) + "." + encodeSegment(payload)
+	sig, err := signDigest(key, signingInput)
+	if err != nil {
+		return "", err
+	}
+
+	return signingInput + "." + encodeSegment(sig), nil
+}
+
+// Parse 校验签名并解析声明, 不校验过期时间
+func Parse(token string, keys KeyProvider, claims interface{}) (*Header, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("token contains an invalid number of segments")
+	}
+
+	hb, err := decodeSegment(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("decode header error, %s", err)
+	}
+	header := new(Header)
+	if err := json.Unmarshal(hb, header); err != nil {
+		return nil, fmt.Errorf("unmarshal header error, %s", err)
+	}
+
+	key, err := keys.VerifyKey(header.KeyID)
+	if err != nil {
+		return nil, err
+	}
+	// 防止算法替换攻击, 以秘钥的算法为准
+	if header.Algorithm != key.Algorithm {
+		return nil, fmt.Errorf("algorithm %s not match key", header.Algorithm)
+	}
+
+	sig, err := decodeSegment(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("decode signature error, %s", err)
+	}
+	if err := verifyDigest(key, parts[0]+"."+parts[1], sig); err != nil {
+		return nil, err
+	}
+
+	payload, err := decodeSegment(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("decode payload error, %s", err)
+	}
+	if err := json.Unmarshal(payload, claims); err != nil {
+		return nil, fmt.Errorf("unmarshal claims error, %s", err)
+	}
+
+	return header, nil
+}
+
+func signDigest(key *Key, input string) ([]byte, error) {
+	digest := sha256.Sum256([]byte(input))
+
+	switch priv := key.PrivateKey.(type) {
+	case *rsa.PrivateKey:
+		return rsa.SignPKCS1v15(rand.Reader, priv, crypto.SHA256, digest[:])
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, priv, digest[:])
+		if err != nil {
+			return nil, err
+		}
+		// ES256 签名为定长的 R || S: https://tools.ietf.org/html/rfc7518#section-3.4
+		size := (priv.Curve.Params().BitSize + 7) / 8
+		return append(padBytes(r.Bytes(), size), padBytes(s.Bytes(), size)...), nil
+	default:
+		return nil, fmt.Errorf("unsupported private key type %T", priv)
+	}
+}
+
+func verifyDigest(key *Key, input string, sig []byte) error {
+	digest := sha256.Sum256([]byte(input))
+
+	switch pub := key.PublicKey.(type) {
+	case *rsa.PublicKey:
+		if err := rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], sig); err != nil {
+			return errors.New("signature is invalid")
+		}
+	case *ecdsa.PublicKey:
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		if len(sig) != 2*size {
+			return errors.New("signature is invalid")
+		}
+		r := new(big.Int).SetBytes(sig[:size])
+		s := new(big.Int).SetBytes(sig[size:])
+		if !ecdsa.Verify(pub, digest[:], r, s) {
+			return errors.New("signature is invalid")
+		}
+	default:
+		return fmt.Errorf("unsupported public key type %T", pub)
+	}
+
+	return nil
+}
+
+func encodeSegment(seg []byte) string {
+	return base64.RawURLEncoding.EncodeToString(seg)
+}
+
+func decodeSegment(seg string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(seg)
+}
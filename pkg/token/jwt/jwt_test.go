@@ -0,0 +1,112 @@
+package jwt_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/infraboard/keyauth/pkg/token/jwt"
+)
+
+// keyProvider 测试使用的单个临时秘钥
+type keyProvider struct {
+	key *jwt.Key
+}
+
+func newKeyProvider(t *testing.T, alg jwt.Algorithm) *keyProvider {
+	key, err := jwt.GenerateKey(alg)
+	require.NoError(t, err)
+	return &keyProvider{key: key}
+}
+
+func (p *keyProvider) SigningKey() (*jwt.Key, error) {
+	return p.key, nil
+}
+
+func (p *keyProvider) VerifyKey(kid string) (*jwt.Key, error) {
+	if kid != p.key.ID {
+		return nil, fmt.Errorf("key %s not found", kid)
+	}
+
+	return p.key, nil
+}
+
+func TestSignAndParse(t *testing.T) {
+	for _, alg := range []jwt.Algorithm{jwt.RS256, jwt.ES256} {
+		t.Run(string(alg), func(t *testing.T) {
+			should := require.New(t)
+
+			keys := newKeyProvider(t, alg)
+			key, err := keys.SigningKey()
+			should.NoError(err)
+
+			claims := &jwt.Claims{
+				Subject:   "admin",
+				Account:   "admin",
+				Domain:    "admin-domain",
+				Scope:     "openid",
+				ExpiresAt: time.Now().Add(time.Hour).Unix(),
+			}
+			tk, err := jwt.Sign(key, claims)
+			should.NoError(err)
+			should.True(jwt.IsJWT(tk))
+
+			parsed := new(jwt.Claims)
+			header, err := jwt.Parse(tk, keys, parsed)
+			should.NoError(err)
+			should.Equal(key.ID, header.KeyID)
+			should.Equal(claims, parsed)
+			should.NoError(parsed.Valid())
+
+			// 篡改payload后签名校验失败
+			tampered := tk[:len(tk)-4] + "AAAA"
+			_, err = jwt.Parse(tampered, keys, new(jwt.Claims))
+			should.Error(err)
+		})
+	}
+}
+
+func TestParseWithUnknownKey(t *testing.T) {
+	should := require.New(t)
+
+	signer := newKeyProvider(t, jwt.ES256)
+	other := newKeyProvider(t, jwt.ES256)
+
+	key, err := signer.SigningKey()
+	should.NoError(err)
+	tk, err := jwt.Sign(key, &jwt.Claims{Subject: "admin"})
+	should.NoError(err)
+
+	_, err = jwt.Parse(tk, other, new(jwt.Claims))
+	should.Error(err)
+}
+
+func TestClaimsExpired(t *testing.T) {
+	should := require.New(t)
+
+	c := &jwt.Claims{ExpiresAt: time.Now().Add(-time.Minute).Unix()}
+	should.Error(c.Valid())
+}
+
+func TestVerifyWithJWKSet(t *testing.T) {
+	for _, alg := range []jwt.Algorithm{jwt.RS256, jwt.ES256} {
+		t.Run(string(alg), func(t *testing.T) {
+			should := require.New(t)
+
+			key, err := jwt.GenerateKey(alg)
+			should.NoError(err)
+			jwk, err := jwt.NewJWK(key)
+			should.NoError(err)
+
+			set := jwt.NewJWKSet()
+			set.Add(jwk)
+
+			tk, err := jwt.Sign(key, &jwt.Claims{Subject: "admin"})
+			should.NoError(err)
+			_, err = jwt.Parse(tk, set, new(jwt.Claims))
+			should.NoError(err)
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+)
+
+// Algorithm JWS签名算法: https://tools.ietf.org/html/rfc7518#section-3.1
+type Algorithm string
+
+const (
+	// RS256 RSASSA-PKCS1-v1_5 using SHA-256
+	RS256 Algorithm = "RS256"
+	// ES256 ECDSA using P-256 and SHA-256
+	ES256 Algorithm = "ES256"
+)
+
+// ParseAlgorithm todo
+func ParseAlgorithm(alg string) (Algorithm, error) {
+	switch Algorithm(alg) {
+	case RS256, "":
+		return RS256, nil
+	case ES256:
+		return ES256, nil
+	default:
+		return "", fmt.Errorf("unsupported jwt algorithm %s", alg)
+	}
+}
+
+// KeyProvider 提供签名和校验所需的秘钥
+type KeyProvider interface {
+	// SigningKey 当前用于签名的秘钥
+	SigningKey() (*Key, error)
+	// VerifyKey 根据kid获取校验签名的公钥
+	VerifyKey(kid string) (*Key, error)
+}
+
+// Key 签名秘钥, 仅用于校验时PrivateKey为空
+type Key struct {
+	ID         string
+	Algorithm  Algorithm
+	PrivateKey crypto.Signer
+	PublicKey  crypto.PublicKey
+}
+
+// CanSign 是否可用于签名
+func (k *Key) CanSign() bool {
+	return k.PrivateKey != nil
+}
+
+// GenerateKey 生成新的签名秘钥
+func GenerateKey(alg Algorithm) (*Key, error) {
+	var (
+		priv crypto.Signer
+		err  error
+	)
+
+	switch alg {
+	case RS256:
+		priv, err = rsa.GenerateKey(rand.Reader, 2048)
+	case ES256:
+		priv, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	default:
+		return nil, fmt.Errorf("unsupported jwt algorithm %s", alg)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return NewKey(alg, priv)
+}
+
+// NewKey 使用已有私钥, kid为公钥的指纹
+func NewKey(alg Algorithm, priv crypto.Signer) (*Key, error) {
+	switch priv.(type) {
+	case *rsa.PrivateKey:
+		if alg != RS256 {
+			return nil, fmt.Errorf("rsa key can't use with %s", alg)
+		}
+	case *ecdsa.PrivateKey:
+		if alg != ES256 {
+			return nil, fmt.Errorf("ecdsa key can't use with %s", alg)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported private key type %T", priv)
+	}
+
+	kid, err := Thumbprint(priv.Public())
+	if err != nil {
+		return nil, err
+	}
+
+	return &Key{
+		ID:         kid,
+		Algorithm:  alg,
+		PrivateKey: priv,
+		PublicKey:  priv.Public(),
+	}, nil
+}
+
+// Thumbprint 公钥指纹, 用作kid
+func Thumbprint(pub crypto.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(der)
+
+	return base64.RawURLEncoding.EncodeToString(sum[:12]), nil
+}
+
+// MarshalPrivateKeyPEM 私钥编码为PKCS8 PEM
+func (k *Key) MarshalPrivateKeyPEM() ([]byte, error) {
+	if !k.CanSign() {
+		return nil, errors.New("key has no private key")
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(k.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+// ParsePrivateKeyPEM 支持PKCS8, PKCS1(RSA), SEC1(EC)格式
+func ParsePrivateKeyPEM(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no pem block found")
+	}
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		return x509.ParseECPrivateKey(block.Bytes)
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+
+	return signer, nil
+}
@@ -0,0 +1,50 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"github.com/infraboard/mcube/exception"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/infraboard/keyauth/pkg/token/issuer"
+)
+
+func (s *service) SaveMFAChallenge(c *issuer.MFAChallenge) error {
+	if _, err := s.challenge.InsertOne(context.TODO(), c); err != nil {
+		return exception.NewInternalServerError("save mfa challenge error, %s", err)
+	}
+
+	return nil
+}
+
+func (s *service) IncMFAChallengeAttempts(id string) (*issuer.MFAChallenge, error) {
+	c := &issuer.MFAChallenge{}
+	err := s.challenge.FindOneAndUpdate(context.TODO(),
+		bson.M{
+			"_id":       id,
+			"attempts":  bson.M{"$lt": issuer.MaxMFAChallengeAttempts},
+			"expire_at": bson.M{"$gt": time.Now()},
+		},
+		bson.M{"$inc": bson.M{"attempts": 1}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(c)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, exception.NewNotFound("mfa challenge %s not found or expired", id)
+		}
+		return nil, exception.NewInternalServerError("inc mfa challenge attempts error, %s", err)
+	}
+
+	return c, nil
+}
+
+func (s *service) DeleteMFAChallenge(id string) error {
+	if _, err := s.challenge.DeleteOne(context.TODO(), bson.M{"_id": id}); err != nil {
+		return exception.NewInternalServerError("delete mfa challenge error, %s", err)
+	}
+
+	return nil
+}
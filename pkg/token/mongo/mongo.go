@@ -3,10 +3,9 @@ package mongo
 import (
 	"context"
 	"errors"
-	"fmt"
-	"time"
 
-	"github.com/infraboard/mcube/cache"
+	"github.com/infraboard/mcube/logger"
+	"github.com/infraboard/mcube/logger/zap"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.mongodb.org/mongo-driver/x/bsonx"
@@ -17,8 +16,10 @@ import (
 	"github.com/infraboard/keyauth/pkg/audit"
 	"github.com/infraboard/keyauth/pkg/domain"
 	"github.com/infraboard/keyauth/pkg/endpoint"
+	"github.com/infraboard/keyauth/pkg/lockout"
 	"github.com/infraboard/keyauth/pkg/token"
 	"github.com/infraboard/keyauth/pkg/token/issuer"
+	"github.com/infraboard/keyauth/pkg/token/jwt"
 	"github.com/infraboard/keyauth/pkg/user"
 )
 
@@ -29,6 +30,8 @@ var (
 
 type service struct {
 	col           *mongo.Collection
+	used          *mongo.Collection
+	challenge     *mongo.Collection
 	enableCache   bool
 	notifyCachPre string
 
@@ -36,10 +39,11 @@ type service struct {
 	user     user.Service
 	domain   domain.Service
 	issuer   issuer.Issuer
+	keys     jwt.KeyProvider
 	endpoint endpoint.Service
 	audit    audit.Service
-	cache    cache.Cache
-	retryTTL time.Duration
+	lockout  lockout.Service
+	log      logger.Logger
 }
 
 func (s *service) Config() error {
@@ -68,17 +72,15 @@ func (s *service) Config() error {
 	}
 	s.audit = pkg.Audit
 
-	issuer, err := issuer.NewTokenIssuer()
-	if err != nil {
-		return err
+	if pkg.KeyStore == nil {
+		return errors.New("denpence keystore service is nil")
 	}
-	s.issuer = issuer
+	s.keys = pkg.KeyStore
 
-	c := cache.C()
-	if c == nil {
-		return fmt.Errorf("denpence cache service is nil")
+	if pkg.Lockout == nil {
+		return errors.New("denpence lockout service is nil")
 	}
-	s.cache = c
+	s.lockout = pkg.Lockout
 
 	db := conf.C().Mongo.GetDB()
 	col := db.Collection("token")
@@ -91,19 +93,51 @@ func (s *service) Config() error {
 		{
 			Keys: bsonx.Doc{{Key: "create_at", Value: bsonx.Int32(-1)}},
 		},
+		{
+			Keys: bsonx.Doc{{Key: "family_id", Value: bsonx.Int32(-1)}},
+		},
+	}
+
+	_, err := col.Indexes().CreateMany(context.Background(), indexs)
+	if err != nil {
+		return err
 	}
 
-	_, err = col.Indexes().CreateMany(context.Background(), indexs)
+	// 已轮换的刷新令牌, 用于检测重放
+	used := db.Collection("used_refresh_token")
+	_, err = used.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys:    bsonx.Doc{{Key: "expire_at", Value: bsonx.Int32(1)}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	if err != nil {
+		return err
+	}
+
+	// 等待完成多因素认证的登录挑战
+	challenge := db.Collection("mfa_challenge")
+	_, err = challenge.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys:    bsonx.Doc{{Key: "expire_at", Value: bsonx.Int32(1)}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
 	if err != nil {
 		return err
 	}
 
 	s.col = col
-	s.retryTTL = 5 * time.Minute
+	s.used = used
+	s.challenge = challenge
+
+	// 令牌服务同时作为颁发器的挑战存储
+	s.issuer, err = issuer.NewTokenIssuer(s.keys, s)
+	if err != nil {
+		return err
+	}
+	s.log = zap.L().Named("Token")
 	return nil
 }
 
 func init() {
 	var _ token.Service = Service
+	var _ issuer.MFAChallengeStore = Service
 	pkg.RegistryService("token", Service)
 }
@@ -81,3 +81,30 @@ func (r *queryRequest) FindFilter() bson.M {
 	}
 	return filter
 }
+
+func newBatchRevolkRequest(req *token.BatchRevolkTokenRequest) *batchRevolkRequest {
+	return &batchRevolkRequest{req}
+}
+
+type batchRevolkRequest struct {
+	*token.BatchRevolkTokenRequest
+}
+
+func (r *batchRevolkRequest) FindFilter() bson.M {
+	filter := bson.M{}
+
+	if r.Domain != "" {
+		filter["domain"] = r.Domain
+	}
+	if r.Account != "" {
+		filter["account"] = r.Account
+	}
+	if r.ApplicationID != "" {
+		filter["application_id"] = r.ApplicationID
+	}
+	if r.FamilyID != "" {
+		filter["family_id"] = r.FamilyID
+	}
+
+	return filter
+}
@@ -0,0 +1,144 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/infraboard/mcube/exception"
+	"github.com/infraboard/mcube/types/ftime"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/infraboard/keyauth/pkg/audit"
+	"github.com/infraboard/keyauth/pkg/token"
+)
+
+const (
+	refreshTokenReuseAction = "refresh_token_reuse"
+
+	// 刷新令牌永不过期时, 轮换记录保留的时长
+	usedRefreshTokenRetain = 90 * 24 * time.Hour
+
+	duplicateKeyErrorCode = 11000
+)
+
+func newUsedRefreshToken(refreshToken string, tk *token.Token) *usedRefreshToken {
+	// 历史令牌没有令牌族时以原令牌作为族ID, 与刷新时保持一致
+	familyID := tk.FamilyID
+	if familyID == "" {
+		familyID = tk.AccessToken
+	}
+
+	expireAt := tk.RefreshExpiredAt.T()
+	if tk.RefreshExpiredAt.Timestamp() <= 0 {
+		expireAt = time.Now().Add(usedRefreshTokenRetain)
+	}
+
+	return &usedRefreshToken{
+		RefreshToken:  refreshToken,
+		FamilyID:      familyID,
+		Domain:        tk.Domain,
+		Account:       tk.Account,
+		ApplicationID: tk.ApplicationID,
+		UsedAt:        ftime.Now(),
+		ExpireAt:      expireAt,
+	}
+}
+
+// usedRefreshToken 已经轮换过的刷新令牌, 该令牌过期后由TTL索引自动清理
+type usedRefreshToken struct {
+	RefreshToken  string     `bson:"_id"`
+	FamilyID      string     `bson:"family_id"`
+	Domain        string     `bson:"domain"`
+	Account       string     `bson:"account"`
+	ApplicationID string     `bson:"application_id"`
+	UsedAt        ftime.Time `bson:"used_at"`
+	ExpireAt      time.Time  `bson:"expire_at"`
+}
+
+// claimRefreshToken 刷新前先占用刷新令牌, 并发刷新时只有一个请求能够占用成功,
+// 占用失败说明该刷新令牌已经被使用过
+func (s *service) claimRefreshToken(refreshToken string) error {
+	tk, err := s.describeToken(newDescribeTokenRequestWithRefresh(refreshToken))
+	if err != nil {
+		if !exception.IsNotFoundError(err) {
+			return err
+		}
+		// 令牌已经轮换删除, 检查是否被重放
+		return s.checkRefreshTokenReuse(refreshToken)
+	}
+
+	_, err = s.used.InsertOne(context.TODO(), newUsedRefreshToken(refreshToken, tk))
+	if err == nil {
+		return nil
+	}
+	if isDuplicateKeyError(err) {
+		return s.checkRefreshTokenReuse(refreshToken)
+	}
+
+	return exception.NewInternalServerError("inserted used refresh token document error, %s", err)
+}
+
+// releaseRefreshToken 刷新失败时释放占用, 避免无效的请求将刷新令牌标记为已使用
+func (s *service) releaseRefreshToken(refreshToken string) {
+	if _, err := s.used.DeleteOne(context.TODO(), bson.M{"_id": refreshToken}); err != nil {
+		s.log.Errorf("delete used refresh token error, %s", err)
+	}
+}
+
+// checkRefreshTokenReuse 已经使用过的刷新令牌被再次使用, 说明令牌可能已经泄露, 撤销整个令牌族
+func (s *service) checkRefreshTokenReuse(refreshToken string) error {
+	used := new(usedRefreshToken)
+	err := s.used.FindOne(context.TODO(), bson.M{"_id": refreshToken}).Decode(used)
+	if err == mongo.ErrNoDocuments {
+		return nil
+	}
+	if err != nil {
+		return exception.NewInternalServerError("find used refresh token error, %s", err)
+	}
+
+	req := token.NewBatchRevolkTokenRequest()
+	req.FamilyID = used.FamilyID
+	count, err := s.BatchRevolkToken(req)
+	if err != nil {
+		return err
+	}
+
+	s.saveReuseLog(used, count)
+	return token.NewInvalidGrantError("refresh_token has been used, all tokens of this session are revoked")
+}
+
+func isDuplicateKeyError(err error) bool {
+	we, ok := err.(mongo.WriteException)
+	if !ok {
+		return false
+	}
+
+	for i := range we.WriteErrors {
+		if we.WriteErrors[i].Code == duplicateKeyErrorCode {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s *service) saveReuseLog(used *usedRefreshToken, count int64) {
+	data := audit.NewDefaultOperateLogData()
+	data.Account = used.Account
+	data.ApplicationID = used.ApplicationID
+	data.ResourceType = "token"
+	data.ResourceID = used.FamilyID
+	data.Action = refreshTokenReuseAction
+	data.Result = audit.Failed
+	data.Comment = fmt.Sprintf("refresh token reused, %d tokens revoked", count)
+
+	// 审计日志需要通过令牌获取所处域
+	data.WithToken(&token.Token{
+		Domain:        used.Domain,
+		Account:       used.Account,
+		ApplicationID: used.ApplicationID,
+	})
+	s.audit.SaveOperateRecord(data)
+}
@@ -7,18 +7,32 @@ import (
 	"go.mongodb.org/mongo-driver/mongo"
 
 	"github.com/infraboard/keyauth/pkg/audit"
+	"github.com/infraboard/keyauth/pkg/lockout"
 	"github.com/infraboard/keyauth/pkg/token"
+	"github.com/infraboard/keyauth/pkg/token/jwt"
 )
 
 func (s *service) IssueToken(req *token.IssueTokenRequest) (*token.Token, error) {
-	fl := s.inspectRequest(req)
-	if err := fl.CheckBlook(); err != nil {
-		return nil, exception.NewBadRequest("inspect request error, %s", err)
+	lr := lockout.NewLoginRequest(req.Username, req.GetRemoteIP())
+	if err := s.lockout.CheckLogin(lr); err != nil {
+		return nil, err
+	}
+
+	// 先占用刷新令牌, 同一个刷新令牌只能成功刷新一次, 再次使用时撤销整个令牌族
+	if req.GrantType.Is(token.REFRESH) {
+		if err := s.claimRefreshToken(req.RefreshToken); err != nil {
+			return nil, err
+		}
 	}
 
 	tk, err := s.issuer.IssueToken(req)
 	if err != nil {
-		s.saveAbnormalLogin(req, fl)
+		if req.GrantType.Is(token.REFRESH) {
+			s.releaseRefreshToken(req.RefreshToken)
+		}
+		if isCredentialFailure(req, err) {
+			s.recordLoginFailure(lr)
+		}
 		return nil, err
 	}
 
@@ -27,20 +41,34 @@ func (s *service) IssueToken(req *token.IssueTokenRequest) (*token.Token, error)
 			tk.AccessToken, err)
 	}
 
+	if err := s.lockout.RecordSuccess(lr); err != nil {
+		s.log.Errorf("record login success error, %s", err)
+	}
 	s.saveLoginLog(req, tk)
 	return tk, nil
 }
 
-func (s *service) inspectRequest(req *token.IssueTokenRequest) *FailedLogin {
-	fl := NewFailedLogin()
-	s.cache.Get(req.AbnormalUserCheckKey(), fl)
-	return fl
+// 只有密码类授权的用户名密码错误计入失败次数, 客户端配置错误、设备码、刷新令牌等失败与用户凭证无关,
+// 计入共享的来源IP会锁定同一出口下所有用户的登录, 动态码错误由颁发器按照挑战所属的账号记录
+func isCredentialFailure(req *token.IssueTokenRequest, err error) bool {
+	if !req.GrantType.Is(token.PASSWORD, token.LDAP) {
+		return false
+	}
+
+	// invalid_client, mfa_required, password_expired等协议错误不是凭证错误
+	if _, ok := err.(*token.OAuthError); ok {
+		return false
+	}
+
+	e, ok := err.(exception.APIException)
+	return ok && e.ErrorCode() == exception.Unauthorized
 }
 
-// 记录登录失败的次数
-func (s *service) saveAbnormalLogin(req *token.IssueTokenRequest, fl *FailedLogin) {
-	fl.Inc()
-	s.cache.PutWithTTL("abnormal_"+req.Username, fl, s.retryTTL)
+// 记录登录失败的次数, 超过阈值后锁定
+func (s *service) recordLoginFailure(lr *lockout.LoginRequest) {
+	if err := s.lockout.RecordFailure(lr); err != nil {
+		s.log.Errorf("record login failure error, %s", err)
+	}
 }
 
 func (s *service) saveLoginLog(req *token.IssueTokenRequest, tk *token.Token) {
@@ -51,6 +79,7 @@ func (s *service) saveLoginLog(req *token.IssueTokenRequest, tk *token.Token) {
 	data.ApplicationName = tk.ApplicationName
 	data.GrantType = tk.GrantType
 	data.LoginIP = req.GetRemoteIP()
+	data.Actor = tk.Actor.String()
 
 	data.WithUserAgent(req.GetUserAgent())
 	data.WithToken(tk)
@@ -83,6 +112,13 @@ func (s *service) ValidateToken(req *token.ValidateTokenRequest) (*token.Token,
 		return nil, exception.NewBadRequest(err.Error())
 	}
 
+	// JWT格式的令牌先校验签名, 伪造的令牌无需查库
+	if jwt.IsJWT(req.AccessToken) {
+		if err := s.verifyJWT(req.AccessToken); err != nil {
+			return nil, err
+		}
+	}
+
 	tk, err := s.describeToken(newDescribeTokenRequest(req.DescribeTokenRequest))
 	if err != nil {
 		return nil, exception.NewUnauthorized(err.Error())
@@ -107,6 +143,87 @@ func (s *service) ValidateToken(req *token.ValidateTokenRequest) (*token.Token,
 	return tk, nil
 }
 
+func (s *service) IntrospectToken(req *token.IntrospectTokenRequest) (*token.Introspection, error) {
+	// 调用方需要使用自己的客户端凭证认证
+	if req.ClientID == "" || req.ClientSecret == "" {
+		return nil, token.NewInvalidClientError("client_id and client_secret required")
+	}
+	if _, err := s.issuer.CheckClient(req.ClientID, req.ClientSecret); err != nil {
+		return nil, token.NewInvalidClientError("%s", err)
+	}
+
+	if err := req.Validate(); err != nil {
+		return nil, token.NewInvalidRequestError("%s", err)
+	}
+
+	// 无论令牌因何无效, 都只返回active=false
+	tk, hint, err := s.lookupToken(req.Token, req.TokenTypeHint)
+	if err != nil {
+		return nil, err
+	}
+	if tk == nil || tk.IsBlock {
+		return token.NewInactiveIntrospection(), nil
+	}
+
+	switch hint {
+	case token.RefreshTokenHint:
+		if tk.CheckRefreshIsExpired() {
+			return token.NewInactiveIntrospection(), nil
+		}
+	default:
+		if tk.CheckAccessIsExpired() {
+			return token.NewInactiveIntrospection(), nil
+		}
+	}
+
+	return token.NewIntrospection(tk, hint), nil
+}
+
+// lookupToken 按照提示的类型查找令牌, 找不到时再尝试另一种类型
+func (s *service) lookupToken(value string, hint token.TokenTypeHint) (*token.Token, token.TokenTypeHint, error) {
+	hints := []token.TokenTypeHint{token.AccessTokenHint, token.RefreshTokenHint}
+	if hint == token.RefreshTokenHint {
+		hints = []token.TokenTypeHint{token.RefreshTokenHint, token.AccessTokenHint}
+	}
+
+	for _, h := range hints {
+		var descReq *describeTokenRequest
+		switch h {
+		case token.RefreshTokenHint:
+			descReq = newDescribeTokenRequestWithRefresh(value)
+		default:
+			if jwt.IsJWT(value) && s.verifyJWT(value) != nil {
+				continue
+			}
+			descReq = newDescribeTokenRequestWithAccess(value)
+		}
+
+		tk, err := s.describeToken(descReq)
+		if exception.IsNotFoundError(err) {
+			continue
+		}
+		if err != nil {
+			return nil, h, err
+		}
+		return tk, h, nil
+	}
+
+	return nil, hint, nil
+}
+
+func (s *service) verifyJWT(accessToken string) error {
+	claims := new(jwt.Claims)
+	if _, err := jwt.Parse(accessToken, s.keys, claims); err != nil {
+		return exception.NewUnauthorized("invalid jwt, %s", err)
+	}
+
+	if err := claims.Valid(); err != nil {
+		return exception.NewAccessTokenExpired("access_token has expired")
+	}
+
+	return nil
+}
+
 func (s *service) QueryToken(req *token.QueryTokenRequest) (*token.Set, error) {
 	query := newQueryRequest(req)
 	resp, err := s.col.Find(context.TODO(), query.FindFilter(), query.FindOptions())
@@ -144,7 +261,12 @@ func (s *service) RevolkToken(req *token.RevolkTokenRequest) error {
 	// 检测撤销token的客户端是否合法
 	app, err := s.issuer.CheckClient(req.ClientID, req.ClientSecret)
 	if err != nil {
-		return exception.NewUnauthorized(err.Error())
+		return token.NewInvalidClientError("%s", err)
+	}
+
+	// 标准协议撤销: https://tools.ietf.org/html/rfc7009#section-2.2
+	if req.Token != "" {
+		return s.revolkClientToken(app.ID, req.Token, req.TokenTypeHint)
 	}
 
 	// 检测被撤销token的合法性
@@ -163,6 +285,43 @@ func (s *service) RevolkToken(req *token.RevolkTokenRequest) error {
 	return s.destoryToken(descReq)
 }
 
+// revolkClientToken 令牌不存在或者已经失效时视为撤销成功
+func (s *service) revolkClientToken(appID, value string, hint token.TokenTypeHint) error {
+	tk, _, err := s.lookupToken(value, hint)
+	if err != nil {
+		return err
+	}
+	if tk == nil {
+		return nil
+	}
+
+	if err := tk.CheckTokenApplication(appID); err != nil {
+		return token.NewUnauthorizedClientError("%s", err)
+	}
+
+	// 访问令牌与刷新令牌保存在同一条记录中, 撤销任意一个都会同时撤销另外一个
+	s.saveLogoutLog(tk)
+	err = s.destoryToken(newDescribeTokenRequestWithAccess(tk.AccessToken))
+	if err != nil && !exception.IsNotFoundError(err) {
+		return err
+	}
+	return nil
+}
+
+func (s *service) BatchRevolkToken(req *token.BatchRevolkTokenRequest) (int64, error) {
+	if err := req.Validate(); err != nil {
+		return 0, exception.NewBadRequest(err.Error())
+	}
+
+	filter := newBatchRevolkRequest(req).FindFilter()
+	resp, err := s.col.DeleteMany(context.TODO(), filter)
+	if err != nil {
+		return 0, exception.NewInternalServerError("delete tokens(%v) error, %s", filter, err)
+	}
+
+	return resp.DeletedCount, nil
+}
+
 func (s *service) destoryToken(req *describeTokenRequest) error {
 	resp, err := s.col.DeleteOne(context.TODO(), req.FindFilter())
 	if err != nil {
@@ -0,0 +1,189 @@
+package token
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/infraboard/mcube/exception"
+)
+
+// oauth2 Error Response: https://tools.ietf.org/html/rfc6749#section-5.2
+const (
+	ErrInvalidRequest       = "invalid_request"
+	ErrInvalidClient        = "invalid_client"
+	ErrInvalidGrant         = "invalid_grant"
+	ErrUnauthorizedClient   = "unauthorized_client"
+	ErrUnsupportedGrantType = "unsupported_grant_type"
+	ErrInvalidScope         = "invalid_scope"
+	ErrServerError          = "server_error"
+)
+
+// device flow Error Response: https://tools.ietf.org/html/rfc8628#section-3.5
+const (
+	ErrAuthorizationPending = "authorization_pending"
+	ErrSlowDown             = "slow_down"
+	ErrAccessDenied         = "access_denied"
+	ErrExpiredToken         = "expired_token"
+)
+
+const (
+	// ErrMFARequired 用户启用了多因素认证, 需要使用挑战ID和动态码完成登录
+	ErrMFARequired = "mfa_required"
+	// ErrPasswordExpired 密码已经超过有效期, 需要修改密码后才能登录
+	ErrPasswordExpired = "password_expired"
+)
+
+// NewOAuthError 构造oauth2协议的错误, code为对应的异常码
+func NewOAuthError(code int, errType, format string, a ...interface{}) *OAuthError {
+	return &OAuthError{
+		APIException: exception.NewAPIException(exception.GlobalNamespace.String(), code, errType, format, a...),
+		ErrorType:    errType,
+		Description:  fmt.Sprintf(format, a...),
+	}
+}
+
+// NewInvalidClientError 客户端认证失败
+func NewInvalidClientError(format string, a ...interface{}) *OAuthError {
+	return NewOAuthError(exception.Unauthorized, ErrInvalidClient, format, a...)
+}
+
+// NewInvalidGrantError 授权凭证(授权码, 刷新令牌, 密码等)不合法
+func NewInvalidGrantError(format string, a ...interface{}) *OAuthError {
+	return NewOAuthError(exception.BadRequest, ErrInvalidGrant, format, a...)
+}
+
+// NewInvalidRequestError 请求参数不合法
+func NewInvalidRequestError(format string, a ...interface{}) *OAuthError {
+	return NewOAuthError(exception.BadRequest, ErrInvalidRequest, format, a...)
+}
+
+// NewUnauthorizedClientError 客户端不允许使用该授权类型
+func NewUnauthorizedClientError(format string, a ...interface{}) *OAuthError {
+	return NewOAuthError(exception.BadRequest, ErrUnauthorizedClient, format, a...)
+}
+
+// NewUnsupportedGrantTypeError 不支持的授权类型
+func NewUnsupportedGrantTypeError(format string, a ...interface{}) *OAuthError {
+	return NewOAuthError(exception.BadRequest, ErrUnsupportedGrantType, format, a...)
+}
+
+// NewInvalidScopeError 申请的范围不合法
+func NewInvalidScopeError(format string, a ...interface{}) *OAuthError {
+	return NewOAuthError(exception.BadRequest, ErrInvalidScope, format, a...)
+}
+
+// NewAuthorizationPendingError 用户还未完成授权, 客户端需要继续轮询
+func NewAuthorizationPendingError(format string, a ...interface{}) *OAuthError {
+	return NewOAuthError(exception.BadRequest, ErrAuthorizationPending, format, a...)
+}
+
+// NewSlowDownError 客户端轮询过快, 需要增大轮询间隔
+func NewSlowDownError(format string, a ...interface{}) *OAuthError {
+	return NewOAuthError(exception.BadRequest, ErrSlowDown, format, a...)
+}
+
+// NewAccessDeniedError 用户拒绝了授权
+func NewAccessDeniedError(format string, a ...interface{}) *OAuthError {
+	return NewOAuthError(exception.BadRequest, ErrAccessDenied, format, a...)
+}
+
+// NewExpiredTokenError 设备码已过期
+func NewExpiredTokenError(format string, a ...interface{}) *OAuthError {
+	return NewOAuthError(exception.BadRequest, ErrExpiredToken, format, a...)
+}
+
+// NewMFARequiredError 密码校验通过, 还需要完成多因素认证
+func NewMFARequiredError(challengeID string) *OAuthError {
+	e := NewOAuthError(exception.Forbidden, ErrMFARequired, "mfa required, use challenge_id and otp with grant type %s", MFAOTP)
+	e.ChallengeID = challengeID
+	return e
+}
+
+// IsMFARequired 需要多因素认证不属于登录失败
+func IsMFARequired(err error) (*OAuthError, bool) {
+	e, ok := err.(*OAuthError)
+	if !ok || e.ErrorType != ErrMFARequired {
+		return nil, false
+	}
+
+	return e, true
+}
+
+// NewPasswordExpiredError 密码正确但已过期
+func NewPasswordExpiredError(format string, a ...interface{}) *OAuthError {
+	return NewOAuthError(exception.Forbidden, ErrPasswordExpired, format, a...)
+}
+
+// IsPasswordExpired 密码过期不属于登录失败
+func IsPasswordExpired(err error) bool {
+	e, ok := err.(*OAuthError)
+	if !ok {
+		return false
+	}
+
+	return e.ErrorType == ErrPasswordExpired
+}
+
+// OAuthError 同时实现了exception.APIException, reason为协议中定义的错误码
+type OAuthError struct {
+	exception.APIException `json:"-"`
+	ErrorType              string `json:"error"`
+	Description            string `json:"error_description,omitempty"`
+	ChallengeID            string `json:"challenge_id,omitempty"`
+}
+
+// Reason todo
+func (e *OAuthError) Reason() string {
+	return e.ErrorType
+}
+
+// HTTPStatus 标准接口返回的http状态码
+func (e *OAuthError) HTTPStatus() int {
+	switch e.ErrorCode() {
+	case exception.Unauthorized:
+		return http.StatusUnauthorized
+	case exception.Forbidden:
+		return http.StatusForbidden
+	case exception.InternalServerError:
+		return http.StatusInternalServerError
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+// ToOAuthError 将其他异常转换为oauth2协议的错误
+func ToOAuthError(err error) *OAuthError {
+	switch t := err.(type) {
+	case *OAuthError:
+		return t
+	case exception.APIException:
+		switch t.ErrorCode() {
+		case exception.BadRequest:
+			return NewInvalidRequestError("%s", t)
+		case exception.Unauthorized, exception.Forbidden, exception.NotFound,
+			exception.AccessTokenExpired, exception.RefreshTokenExpired:
+			return NewInvalidGrantError("%s", t)
+		}
+	}
+
+	return NewOAuthError(exception.InternalServerError, ErrServerError, "%s", err)
+}
+
+// WriteOAuthError 按照oauth2协议的格式返回错误: https://tools.ietf.org/html/rfc6749#section-5.2
+func WriteOAuthError(w http.ResponseWriter, err error) {
+	e := ToOAuthError(err)
+	if e.HTTPStatus() == http.StatusUnauthorized {
+		w.Header().Set("WWW-Authenticate", `Basic realm="keyauth"`)
+	}
+	WriteOAuthJSON(w, e.HTTPStatus(), e)
+}
+
+// WriteOAuthJSON 令牌相关的响应不允许被缓存: https://tools.ietf.org/html/rfc6749#section-5.1
+func WriteOAuthJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json;charset=UTF-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
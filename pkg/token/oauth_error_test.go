@@ -0,0 +1,24 @@
+package token_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/infraboard/mcube/exception"
+	"github.com/stretchr/testify/require"
+
+	"github.com/infraboard/keyauth/pkg/token"
+)
+
+func TestToOAuthError(t *testing.T) {
+	should := require.New(t)
+
+	// 原始错误中的%不能被当作格式化占位符
+	e := token.ToOAuthError(exception.NewBadRequest("scope %s invalid", "100%d"))
+	should.Equal(token.ErrInvalidRequest, e.ErrorType)
+	should.Equal("scope 100%d invalid", e.Description)
+
+	e = token.ToOAuthError(errors.New("dial 50%s failed"))
+	should.Equal(token.ErrServerError, e.ErrorType)
+	should.Equal("dial 50%s failed", e.Description)
+}
@@ -0,0 +1,48 @@
+package token_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/infraboard/keyauth/pkg/token"
+)
+
+func newRequest(remote string, headers map[string]string) *http.Request {
+	r, _ := http.NewRequest("GET", "/", nil)
+	r.RemoteAddr = remote
+	for k, v := range headers {
+		r.Header.Set(k, v)
+	}
+	return r
+}
+
+func TestGetRemoteIPFromHTTP(t *testing.T) {
+	should := require.New(t)
+	should.NoError(token.SetTrustedProxies([]string{"10.0.0.0/8", "192.168.1.1"}))
+	defer token.SetTrustedProxies(nil)
+
+	cases := []struct {
+		remote  string
+		headers map[string]string
+		expect  string
+	}{
+		// 直连的客户端伪造转发头
+		{"1.2.3.4:5678", map[string]string{"X-Forwarded-For": "8.8.8.8"}, "1.2.3.4"},
+		{"1.2.3.4:5678", map[string]string{"X-Real-IP": "8.8.8.8"}, "1.2.3.4"},
+		// 经过可信代理
+		{"10.0.0.1:80", map[string]string{"X-Forwarded-For": "8.8.8.8"}, "8.8.8.8"},
+		{"192.168.1.1:80", map[string]string{"X-Real-IP": "8.8.8.8"}, "8.8.8.8"},
+		// 客户端在转发头最左侧伪造的地址被忽略
+		{"10.0.0.1:80", map[string]string{"X-Forwarded-For": "6.6.6.6, 8.8.8.8, 10.0.0.2"}, "8.8.8.8"},
+		{"10.0.0.1:80", nil, "10.0.0.1"},
+		{"[::1]:80", nil, "::1"},
+	}
+
+	for _, c := range cases {
+		should.Equal(c.expect, token.GetRemoteIPFromHTTP(newRequest(c.remote, c.headers)), c)
+	}
+
+	should.Error(token.SetTrustedProxies([]string{"not an ip"}))
+}
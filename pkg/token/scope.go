@@ -0,0 +1,231 @@
+package token
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/infraboard/mcube/http/label"
+	"github.com/infraboard/mcube/http/router"
+)
+
+// 资源范围的访问级别
+const (
+	// ReadOnly 只读, 只允许get/list动作
+	ReadOnly Access = "ro"
+	// ReadWrite 读写, 允许所有动作
+	ReadWrite Access = "rw"
+)
+
+// Access 访问级别
+type Access string
+
+var (
+	// 身份相关的范围, 不限制资源的访问: https://openid.net/specs/openid-connect-core-1_0.html#ScopeClaims
+	identityScopes = map[string]bool{
+		"openid":         true,
+		"profile":        true,
+		"email":          true,
+		"phone":          true,
+		"address":        true,
+		"offline_access": true,
+	}
+
+	// <resource>-<ro|rw>[@<label_key>=<label_value>]
+	resourceScopeRE = regexp.MustCompile(`^([a-zA-Z0-9_.*]+)-(ro|rw)(?:@([a-zA-Z0-9_.*-]+)=([a-zA-Z0-9_.*-]+))?$`)
+)
+
+// ParseScope 解析令牌范围, 多个范围以空格或者逗号分隔, 例如: host-ro@region=hz, host-rw
+func ParseScope(str string) (*Scope, error) {
+	s := &Scope{}
+	for _, item := range SplitScope(str) {
+		if identityScopes[item] {
+			s.Identities = append(s.Identities, item)
+			continue
+		}
+
+		rs, err := ParseResourceScope(item)
+		if err != nil {
+			return nil, err
+		}
+		s.Resources = append(s.Resources, rs)
+	}
+
+	return s, nil
+}
+
+// SplitScope 多个范围以空格或者逗号分隔
+func SplitScope(str string) []string {
+	return strings.FieldsFunc(str, func(r rune) bool {
+		return r == ',' || r == ' '
+	})
+}
+
+// Scope 令牌的作用范围
+type Scope struct {
+	Identities []string
+	Resources  []*ResourceScope
+}
+
+// IsRestricted 没有携带资源范围的令牌不限制资源的访问
+func (s *Scope) IsRestricted() bool {
+	return len(s.Resources) > 0
+}
+
+// Allow 该范围是否允许访问该接口
+func (s *Scope) Allow(entry *router.Entry) bool {
+	if !s.IsRestricted() {
+		return true
+	}
+
+	for i := range s.Resources {
+		if s.Resources[i].Match(entry) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Covers 判断另一个范围是否完全包含在该范围之内
+func (s *Scope) Covers(target *Scope) bool {
+	for _, id := range target.Identities {
+		if !s.hasIdentity(id) {
+			return false
+		}
+	}
+
+	if !s.IsRestricted() {
+		return true
+	}
+	// 申请无资源范围的令牌等同于申请所有资源
+	if !target.IsRestricted() {
+		return false
+	}
+
+	for _, tr := range target.Resources {
+		covered := false
+		for _, r := range s.Resources {
+			if r.Covers(tr) {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (s *Scope) hasIdentity(id string) bool {
+	for i := range s.Identities {
+		if s.Identities[i] == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ParseResourceScope 解析单个资源范围
+func ParseResourceScope(str string) (*ResourceScope, error) {
+	match := resourceScopeRE.FindStringSubmatch(str)
+	if match == nil {
+		return nil, fmt.Errorf("invalid scope %s, format: <resource>-<ro|rw>[@<label_key>=<label_value>]", str)
+	}
+
+	return &ResourceScope{
+		Resource:   match[1],
+		Access:     Access(match[2]),
+		LabelKey:   match[3],
+		LabelValue: match[4],
+	}, nil
+}
+
+// ResourceScope 资源范围
+type ResourceScope struct {
+	Resource   string
+	Access     Access
+	LabelKey   string
+	LabelValue string
+}
+
+// Match 接口是否在该资源范围内
+func (r *ResourceScope) Match(entry *router.Entry) bool {
+	if r.Resource != "*" && r.Resource != entry.Resource {
+		return false
+	}
+
+	if r.Access == ReadOnly && !isReadEntry(entry) {
+		return false
+	}
+
+	if r.LabelKey != "" && r.LabelValue != "*" {
+		if entry.GetLableValue(r.LabelKey) != r.LabelValue {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Covers 范围r是否包含范围t
+func (r *ResourceScope) Covers(t *ResourceScope) bool {
+	if r.Resource != "*" && r.Resource != t.Resource {
+		return false
+	}
+
+	if r.Access == ReadOnly && t.Access != ReadOnly {
+		return false
+	}
+
+	if r.LabelKey != "" && r.LabelValue != "*" {
+		if t.LabelKey != r.LabelKey || t.LabelValue != r.LabelValue {
+			return false
+		}
+	}
+
+	return true
+}
+
+// 有action标签时以标签为准, 没有时按照http方法判断
+func isReadEntry(entry *router.Entry) bool {
+	switch entry.GetLableValue(label.ActionLableKey) {
+	case label.Get.Value(), label.List.Value():
+		return true
+	case "":
+		return entry.Method == http.MethodGet || entry.Method == http.MethodHead
+	default:
+		return false
+	}
+}
+
+// DownScope 交换后的令牌范围只能缩小, 不能超出主体令牌的范围
+func DownScope(granted, requested string) (string, error) {
+	if requested == "" {
+		return granted, nil
+	}
+
+	rs, err := ParseScope(requested)
+	if err != nil {
+		return "", NewInvalidScopeError("%s", err)
+	}
+	// 主体令牌没有范围限制
+	if granted == "" {
+		return requested, nil
+	}
+
+	gs, err := ParseScope(granted)
+	if err != nil {
+		return "", NewInvalidScopeError("%s", err)
+	}
+
+	if !gs.Covers(rs) {
+		return "", NewInvalidScopeError("scope %s exceeds the subject token scope", requested)
+	}
+
+	return requested, nil
+}
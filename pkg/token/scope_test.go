@@ -0,0 +1,76 @@
+package token_test
+
+import (
+	"testing"
+
+	"github.com/infraboard/mcube/http/label"
+	"github.com/infraboard/mcube/http/router"
+	"github.com/stretchr/testify/require"
+
+	"github.com/infraboard/keyauth/pkg/token"
+)
+
+func TestParseScope(t *testing.T) {
+	should := require.New(t)
+
+	s, err := token.ParseScope("openid, host-ro@region=hz host-rw")
+	should.NoError(err)
+	should.Equal([]string{"openid"}, s.Identities)
+	should.Len(s.Resources, 2)
+	should.Equal("host", s.Resources[0].Resource)
+	should.Equal(token.ReadOnly, s.Resources[0].Access)
+	should.Equal("region", s.Resources[0].LabelKey)
+	should.Equal("hz", s.Resources[0].LabelValue)
+
+	_, err = token.ParseScope("host-admin")
+	should.Error(err)
+}
+
+func TestScopeAllow(t *testing.T) {
+	should := require.New(t)
+
+	list := router.NewEntry("/hosts", "GET", "host")
+	list.AddLabel(label.List)
+	create := router.NewEntry("/hosts", "POST", "host")
+	create.AddLabel(label.Create)
+
+	ro, err := token.ParseScope("host-ro")
+	should.NoError(err)
+	should.True(ro.Allow(list))
+	should.False(ro.Allow(create))
+
+	rw, err := token.ParseScope("host-rw")
+	should.NoError(err)
+	should.True(rw.Allow(create))
+	should.False(rw.Allow(router.NewEntry("/users", "GET", "user")))
+
+	// 不携带资源范围时不做限制
+	unrestricted, err := token.ParseScope("openid profile")
+	should.NoError(err)
+	should.True(unrestricted.Allow(create))
+}
+
+func TestDownScope(t *testing.T) {
+	should := require.New(t)
+
+	scope, err := token.DownScope("openid host-ro host-rw", "host-ro")
+	should.NoError(err)
+	should.Equal("host-ro", scope)
+
+	scope, err = token.DownScope("host-ro", "")
+	should.NoError(err)
+	should.Equal("host-ro", scope)
+
+	_, err = token.DownScope("host-ro", "host-rw")
+	should.Error(err)
+}
+
+func TestDownScopeLabel(t *testing.T) {
+	should := require.New(t)
+
+	_, err := token.DownScope("host-rw@region=hz", "host-ro@region=hz")
+	should.NoError(err)
+
+	_, err = token.DownScope("host-rw@region=hz", "host-ro")
+	should.Error(err)
+}
@@ -4,22 +4,18 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
-	"strings"
 
 	"github.com/infraboard/mcube/http/request"
 )
 
-var (
-	// DefaultForwareHeaderKey 协商forward ip 的 hander key名称
-	defaultScanForwareHeaderKey = []string{"X-Forwarded-For", "X-Real-IP"}
-)
-
 // Service token管理服务
 type Service interface {
 	IssueToken(req *IssueTokenRequest) (*Token, error)
 	ValidateToken(req *ValidateTokenRequest) (*Token, error)
 	RevolkToken(req *RevolkTokenRequest) error
 	QueryToken(req *QueryTokenRequest) (*Set, error)
+	IntrospectToken(req *IntrospectTokenRequest) (*Introspection, error)
+	BatchRevolkToken(req *BatchRevolkTokenRequest) (int64, error)
 }
 
 // NewIssueTokenRequest 默认请求
@@ -40,24 +36,30 @@ func NewIssueTokenByPassword(clientID, clientSecret, user, pass string) *IssueTo
 
 // IssueTokenRequest 颁发token请求
 type IssueTokenRequest struct {
-	ClientID     string    `json:"client_id,omitempty" validate:"required,lte=80"`     // 客户端ID
-	ClientSecret string    `json:"client_secret,omitempty" validate:"required,lte=80"` // 客户端凭证
-	Username     string    `json:"username,omitempty" validate:"lte=40"`               // 用户名
-	Password     string    `json:"password,omitempty" validate:"lte=100"`              // 密码
-	RefreshToken string    `json:"refresh_token,omitempty" validate:"lte=80"`          // 刷新凭证
-	AccessToken  string    `json:"access_token,omitempty" validate:"lte=80"`           // 访问凭证
-	AuthCode     string    `json:"code,omitempty" validate:"lte=40"`                   // https://tools.ietf.org/html/rfc6749#section-4.1.2
-	State        string    `json:"state,omitempty" validate:"lte=40"`                  // https://tools.ietf.org/html/rfc6749#section-10.12
-	GrantType    GrantType `json:"grant_type,omitempty" validate:"lte=20"`             // 授权的类型
-	Type         Type      `json:"type,omitempty" validate:"lte=20"`                   // 令牌的类型 类型包含: bearer/jwt  (默认为bearer)
-	Scope        string    `json:"scope,omitempty" validate:"lte=100"`                 // 令牌的作用范围: detail https://tools.ietf.org/html/rfc6749#section-3.3
-	ua           string
-	ip           string
-}
-
-// AbnormalUserCheckKey todo
-func (req *IssueTokenRequest) AbnormalUserCheckKey() string {
-	return "abnormal_" + req.Username
+	ClientID         string    `json:"client_id,omitempty" validate:"required,lte=80"`  // 客户端ID
+	ClientSecret     string    `json:"client_secret,omitempty" validate:"lte=80"`       // 客户端凭证, 公开客户端使用PKCE时可以为空
+	Username         string    `json:"username,omitempty" validate:"lte=40"`            // 用户名
+	Password         string    `json:"password,omitempty" validate:"lte=100"`           // 密码
+	RefreshToken     string    `json:"refresh_token,omitempty" validate:"lte=80"`       // 刷新凭证
+	AccessToken      string    `json:"access_token,omitempty" validate:"lte=2048"`      // 访问凭证
+	AuthCode         string    `json:"code,omitempty" validate:"lte=40"`                // https://tools.ietf.org/html/rfc6749#section-4.1.2
+	State            string    `json:"state,omitempty" validate:"lte=40"`               // https://tools.ietf.org/html/rfc6749#section-10.12
+	RedirectURI      string    `json:"redirect_uri,omitempty" validate:"lte=200"`       // https://tools.ietf.org/html/rfc6749#section-4.1.3
+	CodeVerifier     string    `json:"code_verifier,omitempty" validate:"lte=128"`      // https://tools.ietf.org/html/rfc7636#section-4.5
+	DeviceCode       string    `json:"device_code,omitempty" validate:"lte=80"`         // https://tools.ietf.org/html/rfc8628#section-3.4
+	SubjectToken     string    `json:"subject_token,omitempty" validate:"lte=2048"`     // https://tools.ietf.org/html/rfc8693#section-2.1
+	SubjectTokenType string    `json:"subject_token_type,omitempty" validate:"lte=100"` // 主体令牌类型
+	ActorToken       string    `json:"actor_token,omitempty" validate:"lte=2048"`       // 实际调用方的令牌, 为空时调用方为客户端自身
+	ActorTokenType   string    `json:"actor_token_type,omitempty" validate:"lte=100"`   // 调用方令牌类型
+	GrantType        GrantType `json:"grant_type,omitempty" validate:"lte=60"`          // 授权的类型
+	Type             Type      `json:"type,omitempty" validate:"lte=20"`                // 令牌的类型 类型包含: bearer/jwt  (默认为bearer)
+	Scope            string    `json:"scope,omitempty" validate:"lte=100"`              // 令牌的作用范围: detail https://tools.ietf.org/html/rfc6749#section-3.3
+	ChallengeID      string    `json:"challenge_id,omitempty" validate:"lte=80"`        // 多因素认证或者WebAuthn登录的挑战ID
+	OTP              string    `json:"otp,omitempty" validate:"lte=20"`                 // 动态码或者恢复码
+	Assertion        string    `json:"assertion,omitempty" validate:"lte=4096"`         // navigator.credentials.get()返回的断言, JSON格式
+	ua               string
+	ip               string
+	nonce            string
 }
 
 // WithUserAgent todo
@@ -65,6 +67,16 @@ func (req *IssueTokenRequest) WithUserAgent(userAgent string) {
 	req.ua = userAgent
 }
 
+// WithNonce 授权码模式下, 授权请求中携带的OIDC nonce
+func (req *IssueTokenRequest) WithNonce(nonce string) {
+	req.nonce = nonce
+}
+
+// GetNonce todo
+func (req *IssueTokenRequest) GetNonce() string {
+	return req.nonce
+}
+
 // GetUserAgent todo
 func (req *IssueTokenRequest) GetUserAgent() string {
 	return req.ua
@@ -72,36 +84,12 @@ func (req *IssueTokenRequest) GetUserAgent() string {
 
 // WithRemoteIPFromHTTP todo
 func (req *IssueTokenRequest) WithRemoteIPFromHTTP(r *http.Request) {
-	// 优先获取代理IP
-	var ip string
-	for _, key := range defaultScanForwareHeaderKey {
-		value := r.Header.Get(key)
-
-		if strings.Contains(value, ", ") {
-			i := strings.Index(value, ", ")
-			if i == -1 {
-				i = len(value)
-			}
-
-			ip = value[:i]
-			break
-		}
-
-		if value != "" {
-			ip = value
-			break
-		}
-	}
-
-	if ip != "" {
-		req.ip = ip
-		return
-	}
+	req.ip = GetRemoteIPFromHTTP(r)
+}
 
-	// 如果没有获得代理IP则采用RemoteIP
-	addr := strings.Split(r.RemoteAddr, ":")
-	req.ip = strings.Join(addr[0:len(addr)-1], ":")
-	return
+// WithRemoteIP 非HTTP协议(比如gRPC)的请求直接设置调用方IP
+func (req *IssueTokenRequest) WithRemoteIP(ip string) {
+	req.ip = ip
 }
 
 // GetRemoteIP todo
@@ -109,21 +97,70 @@ func (req *IssueTokenRequest) GetRemoteIP() string {
 	return req.ip
 }
 
+// IsPublicClient 公开客户端通过PKCE或者设备码换取token, 不携带client_secret
+func (req *IssueTokenRequest) IsPublicClient() bool {
+	if req.ClientSecret != "" {
+		return false
+	}
+
+	return (req.GrantType.Is(AUTHCODE) && req.CodeVerifier != "") || req.GrantType.Is(DEVICE)
+}
+
+// NewIssueTokenRequestFromForm 标准的表单格式请求: https://tools.ietf.org/html/rfc6749#section-4.1.3
+func NewIssueTokenRequestFromForm(r *http.Request) (*IssueTokenRequest, error) {
+	if err := r.ParseForm(); err != nil {
+		return nil, err
+	}
+	form := r.PostForm
+
+	req := NewIssueTokenRequest()
+	req.WithUserAgent(r.UserAgent())
+	req.WithRemoteIPFromHTTP(r)
+
+	// 客户端认证优先使用basic auth, 其次使用表单参数
+	var ok bool
+	req.ClientID, req.ClientSecret, ok = r.BasicAuth()
+	if !ok {
+		req.ClientID = form.Get("client_id")
+		req.ClientSecret = form.Get("client_secret")
+	}
+
+	req.GrantType = GrantType(form.Get("grant_type"))
+	req.Username = form.Get("username")
+	req.Password = form.Get("password")
+	req.RefreshToken = form.Get("refresh_token")
+	req.AccessToken = form.Get("access_token")
+	req.AuthCode = form.Get("code")
+	req.RedirectURI = form.Get("redirect_uri")
+	req.CodeVerifier = form.Get("code_verifier")
+	req.DeviceCode = form.Get("device_code")
+	req.SubjectToken = form.Get("subject_token")
+	req.SubjectTokenType = form.Get("subject_token_type")
+	req.ActorToken = form.Get("actor_token")
+	req.ActorTokenType = form.Get("actor_token_type")
+	req.Scope = form.Get("scope")
+	req.ChallengeID = form.Get("challenge_id")
+	req.OTP = form.Get("otp")
+	req.Assertion = form.Get("assertion")
+	return req, nil
+}
+
 // Validate 校验请求
 func (req *IssueTokenRequest) Validate() error {
 	if err := validate.Struct(req); err != nil {
 		return err
 	}
 
+	if req.ClientSecret == "" && !req.IsPublicClient() {
+		return fmt.Errorf("client_secret required")
+	}
+
 	switch req.GrantType {
 	case PASSWORD:
 		if req.Username == "" || req.Password == "" {
 			return fmt.Errorf("use %s grant type, username and password required", PASSWORD)
 		}
 	case REFRESH:
-		if req.AccessToken == "" {
-			return fmt.Errorf("use %s grant type, access_token required", REFRESH)
-		}
 		if req.RefreshToken == "" {
 			return fmt.Errorf("use %s grant type, refresh_token required", REFRESH)
 		}
@@ -140,6 +177,28 @@ func (req *IssueTokenRequest) Validate() error {
 		if req.AuthCode == "" {
 			return fmt.Errorf("use %s grant type, code required", AUTHCODE)
 		}
+	case DEVICE:
+		if req.DeviceCode == "" {
+			return fmt.Errorf("use %s grant type, device_code required", DEVICE)
+		}
+	case EXCHANGE:
+		if req.SubjectToken == "" {
+			return fmt.Errorf("use %s grant type, subject_token required", EXCHANGE)
+		}
+		if req.SubjectTokenType != AccessTokenType {
+			return fmt.Errorf("unsupported subject_token_type %s", req.SubjectTokenType)
+		}
+		if req.ActorToken != "" && req.ActorTokenType != AccessTokenType {
+			return fmt.Errorf("unsupported actor_token_type %s", req.ActorTokenType)
+		}
+	case MFAOTP:
+		if req.ChallengeID == "" || req.OTP == "" {
+			return fmt.Errorf("use %s grant type, challenge_id and otp required", MFAOTP)
+		}
+	case WEBAUTHN:
+		if req.Username == "" || req.ChallengeID == "" || req.Assertion == "" {
+			return fmt.Errorf("use %s grant type, username, challenge_id and assertion required", WEBAUTHN)
+		}
 	default:
 		return fmt.Errorf("unknown grant type %s", req.GrantType)
 	}
@@ -196,13 +255,76 @@ func NewRevolkTokenRequest(clientID, clientSecret string) *RevolkTokenRequest {
 	}
 }
 
+// NewRevolkTokenRequestFromForm 标准的表单格式请求: https://tools.ietf.org/html/rfc7009#section-2.1
+func NewRevolkTokenRequestFromForm(r *http.Request) (*RevolkTokenRequest, error) {
+	if err := r.ParseForm(); err != nil {
+		return nil, err
+	}
+
+	req := NewRevolkTokenRequest("", "")
+	var ok bool
+	req.ClientID, req.ClientSecret, ok = r.BasicAuth()
+	if !ok {
+		req.ClientID = r.PostForm.Get("client_id")
+		req.ClientSecret = r.PostForm.Get("client_secret")
+	}
+	req.Token = r.PostForm.Get("token")
+	req.TokenTypeHint = TokenTypeHint(r.PostForm.Get("token_type_hint"))
+	return req, nil
+}
+
 // RevolkTokenRequest 撤销Token的请求
 type RevolkTokenRequest struct {
-	ClientSecret string `json:"client_secret,omitempty" validate:"required,lte=80"` // 客户端凭证
-	ClientID     string `json:"client_id,omitempty" validate:"required,lte=80"`     // 客户端ID
+	ClientSecret  string        `json:"client_secret,omitempty" validate:"required,lte=80"` // 客户端凭证
+	ClientID      string        `json:"client_id,omitempty" validate:"required,lte=80"`     // 客户端ID
+	Token         string        `json:"token,omitempty" validate:"lte=2048"`                // 标准协议中的令牌, 可以是访问令牌或者刷新令牌
+	TokenTypeHint TokenTypeHint `json:"token_type_hint,omitempty" validate:"lte=20"`        // 令牌类型提示
 	*DescribeTokenRequest
 }
 
+// Validate 校验
+func (req *RevolkTokenRequest) Validate() error {
+	if err := validate.Struct(req); err != nil {
+		return err
+	}
+
+	if req.Token != "" {
+		return nil
+	}
+
+	if req.DescribeTokenRequest == nil {
+		return errors.New("token required")
+	}
+
+	return req.DescribeTokenRequest.Validate()
+}
+
+// NewBatchRevolkTokenRequest 实例化
+func NewBatchRevolkTokenRequest() *BatchRevolkTokenRequest {
+	return &BatchRevolkTokenRequest{}
+}
+
+// BatchRevolkTokenRequest 批量撤销令牌, 用于账号禁用, 删除以及应用删除等场景
+type BatchRevolkTokenRequest struct {
+	Domain        string `json:"domain,omitempty" validate:"lte=200"`         // 撤销该域下的所有令牌
+	Account       string `json:"account,omitempty" validate:"lte=60"`         // 撤销该用户的所有令牌
+	ApplicationID string `json:"application_id,omitempty" validate:"lte=200"` // 撤销该应用颁发的所有令牌
+	FamilyID      string `json:"family_id,omitempty" validate:"lte=80"`       // 撤销该令牌族的所有令牌
+}
+
+// Validate 校验
+func (req *BatchRevolkTokenRequest) Validate() error {
+	if err := validate.Struct(req); err != nil {
+		return err
+	}
+
+	if req.Domain == "" && req.Account == "" && req.ApplicationID == "" && req.FamilyID == "" {
+		return errors.New("domain, account, application_id, family_id required one")
+	}
+
+	return nil
+}
+
 // NewDescribeTokenRequest 实例化
 func NewDescribeTokenRequest() *DescribeTokenRequest {
 	return &DescribeTokenRequest{}
@@ -210,8 +332,8 @@ func NewDescribeTokenRequest() *DescribeTokenRequest {
 
 // DescribeTokenRequest 撤销请求
 type DescribeTokenRequest struct {
-	AccessToken  string `json:"access_token,omitempty" validate:"lte=80"`  // 访问凭证
-	RefreshToken string `json:"refresh_token,omitempty" validate:"lte=80"` // 访问凭证
+	AccessToken  string `json:"access_token,omitempty" validate:"lte=2048"` // 访问凭证
+	RefreshToken string `json:"refresh_token,omitempty" validate:"lte=80"`  // 访问凭证
 }
 
 // Validate 校验
@@ -226,3 +348,50 @@ func (req *DescribeTokenRequest) Validate() error {
 
 	return nil
 }
+
+// token_type_hint: https://tools.ietf.org/html/rfc7009#section-2.1
+const (
+	// AccessTokenHint 访问令牌
+	AccessTokenHint TokenTypeHint = "access_token"
+	// RefreshTokenHint 刷新令牌
+	RefreshTokenHint TokenTypeHint = "refresh_token"
+)
+
+// TokenTypeHint 提示服务端令牌的类型, 用于加快查找
+type TokenTypeHint string
+
+// NewIntrospectTokenRequest 实例化
+func NewIntrospectTokenRequest() *IntrospectTokenRequest {
+	return &IntrospectTokenRequest{}
+}
+
+// NewIntrospectTokenRequestFromForm 标准的表单格式请求: https://tools.ietf.org/html/rfc7662#section-2.1
+func NewIntrospectTokenRequestFromForm(r *http.Request) (*IntrospectTokenRequest, error) {
+	if err := r.ParseForm(); err != nil {
+		return nil, err
+	}
+
+	req := NewIntrospectTokenRequest()
+	var ok bool
+	req.ClientID, req.ClientSecret, ok = r.BasicAuth()
+	if !ok {
+		req.ClientID = r.PostForm.Get("client_id")
+		req.ClientSecret = r.PostForm.Get("client_secret")
+	}
+	req.Token = r.PostForm.Get("token")
+	req.TokenTypeHint = TokenTypeHint(r.PostForm.Get("token_type_hint"))
+	return req, nil
+}
+
+// IntrospectTokenRequest 令牌内省请求, 由资源服务器使用自己的客户端凭证调用
+type IntrospectTokenRequest struct {
+	ClientID      string        `json:"client_id,omitempty" validate:"required,lte=80"`     // 客户端ID
+	ClientSecret  string        `json:"client_secret,omitempty" validate:"required,lte=80"` // 客户端凭证
+	Token         string        `json:"token,omitempty" validate:"required,lte=2048"`       // 需要内省的令牌
+	TokenTypeHint TokenTypeHint `json:"token_type_hint,omitempty" validate:"lte=20"`        // 令牌类型提示
+}
+
+// Validate 校验
+func (req *IntrospectTokenRequest) Validate() error {
+	return validate.Struct(req)
+}
@@ -28,6 +28,14 @@ const (
 	ACCESS GrantType = "access_token"
 	// LDAP 通过ldap认证
 	LDAP GrantType = "ldap"
+	// DEVICE oauth2 Device Authorization Grant: https://tools.ietf.org/html/rfc8628#section-3.4
+	DEVICE GrantType = "urn:ietf:params:oauth:grant-type:device_code"
+	// EXCHANGE oauth2 Token Exchange: https://tools.ietf.org/html/rfc8693#section-2.1
+	EXCHANGE GrantType = "urn:ietf:params:oauth:grant-type:token-exchange"
+	// MFAOTP 密码认证返回mfa_required后, 使用挑战ID和动态码完成登录
+	MFAOTP GrantType = "mfa_otp"
+	// WEBAUTHN 使用WebAuthn(Passkey)认证器的断言登录
+	WEBAUTHN GrantType = "webauthn"
 )
 
 // ParseGrantTypeFromString todo
@@ -47,6 +55,14 @@ func ParseGrantTypeFromString(str string) (GrantType, error) {
 		return ACCESS, nil
 	case "ldap":
 		return LDAP, nil
+	case "urn:ietf:params:oauth:grant-type:device_code":
+		return DEVICE, nil
+	case "urn:ietf:params:oauth:grant-type:token-exchange":
+		return EXCHANGE, nil
+	case "mfa_otp":
+		return MFAOTP, nil
+	case "webauthn":
+		return WEBAUTHN, nil
 	default:
 		return UNKNOWN, fmt.Errorf("unknown Grant type: %s", str)
 	}
@@ -95,6 +111,7 @@ type Token struct {
 	CreatedAt        ftime.Time `bson:"create_at" json:"create_at,omitempty"`                   // 凭证创建时间
 	AccessExpiredAt  ftime.Time `bson:"access_expired_at" json:"access_expires_at,omitempty"`   // 还有多久过期
 	RefreshExpiredAt ftime.Time `bson:"refresh_expired_at" json:"refresh_expired_at,omitempty"` // 刷新token过期时间
+	FamilyID         string     `bson:"family_id" json:"family_id,omitempty"`                   // 令牌族, 同一次登录通过刷新产生的令牌属于同一族
 
 	Domain          string     `bson:"domain" json:"domain,omitempty"`                     // 用户所处域ID
 	UserType        types.Type `bson:"user_type" json:"user_type,omitempty"`               // 用户类型
@@ -109,6 +126,10 @@ type Token struct {
 	Description     string     `bson:"description" json:"description,omitempty"`           // 独立颁发给SDK使用时, 令牌的描述信息, 方便定位与取消
 	IsBlock         bool       `bson:"is_block" json:"is_block"`                           // 是否被禁用
 	BlockReason     string     `bson:"block_reason" json:"block_reason,omitempty"`         // 禁用原因
+	Actor           *Actor     `bson:"actor,omitempty" json:"actor,omitempty"`             // 通过令牌交换颁发时, 代表主体行事的调用方
+	MFA             bool       `bson:"mfa" json:"mfa,omitempty"`                           // 颁发时是否通过了多因素认证
+
+	IDToken string `bson:"-" json:"id_token,omitempty"` // OIDC身份令牌, 仅在颁发时返回, 不存储
 }
 
 // Block 禁用token
@@ -117,6 +138,15 @@ func (t *Token) Block(reason string) {
 	t.BlockReason = reason
 }
 
+// GetStartGrantType 令牌最开始的授权类型, 刷新或者交换产生的令牌继承原令牌的授权类型
+func (t *Token) GetStartGrantType() GrantType {
+	if t.StartGrantType != "" {
+		return t.StartGrantType
+	}
+
+	return t.GrantType
+}
+
 // CheckAccessIsExpired 检测token是否过期
 func (t *Token) CheckAccessIsExpired() bool {
 	if t.AccessExpiredAt.Timestamp() == 0 {
@@ -140,6 +170,36 @@ func (t *Token) CheckTokenApplication(applicationID string) error {
 	return nil
 }
 
+// HasScope 令牌是否包含该范围, 多个范围以空格或者逗号分隔
+func (t *Token) HasScope(scope string) bool {
+	for _, s := range SplitScope(t.Scope) {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsDelegated 是否是代表用户行事的令牌
+func (t *Token) IsDelegated() bool {
+	return t.Actor != nil
+}
+
+// CheckRefreshable 代理调用的令牌通过令牌交换获取, 不允许刷新, 需要重新交换, 避免有效期超过主体令牌
+func (t *Token) CheckRefreshable() error {
+	if t.GrantType.Is(EXCHANGE) || t.IsDelegated() {
+		return fmt.Errorf("token issued by %s grant can't be refreshed", EXCHANGE)
+	}
+
+	return nil
+}
+
+// IsApplicationToken 通过client_credentials颁发给应用自身的令牌, 不属于任何用户
+func (t *Token) IsApplicationToken() bool {
+	return t.Account == "" && t.ApplicationID != ""
+}
+
 // Desensitize 数据脱敏
 func (t *Token) Desensitize() {
 	t.RefreshToken = ""
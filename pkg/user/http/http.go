@@ -49,6 +49,20 @@ func (h *handler) Registry(router router.SubRouter) {
 	passRouter := router.ResourceRouter("password")
 	passRouter.BasePath("password")
 	passRouter.Handle("PUT", "/", h.UpdatePassword).AddLabel(label.Update)
+	passRouter.Handle("PUT", "/expired", h.UpdateExpiredPassword).DisableAuth()
+
+	mfaRouter := router.ResourceRouter("mfa")
+	mfaRouter.BasePath("mfa")
+	mfaRouter.Handle("POST", "/", h.SetupMFA).AddLabel(label.Create)
+	mfaRouter.Handle("POST", "/confirm", h.ConfirmMFA).AddLabel(label.Update)
+	mfaRouter.Handle("DELETE", "/", h.DisableMFA).AddLabel(label.Delete)
+
+	webauthnRouter := router.ResourceRouter("webauthn")
+	webauthnRouter.BasePath("webauthn")
+	webauthnRouter.Handle("POST", "/registration/begin", h.BeginWebAuthnRegistration).AddLabel(label.Create)
+	webauthnRouter.Handle("POST", "/registration/finish", h.FinishWebAuthnRegistration).AddLabel(label.Create)
+	webauthnRouter.Handle("DELETE", "/credentials/:id", h.DeleteWebAuthnCredential).AddLabel(label.Delete)
+	webauthnRouter.Handle("POST", "/login/begin", h.BeginWebAuthnLogin).DisableAuth()
 }
 
 func (h *handler) Config() error {
@@ -0,0 +1,96 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/infraboard/mcube/http/request"
+	"github.com/infraboard/mcube/http/response"
+
+	"github.com/infraboard/keyauth/conf"
+	"github.com/infraboard/keyauth/pkg"
+	"github.com/infraboard/keyauth/pkg/user"
+)
+
+// SetupMFA 生成TOTP密钥, 需要通过ConfirmMFA确认后才生效
+func (h *handler) SetupMFA(w http.ResponseWriter, r *http.Request) {
+	tk, err := pkg.GetTokenFromContext(r)
+	if err != nil {
+		response.Failed(w, err)
+		return
+	}
+
+	// 请求体可以为空, 未启用多因素认证时不需要当前的动态码
+	body, err := request.ReadBody(r)
+	if err != nil {
+		response.Failed(w, err)
+		return
+	}
+	req := user.NewSetupMFARequest()
+	if len(body) > 0 {
+		if err := json.Unmarshal(body, req); err != nil {
+			response.Failed(w, err)
+			return
+		}
+	}
+	req.Issuer = conf.C().App.Name
+	req.WithToken(tk)
+
+	setup, err := h.service.SetupMFA(req)
+	if err != nil {
+		response.Failed(w, err)
+		return
+	}
+
+	response.Success(w, setup)
+	return
+}
+
+// ConfirmMFA 确认绑定, 返回的恢复码只展示这一次
+func (h *handler) ConfirmMFA(w http.ResponseWriter, r *http.Request) {
+	tk, err := pkg.GetTokenFromContext(r)
+	if err != nil {
+		response.Failed(w, err)
+		return
+	}
+
+	req := user.NewConfirmMFARequest()
+	req.WithToken(tk)
+	if err := request.GetDataFromRequest(r, req); err != nil {
+		response.Failed(w, err)
+		return
+	}
+
+	codes, err := h.service.ConfirmMFA(req)
+	if err != nil {
+		response.Failed(w, err)
+		return
+	}
+
+	response.Success(w, codes)
+	return
+}
+
+// DisableMFA 关闭多因素认证
+func (h *handler) DisableMFA(w http.ResponseWriter, r *http.Request) {
+	tk, err := pkg.GetTokenFromContext(r)
+	if err != nil {
+		response.Failed(w, err)
+		return
+	}
+
+	req := user.NewDisableMFARequest()
+	req.WithToken(tk)
+	if err := request.GetDataFromRequest(r, req); err != nil {
+		response.Failed(w, err)
+		return
+	}
+
+	if err := h.service.DisableMFA(req); err != nil {
+		response.Failed(w, err)
+		return
+	}
+
+	response.Success(w, "disable ok")
+	return
+}
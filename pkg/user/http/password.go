@@ -34,3 +34,23 @@ func (h *handler) UpdatePassword(w http.ResponseWriter, r *http.Request) {
 	response.Success(w, pass)
 	return
 }
+
+// UpdateExpiredPassword 密码过期后无法登录, 使用旧密码修改
+func (h *handler) UpdateExpiredPassword(w http.ResponseWriter, r *http.Request) {
+	req := user.NewUpdatePasswordRequest()
+	if err := request.GetDataFromRequest(r, req); err != nil {
+		response.Failed(w, err)
+		return
+	}
+	req.WithRemoteIPFromHTTP(r)
+
+	pass, err := h.service.UpdateAccountPassword(req)
+	if err != nil {
+		response.Failed(w, err)
+		return
+	}
+
+	pass.Password = ""
+	response.Success(w, pass)
+	return
+}
@@ -0,0 +1,97 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/infraboard/mcube/http/context"
+	"github.com/infraboard/mcube/http/request"
+	"github.com/infraboard/mcube/http/response"
+
+	"github.com/infraboard/keyauth/pkg"
+	"github.com/infraboard/keyauth/pkg/user"
+)
+
+// BeginWebAuthnRegistration 获取注册Passkey的参数, 交给navigator.credentials.create()
+func (h *handler) BeginWebAuthnRegistration(w http.ResponseWriter, r *http.Request) {
+	tk, err := pkg.GetTokenFromContext(r)
+	if err != nil {
+		response.Failed(w, err)
+		return
+	}
+
+	req := user.NewBeginWebAuthnRegistrationRequest()
+	req.WithToken(tk)
+
+	opts, err := h.service.BeginWebAuthnRegistration(req)
+	if err != nil {
+		response.Failed(w, err)
+		return
+	}
+
+	response.Success(w, opts)
+	return
+}
+
+// FinishWebAuthnRegistration 保存认证器创建的凭证
+func (h *handler) FinishWebAuthnRegistration(w http.ResponseWriter, r *http.Request) {
+	tk, err := pkg.GetTokenFromContext(r)
+	if err != nil {
+		response.Failed(w, err)
+		return
+	}
+
+	req := user.NewFinishWebAuthnRegistrationRequest()
+	req.WithToken(tk)
+	if err := request.GetDataFromRequest(r, req); err != nil {
+		response.Failed(w, err)
+		return
+	}
+
+	cred, err := h.service.FinishWebAuthnRegistration(req)
+	if err != nil {
+		response.Failed(w, err)
+		return
+	}
+
+	response.Success(w, cred)
+	return
+}
+
+// DeleteWebAuthnCredential 删除凭证
+func (h *handler) DeleteWebAuthnCredential(w http.ResponseWriter, r *http.Request) {
+	tk, err := pkg.GetTokenFromContext(r)
+	if err != nil {
+		response.Failed(w, err)
+		return
+	}
+
+	rctx := context.GetContext(r)
+	req := user.NewDeleteWebAuthnCredentialRequest(rctx.PS.ByName("id"))
+	req.WithToken(tk)
+
+	if err := h.service.DeleteWebAuthnCredential(req); err != nil {
+		response.Failed(w, err)
+		return
+	}
+
+	response.Success(w, "delete ok")
+	return
+}
+
+// BeginWebAuthnLogin 获取登录断言的参数, 交给navigator.credentials.get()
+func (h *handler) BeginWebAuthnLogin(w http.ResponseWriter, r *http.Request) {
+	req := user.NewBeginWebAuthnLoginRequest()
+	if err := request.GetDataFromRequest(r, req); err != nil {
+		response.Failed(w, err)
+		return
+	}
+
+	opts, err := h.service.BeginWebAuthnLogin(req)
+	if err != nil {
+		response.Failed(w, err)
+		return
+	}
+
+	response.Success(w, opts)
+	return
+}
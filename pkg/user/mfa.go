@@ -0,0 +1,192 @@
+package user
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/infraboard/mcube/exception"
+	"github.com/infraboard/mcube/types/ftime"
+
+	"github.com/infraboard/keyauth/pkg/user/totp"
+)
+
+const (
+	// RecoveryCodeCount 启用多因素认证时生成的恢复码个数
+	RecoveryCodeCount = 10
+
+	recoveryCodeCharset = "abcdefghjkmnpqrstuvwxyz23456789"
+	recoveryCodeLength  = 10
+)
+
+// NewMFA todo
+func NewMFA() *MFA {
+	return &MFA{}
+}
+
+// MFA 用户的多因素认证配置, 密钥和恢复码不对外返回
+type MFA struct {
+	Enabled       bool       `bson:"enabled" json:"enabled"`                 // 是否启用
+	EnabledAt     ftime.Time `bson:"enabled_at" json:"enabled_at,omitempty"` // 启用时间
+	Secret        string     `bson:"secret" json:"-"`                        // TOTP密钥
+	PendingSecret string     `bson:"pending_secret" json:"-"`                // 待确认的TOTP密钥, 确认后生效
+	RecoveryCodes []string   `bson:"recovery_codes" json:"-"`                // 恢复码的摘要, 每个只能使用一次
+	LastUsedStep  int64      `bson:"last_used_step" json:"-"`                // 最近一次使用的时间步, 防止动态码重放
+	RecoveryLeft  int        `bson:"-" json:"recovery_codes_left,omitempty"` // 剩余的恢复码个数
+}
+
+// Setup 生成待确认的密钥, 确认之前原有的配置继续有效
+func (m *MFA) Setup() (string, error) {
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return "", err
+	}
+
+	m.PendingSecret = secret
+	return secret, nil
+}
+
+// Confirm 通过动态码确认密钥, 启用多因素认证并生成新的恢复码
+func (m *MFA) Confirm(code string) ([]string, error) {
+	if m.PendingSecret == "" {
+		return nil, exception.NewBadRequest("mfa not setup, please setup first")
+	}
+
+	step, ok := totp.Validate(m.PendingSecret, code, time.Now())
+	if !ok {
+		return nil, exception.NewBadRequest("totp code not correct")
+	}
+
+	codes, hashed, err := NewRecoveryCodes(RecoveryCodeCount)
+	if err != nil {
+		return nil, err
+	}
+
+	m.Enabled = true
+	m.EnabledAt = ftime.Now()
+	m.Secret = m.PendingSecret
+	m.PendingSecret = ""
+	m.RecoveryCodes = hashed
+	m.LastUsedStep = step
+	return codes, nil
+}
+
+// Verify 校验动态码或者恢复码, 恢复码使用后失效
+func (m *MFA) Verify(code string) error {
+	usage, err := m.Check(code)
+	if err != nil {
+		return err
+	}
+
+	m.Use(usage)
+	return nil
+}
+
+// MFAUsage 校验通过后需要消费的动态码时间步或者恢复码摘要, 只有一个有值
+type MFAUsage struct {
+	Step         int64
+	RecoveryCode string
+}
+
+// Check 校验动态码或者恢复码, 不修改配置, 存储时需要以消费的内容作为条件更新, 防止并发重放
+func (m *MFA) Check(code string) (*MFAUsage, error) {
+	if !m.Enabled {
+		return nil, exception.NewBadRequest("mfa not enabled")
+	}
+
+	step, ok := totp.Validate(m.Secret, code, time.Now())
+	if ok {
+		if step <= m.LastUsedStep {
+			return nil, exception.NewUnauthorized("totp code has been used")
+		}
+		return &MFAUsage{Step: step}, nil
+	}
+
+	hash := hashRecoveryCode(code)
+	for i := range m.RecoveryCodes {
+		if m.RecoveryCodes[i] == hash {
+			return &MFAUsage{RecoveryCode: hash}, nil
+		}
+	}
+
+	return nil, exception.NewUnauthorized("mfa code not correct")
+}
+
+// Use 记录已使用的时间步, 或者移除已使用的恢复码
+func (m *MFA) Use(usage *MFAUsage) {
+	if usage.Step > 0 {
+		m.LastUsedStep = usage.Step
+		return
+	}
+
+	for i := range m.RecoveryCodes {
+		if m.RecoveryCodes[i] == usage.RecoveryCode {
+			m.RecoveryCodes = append(m.RecoveryCodes[:i], m.RecoveryCodes[i+1:]...)
+			return
+		}
+	}
+}
+
+// Disable 关闭多因素认证, 清除密钥和恢复码
+func (m *MFA) Disable() {
+	*m = MFA{}
+}
+
+// Desensitize 只返回剩余的恢复码个数
+func (m *MFA) Desensitize() {
+	m.RecoveryLeft = len(m.RecoveryCodes)
+}
+
+// NewRecoveryCodes 生成恢复码, 返回明文和用于存储的摘要
+func NewRecoveryCodes(n int) ([]string, []string, error) {
+	max := big.NewInt(int64(len(recoveryCodeCharset)))
+	codes := make([]string, 0, n)
+	hashed := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		b := make([]byte, recoveryCodeLength)
+		for j := range b {
+			r, err := rand.Int(rand.Reader, max)
+			if err != nil {
+				return nil, nil, fmt.Errorf("generate recovery code error, %s", err)
+			}
+			b[j] = recoveryCodeCharset[r.Int64()]
+		}
+
+		code := string(b[:recoveryCodeLength/2]) + "-" + string(b[recoveryCodeLength/2:])
+		codes = append(codes, code)
+		hashed = append(hashed, hashRecoveryCode(code))
+	}
+
+	return codes, hashed, nil
+}
+
+// 恢复码忽略大小写和分隔符
+func hashRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	code = strings.Replace(code, "-", "", -1)
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
+
+// NewMFASetup todo
+func NewMFASetup(issuer, account, secret string) *MFASetup {
+	return &MFASetup{
+		Secret: secret,
+		URI:    totp.URI(issuer, account, secret),
+	}
+}
+
+// MFASetup 认证器绑定信息, 用户通过扫描URI的二维码或者手动输入密钥绑定
+type MFASetup struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+// RecoveryCodeSet 恢复码只在启用时返回一次
+type RecoveryCodeSet struct {
+	Items []string `json:"items"`
+}
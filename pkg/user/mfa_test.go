@@ -0,0 +1,53 @@
+package user_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/infraboard/keyauth/pkg/user"
+	"github.com/infraboard/keyauth/pkg/user/totp"
+)
+
+func TestMFA(t *testing.T) {
+	should := require.New(t)
+
+	m := user.NewMFA()
+	secret, err := m.Setup()
+	should.NoError(err)
+	should.False(m.Enabled)
+
+	_, err = m.Confirm("000000x")
+	should.Error(err)
+
+	code, err := totp.GenerateCode(secret, time.Now())
+	should.NoError(err)
+	recovery, err := m.Confirm(code)
+	should.NoError(err)
+	should.True(m.Enabled)
+	should.Equal(secret, m.Secret)
+	should.Len(recovery, user.RecoveryCodeCount)
+
+	// 确认时使用过的动态码不能再用于登录
+	should.Error(m.Verify(code))
+
+	// 校验不修改配置, 消费后才失效
+	usage, err := m.Check(recovery[1])
+	should.NoError(err)
+	should.NotEmpty(usage.RecoveryCode)
+	should.Len(m.RecoveryCodes, user.RecoveryCodeCount)
+	m.Use(usage)
+	_, err = m.Check(recovery[1])
+	should.Error(err)
+	should.Len(m.RecoveryCodes, user.RecoveryCodeCount-1)
+
+	// 恢复码只能使用一次
+	should.NoError(m.Verify(recovery[0]))
+	should.Error(m.Verify(recovery[0]))
+	should.Len(m.RecoveryCodes, user.RecoveryCodeCount-2)
+
+	m.Disable()
+	should.False(m.Enabled)
+	should.Error(m.Verify(recovery[2]))
+}
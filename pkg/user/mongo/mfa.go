@@ -0,0 +1,157 @@
+package mongo
+
+import (
+	"context"
+
+	"github.com/infraboard/mcube/exception"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/infraboard/keyauth/pkg/user"
+)
+
+func (s *service) SetupMFA(req *user.SetupMFARequest) (*user.MFASetup, error) {
+	if err := req.Validate(); err != nil {
+		return nil, exception.NewBadRequest("check setup mfa request error, %s", err)
+	}
+
+	u, err := s.DescribeAccount(user.NewDescriptAccountRequestWithAccount(req.GetToken().Account))
+	if err != nil {
+		return nil, err
+	}
+
+	if u.MFA == nil {
+		u.MFA = user.NewMFA()
+	}
+	if err := s.verifyCurrentMFA(u, req.CurrentCode); err != nil {
+		return nil, err
+	}
+	secret, err := u.MFA.Setup()
+	if err != nil {
+		return nil, exception.NewInternalServerError("generate totp secret error, %s", err)
+	}
+
+	if err := s.updateMFA(u); err != nil {
+		return nil, err
+	}
+
+	return user.NewMFASetup(req.Issuer, u.Account, secret), nil
+}
+
+func (s *service) ConfirmMFA(req *user.ConfirmMFARequest) (*user.RecoveryCodeSet, error) {
+	if err := req.Validate(); err != nil {
+		return nil, exception.NewBadRequest("check confirm mfa request error, %s", err)
+	}
+
+	u, err := s.DescribeAccount(user.NewDescriptAccountRequestWithAccount(req.GetToken().Account))
+	if err != nil {
+		return nil, err
+	}
+
+	if u.MFA == nil {
+		return nil, exception.NewBadRequest("mfa not setup, please setup first")
+	}
+	if err := s.verifyCurrentMFA(u, req.CurrentCode); err != nil {
+		return nil, err
+	}
+	codes, err := u.MFA.Confirm(req.Code)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.updateMFA(u); err != nil {
+		return nil, err
+	}
+
+	return &user.RecoveryCodeSet{Items: codes}, nil
+}
+
+func (s *service) DisableMFA(req *user.DisableMFARequest) error {
+	if err := req.Validate(); err != nil {
+		return exception.NewBadRequest("check disable mfa request error, %s", err)
+	}
+
+	u, err := s.DescribeAccount(user.NewDescriptAccountRequestWithAccount(req.GetToken().Account))
+	if err != nil {
+		return err
+	}
+
+	if !u.IsMFAEnabled() {
+		return exception.NewBadRequest("mfa not enabled")
+	}
+	if err := s.consumeMFA(u, req.Code); err != nil {
+		return err
+	}
+
+	u.MFA.Disable()
+	return s.updateMFA(u)
+}
+
+func (s *service) VerifyMFA(req *user.VerifyMFARequest) error {
+	if err := req.Validate(); err != nil {
+		return exception.NewBadRequest("check verify mfa request error, %s", err)
+	}
+
+	u, err := s.DescribeAccount(user.NewDescriptAccountRequestWithAccount(req.Account))
+	if err != nil {
+		return err
+	}
+
+	if !u.IsMFAEnabled() {
+		return exception.NewBadRequest("user %s mfa not enabled", u.Account)
+	}
+
+	return s.consumeMFA(u, req.Code)
+}
+
+// 已启用多因素认证时, 重新绑定需要先校验当前的动态码或者恢复码, 防止令牌泄露后被替换认证器
+func (s *service) verifyCurrentMFA(u *user.User, code string) error {
+	if !u.IsMFAEnabled() {
+		return nil
+	}
+
+	if code == "" {
+		return exception.NewBadRequest("mfa enabled, current_code required")
+	}
+
+	return s.consumeMFA(u, code)
+}
+
+// consumeMFA 以未使用过的时间步或者恢复码为条件更新, 并发的请求只有一个能使用同一个动态码
+func (s *service) consumeMFA(u *user.User, code string) error {
+	usage, err := u.MFA.Check(code)
+	if err != nil {
+		return err
+	}
+
+	filter := bson.M{"_id": u.Account, "mfa.enabled": true}
+	var update bson.M
+	if usage.Step > 0 {
+		filter["mfa.last_used_step"] = bson.M{"$lt": usage.Step}
+		update = bson.M{"$set": bson.M{"mfa.last_used_step": usage.Step}}
+	} else {
+		filter["mfa.recovery_codes"] = usage.RecoveryCode
+		update = bson.M{"$pull": bson.M{"mfa.recovery_codes": usage.RecoveryCode}}
+	}
+
+	res, err := s.col.UpdateOne(context.TODO(), filter, update)
+	if err != nil {
+		return exception.NewInternalServerError("update user(%s) mfa error, %s", u.Account, err)
+	}
+	if res.MatchedCount == 0 {
+		return exception.NewUnauthorized("mfa code has been used")
+	}
+
+	u.MFA.Use(usage)
+	return nil
+}
+
+func (s *service) updateMFA(u *user.User) error {
+	_, err := s.col.UpdateOne(context.TODO(), bson.M{"_id": u.Account}, bson.M{"$set": bson.M{
+		"mfa": u.MFA,
+	}})
+	if err != nil {
+		return exception.NewInternalServerError("update user(%s) mfa error, %s", u.Account, err)
+	}
+
+	return nil
+}
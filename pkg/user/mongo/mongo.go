@@ -4,14 +4,21 @@ import (
 	"context"
 	"fmt"
 
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.mongodb.org/mongo-driver/x/bsonx"
 
 	"github.com/infraboard/keyauth/conf"
 	"github.com/infraboard/keyauth/pkg"
 	"github.com/infraboard/keyauth/pkg/department"
+	"github.com/infraboard/keyauth/pkg/domain"
+	"github.com/infraboard/keyauth/pkg/lockout"
 	"github.com/infraboard/keyauth/pkg/policy"
+	"github.com/infraboard/keyauth/pkg/token"
 	"github.com/infraboard/keyauth/pkg/user"
+	"github.com/infraboard/keyauth/pkg/user/webauthn"
+	"github.com/infraboard/mcube/cache"
 	"github.com/infraboard/mcube/logger"
 	"github.com/infraboard/mcube/logger/zap"
 )
@@ -28,6 +35,11 @@ type service struct {
 	notifyCachPre string
 	policy        policy.Service
 	depart        department.Service
+	domain        domain.Service
+	token         token.Service
+	lockout       lockout.Service
+	cache         cache.Cache
+	rp            *webauthn.Config
 }
 
 func (s *service) Config() error {
@@ -41,6 +53,34 @@ func (s *service) Config() error {
 	}
 	s.depart = pkg.Department
 
+	if pkg.Domain == nil {
+		return fmt.Errorf("dependence domain service is nil")
+	}
+	s.domain = pkg.Domain
+
+	if pkg.Token == nil {
+		return fmt.Errorf("dependence token service is nil")
+	}
+	s.token = pkg.Token
+
+	if pkg.Lockout == nil {
+		return fmt.Errorf("dependence lockout service is nil")
+	}
+	s.lockout = pkg.Lockout
+
+	c := cache.C()
+	if c == nil {
+		return fmt.Errorf("dependence cache service is nil")
+	}
+	s.cache = c
+
+	wc := conf.C().WebAuthn
+	s.rp = &webauthn.Config{
+		RPID:    wc.RPID,
+		RPName:  wc.RPName,
+		Origins: wc.Origins,
+	}
+
 	db := conf.C().Mongo.GetDB()
 	uc := db.Collection("user")
 
@@ -51,6 +91,13 @@ func (s *service) Config() error {
 		{
 			Keys: bsonx.Doc{{Key: "department_id", Value: bsonx.Int32(-1)}},
 		},
+		// WebAuthn凭证ID全局唯一, 没有凭证的用户不参与索引
+		{
+			Keys: bsonx.Doc{{Key: "webauthn_credentials.id", Value: bsonx.Int32(1)}},
+			Options: options.Index().SetUnique(true).SetPartialFilterExpression(bson.M{
+				"webauthn_credentials.id": bson.M{"$exists": true},
+			}),
+		},
 	}
 
 	_, err := uc.Indexes().CreateMany(context.Background(), indexs)
@@ -0,0 +1,51 @@
+package mongo
+
+import (
+	"github.com/infraboard/mcube/exception"
+	"github.com/infraboard/mcube/http/request"
+
+	"github.com/infraboard/keyauth/pkg/domain"
+	"github.com/infraboard/keyauth/pkg/token"
+	"github.com/infraboard/keyauth/pkg/user"
+	"github.com/infraboard/keyauth/pkg/user/types"
+)
+
+// getUserPasswordPolicy 子账号使用所在域的策略, 主账号使用其创建的域的策略
+func (s *service) getUserPasswordPolicy(u *user.User) (*domain.PasswordPolicy, error) {
+	if u.Domain != "" {
+		return s.getPasswordPolicy(u.Domain)
+	}
+
+	if !u.Type.Is(types.SupperAccount, types.PrimaryAccount) {
+		return domain.NewDefaultPasswordPolicy(), nil
+	}
+
+	req := domain.NewQueryDomainRequest(request.NewPageRequest(1, 1))
+	req.WithToken(&token.Token{Account: u.Account})
+	domains, err := s.domain.QueryDomain(req)
+	if err != nil {
+		return nil, err
+	}
+	if domains.Length() == 0 {
+		return domain.NewDefaultPasswordPolicy(), nil
+	}
+
+	return domains.Items[0].GetPasswordPolicy(), nil
+}
+
+// getPasswordPolicy 获取域的密码策略, 域不存在时使用默认策略
+func (s *service) getPasswordPolicy(domainName string) (*domain.PasswordPolicy, error) {
+	if domainName == "" {
+		return domain.NewDefaultPasswordPolicy(), nil
+	}
+
+	d, err := s.domain.DescriptionDomain(domain.NewDescriptDomainRequestWithName(domainName))
+	if err != nil {
+		if exception.IsNotFoundError(err) {
+			return domain.NewDefaultPasswordPolicy(), nil
+		}
+		return nil, err
+	}
+
+	return d.GetPasswordPolicy(), nil
+}
@@ -3,6 +3,7 @@ package mongo
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/infraboard/mcube/exception"
 	"github.com/infraboard/mcube/types/ftime"
@@ -10,6 +11,8 @@ import (
 	"go.mongodb.org/mongo-driver/mongo"
 
 	common "github.com/infraboard/keyauth/common/types"
+	"github.com/infraboard/keyauth/pkg/lockout"
+	"github.com/infraboard/keyauth/pkg/token"
 	"github.com/infraboard/keyauth/pkg/user"
 	"github.com/infraboard/keyauth/pkg/user/types"
 )
@@ -25,15 +28,21 @@ func (s *service) QueryAccount(t types.Type, req *user.QueryAccountRequest) (*us
 }
 
 func (s *service) CreateAccount(t types.Type, req *user.CreateAccountRequest) (*user.User, error) {
-	u, err := user.New(req)
+	domainName := ""
+	if tk := req.GetToken(); tk != nil {
+		domainName = tk.Domain
+	}
+
+	policy, err := s.getPasswordPolicy(domainName)
 	if err != nil {
 		return nil, err
 	}
 
-	tk := req.GetToken()
-	if tk != nil {
-		u.Domain = tk.Domain
+	u, err := user.New(req, policy)
+	if err != nil {
+		return nil, err
 	}
+	u.Domain = domainName
 
 	u.Type = t
 	if err := s.saveAccount(u); err != nil {
@@ -78,19 +87,32 @@ func (s *service) UpdateAccountPassword(req *user.UpdatePasswordRequest) (*user.
 		return nil, exception.NewBadRequest("check update pass request error, %s", err)
 	}
 
-	descReq := user.NewDescriptAccountRequest()
-	descReq.Account = req.GetToken().Account
-	u, err := s.DescribeAccount(descReq)
+	var (
+		u   *user.User
+		err error
+	)
+	if tk := req.GetToken(); tk != nil {
+		u, err = s.DescribeAccount(user.NewDescriptAccountRequestWithAccount(tk.Account))
+	} else {
+		u, err = s.checkExpiredPassword(req)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	policy, err := s.getUserPasswordPolicy(u)
 	if err != nil {
 		return nil, err
 	}
 
-	if err := u.ChangePassword(req.OldPass, req.NewPass); err != nil {
+	if err := u.ChangePassword(req.OldPass, req.NewPass, policy); err != nil {
 		return nil, err
 	}
 
+	u.UpdateAt = ftime.Now()
 	_, err = s.col.UpdateOne(context.TODO(), bson.M{"_id": u.Account}, bson.M{"$set": bson.M{
-		"password": u.HashedPassword,
+		"password":  u.HashedPassword,
+		"update_at": u.UpdateAt,
 	}})
 
 	if err != nil {
@@ -100,6 +122,75 @@ func (s *service) UpdateAccountPassword(req *user.UpdatePasswordRequest) (*user.
 	return u.HashedPassword, nil
 }
 
+// 密码过期后无法登录获取令牌, 此时允许使用旧密码直接修改, 与登录一样受失败锁定的限制,
+// 账号不存在、密码错误以及密码未过期返回相同的错误, 防止被用于枚举账号和探测密码
+func (s *service) checkExpiredPassword(req *user.UpdatePasswordRequest) (*user.User, error) {
+	lr := lockout.NewLoginRequest(req.Account, req.IP)
+	if err := s.lockout.CheckLogin(lr); err != nil {
+		return nil, err
+	}
+
+	u, err := s.DescribeAccount(user.NewDescriptAccountRequestWithAccount(req.Account))
+	if err != nil {
+		if !exception.IsNotFoundError(err) {
+			return nil, err
+		}
+		s.recordLoginFailure(lr)
+		return nil, newExpiredPasswordError()
+	}
+
+	if err := u.HashedPassword.CheckPassword(req.OldPass); err != nil {
+		s.recordLoginFailure(lr)
+		return nil, newExpiredPasswordError()
+	}
+	if !u.HashedPassword.IsExpired() {
+		return nil, newExpiredPasswordError()
+	}
+
+	if err := s.lockout.RecordSuccess(lr); err != nil {
+		s.log.Errorf("record login success error, %s", err)
+	}
+	return u, nil
+}
+
+func (s *service) recordLoginFailure(lr *lockout.LoginRequest) {
+	if err := s.lockout.RecordFailure(lr); err != nil {
+		s.log.Errorf("record login failure error, %s", err)
+	}
+}
+
+func newExpiredPasswordError() error {
+	return exception.NewUnauthorized("account or password not correct, or password not expired")
+}
+
+func (s *service) ResetAccountPassword(account, password string) error {
+	u, err := s.DescribeAccount(user.NewDescriptAccountRequestWithAccount(account))
+	if err != nil {
+		return err
+	}
+
+	policy, err := s.getUserPasswordPolicy(u)
+	if err != nil {
+		return err
+	}
+
+	if err := u.SetPassword(password, policy); err != nil {
+		return err
+	}
+
+	u.UpdateAt = ftime.Now()
+	_, err = s.col.UpdateOne(context.TODO(), bson.M{"_id": u.Account}, bson.M{"$set": bson.M{
+		"password":  u.HashedPassword,
+		"update_at": u.UpdateAt,
+	}})
+	if err != nil {
+		return exception.NewInternalServerError("reset user(%s) password error, %s", u.Account, err)
+	}
+
+	// 密码可能已经泄露, 重置后撤销已颁发的令牌
+	return s.revolkAccountToken(u.Account)
+}
+
 func (s *service) DescribeAccount(req *user.DescriptAccountRequest) (*user.User, error) {
 	r, err := newDescribeRequest(req)
 	if err != nil {
@@ -126,7 +217,59 @@ func (s *service) BlockAccount(account, reason string) error {
 	}
 
 	user.Block(reason)
-	return s.saveAccount(user)
+	if err := s.saveAccount(user); err != nil {
+		return err
+	}
+
+	// 禁用后立即撤销该用户已颁发的令牌
+	return s.revolkAccountToken(account)
+}
+
+func (s *service) LockAccount(account, reason string, duration time.Duration) error {
+	u, err := s.DescribeAccount(user.NewDescriptAccountRequestWithAccount(account))
+	if err != nil {
+		return err
+	}
+
+	u.Lock(reason, duration)
+	return s.updateStatus(u)
+}
+
+func (s *service) UnlockAccount(account string) error {
+	u, err := s.DescribeAccount(user.NewDescriptAccountRequestWithAccount(account))
+	if err != nil {
+		return err
+	}
+
+	// 没有解冻时间的是管理员禁用, 不能通过解除冻结恢复
+	if !u.Status.Locked || u.Status.UnLockTime.Timestamp() <= 0 {
+		return nil
+	}
+
+	// 只更新仍处于临时冻结的账号, 防止覆盖并发的禁用
+	unlockTime := u.Status.UnLockTime
+	u.Status.Unlock()
+	_, err = s.col.UpdateOne(context.TODO(), bson.M{
+		"_id":                u.Account,
+		"status.locked":      true,
+		"status.unlock_time": unlockTime,
+	}, bson.M{"$set": bson.M{"status": u.Status}})
+	if err != nil {
+		return exception.NewInternalServerError("unlock user(%s) error, %s", u.Account, err)
+	}
+
+	return nil
+}
+
+func (s *service) updateStatus(u *user.User) error {
+	_, err := s.col.UpdateOne(context.TODO(), bson.M{"_id": u.Account}, bson.M{"$set": bson.M{
+		"status": u.Status,
+	}})
+	if err != nil {
+		return exception.NewInternalServerError("update user(%s) status error, %s", u.Account, err)
+	}
+
+	return nil
 }
 
 func (s *service) DeleteAccount(account string) error {
@@ -134,5 +277,18 @@ func (s *service) DeleteAccount(account string) error {
 	if err != nil {
 		return exception.NewInternalServerError("delete user(%s) error, %s", account, err)
 	}
+
+	return s.revolkAccountToken(account)
+}
+
+func (s *service) revolkAccountToken(account string) error {
+	req := token.NewBatchRevolkTokenRequest()
+	req.Account = account
+	count, err := s.token.BatchRevolkToken(req)
+	if err != nil {
+		return fmt.Errorf("revolk account %s token error, %s", account, err)
+	}
+
+	s.log.Debugf("revolk account %s %d tokens", account, count)
 	return nil
 }
@@ -0,0 +1,272 @@
+package mongo
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"time"
+
+	"github.com/infraboard/mcube/exception"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/infraboard/keyauth/conf"
+	"github.com/infraboard/keyauth/pkg/token"
+	"github.com/infraboard/keyauth/pkg/user"
+	"github.com/infraboard/keyauth/pkg/user/webauthn"
+)
+
+// webauthnChallenge 缓存中的挑战, 内存缓存不支持过期, 需要记录过期时间
+type webauthnChallenge struct {
+	Account   string `json:"account"`
+	Challenge string `json:"challenge"`
+	ExpiredAt int64  `json:"expired_at"`
+}
+
+func registrationChallengeKey(account string) string {
+	return "user.webauthn.registration." + account
+}
+
+// 登录不需要认证, 挑战使用随机ID, 按账号保存会被他人覆盖导致无法登录
+func loginChallengeKey(id string) string {
+	return "user.webauthn.login." + id
+}
+
+func (s *service) BeginWebAuthnRegistration(req *user.BeginWebAuthnRegistrationRequest) (*webauthn.CreationOptions, error) {
+	if err := req.Validate(); err != nil {
+		return nil, exception.NewBadRequest("check begin webauthn registration request error, %s", err)
+	}
+
+	u, err := s.DescribeAccount(user.NewDescriptAccountRequestWithAccount(req.GetToken().Account))
+	if err != nil {
+		return nil, err
+	}
+
+	challenge, err := s.newWebAuthnChallenge(registrationChallengeKey(u.Account), u.Account)
+	if err != nil {
+		return nil, err
+	}
+
+	displayName := ""
+	if u.Profile != nil {
+		displayName = u.RealName
+	}
+	return s.rp.NewCreationOptions(challenge, u.Account, displayName, u.WebAuthnCredentials), nil
+}
+
+func (s *service) FinishWebAuthnRegistration(req *user.FinishWebAuthnRegistrationRequest) (*webauthn.Credential, error) {
+	if err := req.Validate(); err != nil {
+		return nil, exception.NewBadRequest("check finish webauthn registration request error, %s", err)
+	}
+
+	u, err := s.DescribeAccount(user.NewDescriptAccountRequestWithAccount(req.GetToken().Account))
+	if err != nil {
+		return nil, err
+	}
+
+	challenge, err := s.popWebAuthnChallenge(registrationChallengeKey(u.Account), u.Account)
+	if err != nil {
+		return nil, err
+	}
+
+	cred, err := s.rp.VerifyRegistration(req.Credential, challenge)
+	if err != nil {
+		return nil, exception.NewBadRequest("verify webauthn registration error, %s", err)
+	}
+	cred.Name = req.Name
+
+	// 凭证ID全局唯一, 通过唯一索引保证, 同一个账号下通过更新条件保证
+	res, err := s.col.UpdateOne(context.TODO(),
+		bson.M{"_id": u.Account, "webauthn_credentials.id": bson.M{"$ne": cred.ID}},
+		bson.M{"$push": bson.M{"webauthn_credentials": cred}},
+	)
+	if err != nil {
+		if isDuplicateKeyError(err) {
+			return nil, exception.NewBadRequest("webauthn credential %s already registered", cred.ID)
+		}
+		return nil, exception.NewInternalServerError("add user(%s) webauthn credential error, %s", u.Account, err)
+	}
+	if res.MatchedCount == 0 {
+		return nil, exception.NewBadRequest("webauthn credential %s already registered", cred.ID)
+	}
+
+	return cred, nil
+}
+
+func (s *service) DeleteWebAuthnCredential(req *user.DeleteWebAuthnCredentialRequest) error {
+	if err := req.Validate(); err != nil {
+		return exception.NewBadRequest("check delete webauthn credential request error, %s", err)
+	}
+
+	u, err := s.DescribeAccount(user.NewDescriptAccountRequestWithAccount(req.GetToken().Account))
+	if err != nil {
+		return err
+	}
+
+	if u.GetWebAuthnCredential(req.ID) == nil {
+		return exception.NewNotFound("webauthn credential %s not found", req.ID)
+	}
+
+	_, err = s.col.UpdateOne(context.TODO(), bson.M{"_id": u.Account}, bson.M{
+		"$pull": bson.M{"webauthn_credentials": bson.M{"id": req.ID}},
+	})
+	if err != nil {
+		return exception.NewInternalServerError("delete user(%s) webauthn credential error, %s", u.Account, err)
+	}
+
+	return nil
+}
+
+func (s *service) BeginWebAuthnLogin(req *user.BeginWebAuthnLoginRequest) (*user.WebAuthnLoginSession, error) {
+	if err := req.Validate(); err != nil {
+		return nil, exception.NewBadRequest("check begin webauthn login request error, %s", err)
+	}
+
+	// 账号不存在或者没有凭证时返回相同格式的响应, 防止被用于枚举账号
+	var creds []*webauthn.Credential
+	u, err := s.DescribeAccount(user.NewDescriptAccountRequestWithAccount(req.Account))
+	switch {
+	case err == nil && len(u.WebAuthnCredentials) > 0:
+		creds = u.WebAuthnCredentials
+	case err == nil || exception.IsNotFoundError(err):
+		creds = []*webauthn.Credential{s.fakeWebAuthnCredential(req.Account)}
+	default:
+		return nil, err
+	}
+
+	id := token.MakeBearer(32)
+	challenge, err := s.newWebAuthnChallenge(loginChallengeKey(id), req.Account)
+	if err != nil {
+		return nil, err
+	}
+
+	return &user.WebAuthnLoginSession{
+		ChallengeID: id,
+		Options:     s.rp.NewRequestOptions(challenge, creds),
+	}, nil
+}
+
+func (s *service) VerifyWebAuthnLogin(req *user.VerifyWebAuthnLoginRequest) (*user.User, error) {
+	if err := req.Validate(); err != nil {
+		return nil, exception.NewBadRequest("check verify webauthn login request error, %s", err)
+	}
+
+	// 挑战只能使用一次, 校验失败也需要重新获取
+	challenge, err := s.popWebAuthnChallenge(loginChallengeKey(req.ChallengeID), req.Account)
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := s.DescribeAccount(user.NewDescriptAccountRequestWithAccount(req.Account))
+	if err != nil {
+		return nil, err
+	}
+
+	cred := u.GetWebAuthnCredential(req.Assertion.ID)
+	if cred == nil {
+		return nil, exception.NewUnauthorized("webauthn credential %s not registered", req.Assertion.ID)
+	}
+
+	// 可发现凭证会返回注册时的用户ID
+	if resp := req.Assertion.Response; resp != nil && resp.UserHandle != "" {
+		if resp.UserHandle != base64.RawURLEncoding.EncodeToString([]byte(u.Account)) {
+			return nil, exception.NewUnauthorized("webauthn user handle not match")
+		}
+	}
+
+	if err := s.rp.VerifyAssertion(req.Assertion, challenge, cred); err != nil {
+		return nil, exception.NewUnauthorized("verify webauthn assertion error, %s", err)
+	}
+
+	// 记录签名计数器和使用时间
+	if err := s.updateWebAuthnSignCount(u.Account, cred); err != nil {
+		return nil, err
+	}
+
+	return u, nil
+}
+
+func (s *service) newWebAuthnChallenge(key, account string) (string, error) {
+	challenge, err := webauthn.NewChallenge()
+	if err != nil {
+		return "", exception.NewInternalServerError(err.Error())
+	}
+
+	c := &webauthnChallenge{
+		Account:   account,
+		Challenge: challenge,
+		ExpiredAt: time.Now().Add(webauthn.DefaultTimeout).Unix(),
+	}
+	if err := s.cache.PutWithTTL(key, c, webauthn.DefaultTimeout); err != nil {
+		return "", exception.NewInternalServerError("save webauthn challenge error, %s", err)
+	}
+
+	return challenge, nil
+}
+
+func (s *service) popWebAuthnChallenge(key, account string) (string, error) {
+	c := &webauthnChallenge{}
+	if err := s.cache.Get(key, c); err != nil || c.Challenge == "" || time.Now().Unix() > c.ExpiredAt {
+		return "", exception.NewBadRequest("webauthn challenge not found or expired")
+	}
+
+	if err := s.cache.Delete(key); err != nil {
+		return "", exception.NewInternalServerError("delete webauthn challenge error, %s", err)
+	}
+
+	if c.Account != account {
+		return "", exception.NewBadRequest("webauthn challenge not issued to %s", account)
+	}
+
+	return c.Challenge, nil
+}
+
+// fakeWebAuthnCredential 账号不存在时返回的凭证, 同一个账号每次返回相同的凭证ID
+func (s *service) fakeWebAuthnCredential(account string) *webauthn.Credential {
+	mac := hmac.New(sha256.New, []byte(conf.C().App.Key))
+	mac.Write([]byte("webauthn:" + account))
+	return &webauthn.Credential{ID: base64.RawURLEncoding.EncodeToString(mac.Sum(nil))}
+}
+
+// updateWebAuthnSignCount 只更新使用的凭证, 计数器必须递增, 并发使用同一个计数值时只有一个成功
+func (s *service) updateWebAuthnSignCount(account string, cred *webauthn.Credential) error {
+	elem := bson.M{"id": cred.ID}
+	if cred.SignCount > 0 {
+		elem["sign_count"] = bson.M{"$lt": cred.SignCount}
+	}
+
+	res, err := s.col.UpdateOne(context.TODO(),
+		bson.M{"_id": account, "webauthn_credentials": bson.M{"$elemMatch": elem}},
+		bson.M{"$set": bson.M{
+			"webauthn_credentials.$.sign_count":   cred.SignCount,
+			"webauthn_credentials.$.last_used_at": cred.LastUsedAt,
+		}},
+	)
+	if err != nil {
+		return exception.NewInternalServerError("update user(%s) webauthn credential error, %s", account, err)
+	}
+	if res.MatchedCount == 0 {
+		return exception.NewUnauthorized("webauthn sign count not greater than stored, authenticator may be cloned")
+	}
+
+	return nil
+}
+
+// 唯一索引冲突的错误码
+const duplicateKeyErrorCode = 11000
+
+func isDuplicateKeyError(err error) bool {
+	we, ok := err.(mongo.WriteException)
+	if !ok {
+		return false
+	}
+
+	for i := range we.WriteErrors {
+		if we.WriteErrors[i].Code == duplicateKeyErrorCode {
+			return true
+		}
+	}
+
+	return false
+}
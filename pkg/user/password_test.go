@@ -0,0 +1,45 @@
+package user_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/infraboard/keyauth/pkg/domain"
+	"github.com/infraboard/keyauth/pkg/token"
+	"github.com/infraboard/keyauth/pkg/user"
+	"github.com/infraboard/keyauth/pkg/user/types"
+)
+
+func TestChangePasswordWithPolicy(t *testing.T) {
+	should := require.New(t)
+
+	policy := domain.NewDefaultPasswordPolicy()
+	policy.HistoryCount = 1
+	policy.MaxAgeDays = 30
+
+	req := user.NewCreateUserRequest()
+	req.WithToken(&token.Token{UserType: types.SupperAccount})
+	req.Account = "alice"
+	req.Password = "short"
+	_, err := user.New(req, policy)
+	should.Error(err)
+
+	req.Password = "password-1"
+	u, err := user.New(req, policy)
+	should.NoError(err)
+	should.False(u.HashedPassword.IsExpired())
+
+	should.Error(u.ChangePassword("password-1", "password-1", policy))
+	should.NoError(u.ChangePassword("password-1", "password-2", policy))
+	// 最近1次的密码不能重复使用
+	should.Error(u.ChangePassword("password-2", "password-1", policy))
+	should.Len(u.HashedPassword.History, 1)
+
+	u.HashedPassword.SetExpireAt(-time.Hour)
+	should.False(u.HashedPassword.IsExpired())
+	u.HashedPassword.SetExpireAt(time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	should.True(u.HashedPassword.IsExpired())
+}
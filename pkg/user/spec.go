@@ -5,11 +5,13 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/infraboard/mcube/http/request"
 
 	"github.com/infraboard/keyauth/pkg/token"
 	"github.com/infraboard/keyauth/pkg/user/types"
+	"github.com/infraboard/keyauth/pkg/user/webauthn"
 )
 
 // Service 用户服务
@@ -22,11 +24,27 @@ type Service interface {
 	DescribeAccount(req *DescriptAccountRequest) (*User, error)
 	// 警用账号
 	BlockAccount(account, reason string) error
+	// 临时冻结账号和解除临时冻结, 禁用的账号不会被解除
+	LockAccount(account, reason string, duration time.Duration) error
+	UnlockAccount(account string) error
 	// DeleteAccount 删除用户
 	DeleteAccount(account string) error
 	// 更新用户
 	UpdateAccountProfile(*UpdateAccountRequest) (*User, error)
 	UpdateAccountPassword(*UpdatePasswordRequest) (*Password, error)
+	// 找回密码, 身份已经通过验证码校验, 不需要旧密码
+	ResetAccountPassword(account, password string) error
+	// 多因素认证
+	SetupMFA(*SetupMFARequest) (*MFASetup, error)
+	ConfirmMFA(*ConfirmMFARequest) (*RecoveryCodeSet, error)
+	DisableMFA(*DisableMFARequest) error
+	VerifyMFA(*VerifyMFARequest) error
+	// WebAuthn(Passkey)凭证
+	BeginWebAuthnRegistration(*BeginWebAuthnRegistrationRequest) (*webauthn.CreationOptions, error)
+	FinishWebAuthnRegistration(*FinishWebAuthnRegistrationRequest) (*webauthn.Credential, error)
+	DeleteWebAuthnCredential(*DeleteWebAuthnCredentialRequest) error
+	BeginWebAuthnLogin(*BeginWebAuthnLoginRequest) (*WebAuthnLoginSession, error)
+	VerifyWebAuthnLogin(*VerifyWebAuthnLoginRequest) (*User, error)
 }
 
 // NewDescriptAccountRequest 查询详情请求
@@ -136,15 +154,22 @@ func NewUpdatePasswordRequest() *UpdatePasswordRequest {
 // UpdatePasswordRequest todo
 type UpdatePasswordRequest struct {
 	*token.Session `json:"-"`
+	Account        string `json:"account,omitempty"` // 没有令牌时(密码已过期)需要指定账号
 	OldPass        string `json:"old_pass,omitempty"`
 	NewPass        string `json:"new_pass,omitempty"`
+	IP             string `json:"-"` // 没有令牌时按来源IP统计失败次数
+}
+
+// WithRemoteIPFromHTTP 用于没有令牌时按来源IP锁定
+func (req *UpdatePasswordRequest) WithRemoteIPFromHTTP(r *http.Request) {
+	req.IP = token.GetRemoteIPFromHTTP(r)
 }
 
 // Validate tood
 func (req *UpdatePasswordRequest) Validate() error {
 	tk := req.GetToken()
-	if tk == nil {
-		return fmt.Errorf("token required")
+	if tk == nil && req.Account == "" {
+		return fmt.Errorf("token or account required")
 	}
 
 	if req.OldPass == req.NewPass {
@@ -157,3 +182,199 @@ func (req *UpdatePasswordRequest) Validate() error {
 
 	return nil
 }
+
+// NewSetupMFARequest todo
+func NewSetupMFARequest() *SetupMFARequest {
+	return &SetupMFARequest{
+		Session: token.NewSession(),
+	}
+}
+
+// SetupMFARequest 为当前用户生成待确认的TOTP密钥
+type SetupMFARequest struct {
+	*token.Session `json:"-"`
+	Issuer         string `json:"-"`
+	CurrentCode    string `json:"current_code" validate:"lte=20"` // 已启用时需要提供当前的动态码或者恢复码
+}
+
+// Validate todo
+func (req *SetupMFARequest) Validate() error {
+	if req.GetToken() == nil {
+		return fmt.Errorf("token required")
+	}
+
+	return validate.Struct(req)
+}
+
+// NewConfirmMFARequest todo
+func NewConfirmMFARequest() *ConfirmMFARequest {
+	return &ConfirmMFARequest{
+		Session: token.NewSession(),
+	}
+}
+
+// ConfirmMFARequest 使用认证器上的动态码确认绑定
+type ConfirmMFARequest struct {
+	*token.Session `json:"-"`
+	Code           string `json:"code" validate:"required,lte=20"`
+	CurrentCode    string `json:"current_code" validate:"lte=20"` // 已启用时需要提供当前的动态码或者恢复码
+}
+
+// Validate todo
+func (req *ConfirmMFARequest) Validate() error {
+	if req.GetToken() == nil {
+		return fmt.Errorf("token required")
+	}
+
+	return validate.Struct(req)
+}
+
+// NewDisableMFARequest todo
+func NewDisableMFARequest() *DisableMFARequest {
+	return &DisableMFARequest{
+		Session: token.NewSession(),
+	}
+}
+
+// DisableMFARequest 关闭多因素认证, 需要提供动态码或者恢复码
+type DisableMFARequest struct {
+	*token.Session `json:"-"`
+	Code           string `json:"code" validate:"required,lte=20"`
+}
+
+// Validate todo
+func (req *DisableMFARequest) Validate() error {
+	if req.GetToken() == nil {
+		return fmt.Errorf("token required")
+	}
+
+	return validate.Struct(req)
+}
+
+// NewVerifyMFARequest todo
+func NewVerifyMFARequest(account, code string) *VerifyMFARequest {
+	return &VerifyMFARequest{
+		Account: account,
+		Code:    code,
+	}
+}
+
+// VerifyMFARequest 登录时校验用户的动态码或者恢复码
+type VerifyMFARequest struct {
+	Account string `json:"account" validate:"required,lte=60"`
+	Code    string `json:"code" validate:"required,lte=20"`
+}
+
+// Validate todo
+func (req *VerifyMFARequest) Validate() error {
+	return validate.Struct(req)
+}
+
+// NewBeginWebAuthnRegistrationRequest todo
+func NewBeginWebAuthnRegistrationRequest() *BeginWebAuthnRegistrationRequest {
+	return &BeginWebAuthnRegistrationRequest{
+		Session: token.NewSession(),
+	}
+}
+
+// BeginWebAuthnRegistrationRequest 为当前用户生成注册凭证的挑战
+type BeginWebAuthnRegistrationRequest struct {
+	*token.Session `json:"-"`
+}
+
+// Validate todo
+func (req *BeginWebAuthnRegistrationRequest) Validate() error {
+	if req.GetToken() == nil {
+		return fmt.Errorf("token required")
+	}
+
+	return nil
+}
+
+// NewFinishWebAuthnRegistrationRequest todo
+func NewFinishWebAuthnRegistrationRequest() *FinishWebAuthnRegistrationRequest {
+	return &FinishWebAuthnRegistrationRequest{
+		Session: token.NewSession(),
+	}
+}
+
+// FinishWebAuthnRegistrationRequest 提交认证器创建的凭证
+type FinishWebAuthnRegistrationRequest struct {
+	*token.Session `json:"-"`
+	Name           string                         `json:"name" validate:"lte=60"`
+	Credential     *webauthn.RegistrationResponse `json:"credential" validate:"required"`
+}
+
+// Validate todo
+func (req *FinishWebAuthnRegistrationRequest) Validate() error {
+	if req.GetToken() == nil {
+		return fmt.Errorf("token required")
+	}
+
+	return validate.Struct(req)
+}
+
+// NewDeleteWebAuthnCredentialRequest todo
+func NewDeleteWebAuthnCredentialRequest(id string) *DeleteWebAuthnCredentialRequest {
+	return &DeleteWebAuthnCredentialRequest{
+		Session: token.NewSession(),
+		ID:      id,
+	}
+}
+
+// DeleteWebAuthnCredentialRequest 删除当前用户的凭证
+type DeleteWebAuthnCredentialRequest struct {
+	*token.Session `json:"-"`
+	ID             string `json:"id" validate:"required,lte=1024"`
+}
+
+// Validate todo
+func (req *DeleteWebAuthnCredentialRequest) Validate() error {
+	if req.GetToken() == nil {
+		return fmt.Errorf("token required")
+	}
+
+	return validate.Struct(req)
+}
+
+// NewBeginWebAuthnLoginRequest todo
+func NewBeginWebAuthnLoginRequest() *BeginWebAuthnLoginRequest {
+	return &BeginWebAuthnLoginRequest{}
+}
+
+// BeginWebAuthnLoginRequest 登录前获取断言挑战, 不需要认证
+type BeginWebAuthnLoginRequest struct {
+	Account string `json:"account" validate:"required,lte=60"`
+}
+
+// Validate todo
+func (req *BeginWebAuthnLoginRequest) Validate() error {
+	return validate.Struct(req)
+}
+
+// WebAuthnLoginSession 登录断言的参数, 每次获取都是独立的挑战, 获取令牌时通过challenge_id提交
+type WebAuthnLoginSession struct {
+	ChallengeID string                   `json:"challenge_id"`
+	Options     *webauthn.RequestOptions `json:"options"`
+}
+
+// NewVerifyWebAuthnLoginRequest todo
+func NewVerifyWebAuthnLoginRequest(account, challengeID string, assertion *webauthn.AssertionResponse) *VerifyWebAuthnLoginRequest {
+	return &VerifyWebAuthnLoginRequest{
+		Account:     account,
+		ChallengeID: challengeID,
+		Assertion:   assertion,
+	}
+}
+
+// VerifyWebAuthnLoginRequest 登录时校验认证器返回的断言
+type VerifyWebAuthnLoginRequest struct {
+	Account     string                      `json:"account" validate:"required,lte=60"`
+	ChallengeID string                      `json:"challenge_id" validate:"required,lte=80"`
+	Assertion   *webauthn.AssertionResponse `json:"assertion" validate:"required"`
+}
+
+// Validate todo
+func (req *VerifyWebAuthnLoginRequest) Validate() error {
+	return validate.Struct(req)
+}
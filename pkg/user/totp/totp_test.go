@@ -0,0 +1,62 @@
+package totp_test
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/infraboard/keyauth/pkg/user/totp"
+)
+
+// https://tools.ietf.org/html/rfc6238#appendix-B, 取8位结果的后6位
+func TestGenerateCode(t *testing.T) {
+	should := require.New(t)
+
+	secret := base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+	cases := map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1111111111: "050471",
+		1234567890: "005924",
+		2000000000: "279037",
+	}
+
+	for ts, expect := range cases {
+		code, err := totp.GenerateCode(secret, time.Unix(ts, 0))
+		should.NoError(err)
+		should.Equal(expect, code, "timestamp %d", ts)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	should := require.New(t)
+
+	secret, err := totp.GenerateSecret()
+	should.NoError(err)
+
+	now := time.Now()
+	code, err := totp.GenerateCode(secret, now.Add(-totp.Period*time.Second))
+	should.NoError(err)
+
+	step, ok := totp.Validate(secret, code, now)
+	should.True(ok)
+	should.Equal(totp.Step(now)-1, step)
+
+	_, ok = totp.Validate(secret, code, now.Add(3*totp.Period*time.Second))
+	should.False(ok)
+
+	_, ok = totp.Validate(secret, "12345", now)
+	should.False(ok)
+}
+
+func TestURI(t *testing.T) {
+	should := require.New(t)
+
+	uri := totp.URI("keyauth", "admin", "JBSWY3DPEHPK3PXP")
+	should.True(strings.HasPrefix(uri, "otpauth://totp/keyauth:admin?"))
+	should.Contains(uri, "secret=JBSWY3DPEHPK3PXP")
+	should.Contains(uri, "issuer=keyauth")
+}
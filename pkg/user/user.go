@@ -3,6 +3,7 @@ package user
 import (
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/infraboard/mcube/exception"
@@ -12,8 +13,10 @@ import (
 
 	common "github.com/infraboard/keyauth/common/types"
 	"github.com/infraboard/keyauth/pkg/department"
+	"github.com/infraboard/keyauth/pkg/domain"
 	"github.com/infraboard/keyauth/pkg/token"
 	"github.com/infraboard/keyauth/pkg/user/types"
+	"github.com/infraboard/keyauth/pkg/user/webauthn"
 )
 
 // use a single instance of Validate, it caches struct info
@@ -21,16 +24,21 @@ var (
 	validate = validator.New()
 )
 
-// New 实例
-func New(req *CreateAccountRequest) (*User, error) {
+// New 实例, 密码需要满足用户所在域的密码策略
+func New(req *CreateAccountRequest, policy *domain.PasswordPolicy) (*User, error) {
 	if err := req.Validate(); err != nil {
 		return nil, exception.NewBadRequest(err.Error())
 	}
 
+	if err := policy.Check(req.Password); err != nil {
+		return nil, exception.NewBadRequest(err.Error())
+	}
+
 	pass, err := NewHashedPassword(req.Password)
 	if err != nil {
 		return nil, exception.NewBadRequest(err.Error())
 	}
+	pass.SetExpireAt(policy.MaxAge())
 
 	return &User{
 		CreateAt:             ftime.Now(),
@@ -64,7 +72,26 @@ type User struct {
 
 	HashedPassword *Password              `bson:"password" json:"password,omitempty"` // 密码相关信息
 	Status         *Status                `bson:"status" json:"status,omitempty"`     // 用户状态
+	MFA            *MFA                   `bson:"mfa" json:"mfa,omitempty"`           // 多因素认证
 	Department     *department.Department `bson:"-" json:"department,omitempty"`      // 部门
+
+	WebAuthnCredentials []*webauthn.Credential `bson:"webauthn_credentials" json:"webauthn_credentials,omitempty"` // 注册的Passkey凭证
+}
+
+// GetWebAuthnCredential 根据凭证ID获取凭证
+func (u *User) GetWebAuthnCredential(id string) *webauthn.Credential {
+	for i := range u.WebAuthnCredentials {
+		if u.WebAuthnCredentials[i].ID == id {
+			return u.WebAuthnCredentials[i]
+		}
+	}
+
+	return nil
+}
+
+// IsMFAEnabled 用户是否启用了多因素认证
+func (u *User) IsMFAEnabled() bool {
+	return u.MFA != nil && u.MFA.Enabled
 }
 
 // Block 锁用户
@@ -72,6 +99,13 @@ func (u *User) Block(reason string) {
 	u.Status.Locked = true
 	u.Status.LockedReson = reason
 	u.Status.LockedTime = ftime.Now()
+	u.Status.UnLockTime = ftime.Time{}
+}
+
+// Lock 临时冻结用户, 到达解冻时间后自动解冻
+func (u *User) Lock(reason string, duration time.Duration) {
+	u.Block(reason)
+	u.Status.UnLockTime = ftime.T(time.Now().Add(duration))
 }
 
 // Desensitize 关键数据脱敏
@@ -79,22 +113,37 @@ func (u *User) Desensitize() {
 	if u.HashedPassword != nil {
 		u.HashedPassword.Password = ""
 	}
+	if u.MFA != nil {
+		u.MFA.Desensitize()
+	}
 	return
 }
 
 // ChangePassword 修改用户密码
-func (u *User) ChangePassword(old, new string) error {
+func (u *User) ChangePassword(old, new string, policy *domain.PasswordPolicy) error {
 	// 确认旧密码
 	if err := u.HashedPassword.CheckPassword(old); err != nil {
 		return err
 	}
 
-	// 修改新密码
+	return u.SetPassword(new, policy)
+}
+
+// SetPassword 按照密码策略设置新密码, 不校验旧密码
+func (u *User) SetPassword(new string, policy *domain.PasswordPolicy) error {
+	if err := policy.Check(new); err != nil {
+		return exception.NewBadRequest(err.Error())
+	}
+	if u.HashedPassword.IsUsed(new, policy.HistoryCount) {
+		return exception.NewBadRequest("password can't be the same as the last %d passwords", policy.HistoryCount)
+	}
+
 	newPass, err := NewHashedPassword(new)
 	if err != nil {
 		return exception.NewBadRequest(err.Error())
 	}
-	u.HashedPassword.Update(newPass)
+	newPass.SetExpireAt(policy.MaxAge())
+	u.HashedPassword.Update(newPass, policy.HistoryCount)
 	return nil
 }
 
@@ -162,6 +211,25 @@ type Status struct {
 	UnLockTime  ftime.Time `bson:"unlock_time" json:"unlock_time,omitempty"`   // 解冻时间
 }
 
+// IsLocked 是否处于冻结状态, 没有解冻时间的冻结需要管理员解除
+func (s *Status) IsLocked() bool {
+	if !s.Locked {
+		return false
+	}
+	if s.UnLockTime.Timestamp() <= 0 {
+		return true
+	}
+
+	return time.Now().Before(s.UnLockTime.T())
+}
+
+// Unlock 解除冻结
+func (s *Status) Unlock() {
+	s.Locked = false
+	s.LockedReson = ""
+	s.UnLockTime = ftime.Now()
+}
+
 // NewHashedPassword 生产hash后的密码对象
 func NewHashedPassword(password string) (*Password, error) {
 	bytes, err := bcrypt.GenerateFromPassword([]byte(password), 14)
@@ -182,6 +250,42 @@ type Password struct {
 	ExpireAt ftime.Time `bson:"expire_at" json:"expire_at,omitempty" ` // 密码过期时间
 	CreateAt ftime.Time `bson:"create_at" json:"create_at,omitempty" ` // 密码创建时间
 	UpdateAt ftime.Time `bson:"update_at" json:"update_at,omitempty"`  // 密码更新时间
+	History  []string   `bson:"history" json:"-"`                      // 历史密码的hash, 最近的在前
+}
+
+// SetExpireAt 设置密码有效期, 0表示永不过期
+func (p *Password) SetExpireAt(maxAge time.Duration) {
+	if maxAge <= 0 {
+		p.ExpireAt = ftime.Time{}
+		return
+	}
+
+	p.ExpireAt = ftime.T(time.Now().Add(maxAge))
+}
+
+// IsExpired 密码是否已经过期, 过期后需要修改密码才能登录
+func (p *Password) IsExpired() bool {
+	if p.ExpireAt.Timestamp() <= 0 {
+		return false
+	}
+
+	return time.Now().After(p.ExpireAt.T())
+}
+
+// IsUsed 是否与当前密码或者最近n次的历史密码相同, 最多需要n+1次cost为14的bcrypt比对,
+// 单次比对接近1秒, 所以密码策略限制了n的上限
+func (p *Password) IsUsed(password string, n int) bool {
+	if bcrypt.CompareHashAndPassword([]byte(p.Password), []byte(password)) == nil {
+		return true
+	}
+
+	for i := 0; i < n && i < len(p.History); i++ {
+		if bcrypt.CompareHashAndPassword([]byte(p.History[i]), []byte(password)) == nil {
+			return true
+		}
+	}
+
+	return false
 }
 
 // CheckPassword 判断password 是否正确
@@ -193,9 +297,15 @@ func (p *Password) CheckPassword(password string) error {
 	return nil
 }
 
-// Update 更新密码
-func (p *Password) Update(new *Password) {
+// Update 更新密码, 保留最近historyCount次的历史密码
+func (p *Password) Update(new *Password, historyCount int) {
+	p.History = append([]string{p.Password}, p.History...)
+	if len(p.History) > historyCount {
+		p.History = p.History[:historyCount]
+	}
+
 	p.Password = new.Password
+	p.ExpireAt = new.ExpireAt
 	p.UpdateAt = ftime.Now()
 }
 
@@ -0,0 +1,339 @@
+package webauthn
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/asn1"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/infraboard/mcube/types/ftime"
+)
+
+// 认证器数据中的标志位: https://www.w3.org/TR/webauthn-2/#flags
+const (
+	flagUserPresent  byte = 0x01
+	flagUserVerified byte = 0x04
+	flagAttestedData byte = 0x40
+)
+
+const (
+	ceremonyCreate = "webauthn.create"
+	ceremonyGet    = "webauthn.get"
+)
+
+// VerifyRegistration 校验注册仪式, 通过后返回需要保存的凭证: https://www.w3.org/TR/webauthn-2/#sctn-registering-a-new-credential
+// 只使用none证明, 不校验证明声明, 即不信任认证器的型号
+func (c *Config) VerifyRegistration(resp *RegistrationResponse, challenge string) (*Credential, error) {
+	if resp == nil || resp.Response == nil {
+		return nil, errors.New("credential response required")
+	}
+	if resp.Type != PublicKeyType {
+		return nil, fmt.Errorf("unsupported credential type %s", resp.Type)
+	}
+
+	if _, err := c.verifyClientData(resp.Response.ClientDataJSON, ceremonyCreate, challenge); err != nil {
+		return nil, err
+	}
+
+	raw, err := decode(resp.Response.AttestationObject)
+	if err != nil {
+		return nil, fmt.Errorf("decode attestationObject error, %s", err)
+	}
+	att := &attestationObject{}
+	if err := cbor.Unmarshal(raw, att); err != nil {
+		return nil, fmt.Errorf("unmarshal attestationObject error, %s", err)
+	}
+
+	ad, err := parseAuthenticatorData(att.AuthData)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.checkAuthenticatorData(ad, false); err != nil {
+		return nil, err
+	}
+	if ad.Flags&flagAttestedData == 0 || len(ad.CredentialID) == 0 {
+		return nil, errors.New("attested credential data missing")
+	}
+
+	key, err := parsePublicKey(ad.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Credential{
+		ID:        encoding.EncodeToString(ad.CredentialID),
+		PublicKey: ad.PublicKey,
+		Algorithm: key.alg,
+		AAGUID:    hex.EncodeToString(ad.AAGUID),
+		SignCount: ad.SignCount,
+		CreateAt:  ftime.Now(),
+	}, nil
+}
+
+// VerifyAssertion 校验登录断言, 通过后更新凭证的签名计数器: https://www.w3.org/TR/webauthn-2/#sctn-verifying-assertion
+func (c *Config) VerifyAssertion(resp *AssertionResponse, challenge string, cred *Credential) error {
+	if resp == nil || resp.Response == nil {
+		return errors.New("assertion response required")
+	}
+	if resp.Type != PublicKeyType {
+		return fmt.Errorf("unsupported credential type %s", resp.Type)
+	}
+	if resp.ID != cred.ID {
+		return fmt.Errorf("credential %s not match", resp.ID)
+	}
+
+	clientData, err := c.verifyClientData(resp.Response.ClientDataJSON, ceremonyGet, challenge)
+	if err != nil {
+		return err
+	}
+
+	rawAuthData, err := decode(resp.Response.AuthenticatorData)
+	if err != nil {
+		return fmt.Errorf("decode authenticatorData error, %s", err)
+	}
+	ad, err := parseAuthenticatorData(rawAuthData)
+	if err != nil {
+		return err
+	}
+	if err := c.checkAuthenticatorData(ad, true); err != nil {
+		return err
+	}
+
+	sig, err := decode(resp.Response.Signature)
+	if err != nil {
+		return fmt.Errorf("decode signature error, %s", err)
+	}
+
+	key, err := parsePublicKey(cred.PublicKey)
+	if err != nil {
+		return err
+	}
+	clientDataHash := sha256.Sum256(clientData)
+	signed := make([]byte, 0, len(rawAuthData)+len(clientDataHash))
+	signed = append(signed, rawAuthData...)
+	signed = append(signed, clientDataHash[:]...)
+	if err := key.verify(signed, sig); err != nil {
+		return err
+	}
+
+	// 计数器不递增说明认证器可能被克隆, 不支持计数器的认证器始终为0
+	if ad.SignCount != 0 || cred.SignCount != 0 {
+		if ad.SignCount <= cred.SignCount {
+			return fmt.Errorf("sign count %d not greater than stored %d, authenticator may be cloned", ad.SignCount, cred.SignCount)
+		}
+	}
+
+	cred.SignCount = ad.SignCount
+	cred.LastUsedAt = ftime.Now()
+	return nil
+}
+
+type clientData struct {
+	Type      string `json:"type"`
+	Challenge string `json:"challenge"`
+	Origin    string `json:"origin"`
+}
+
+// verifyClientData 校验仪式类型, 挑战和来源, 返回原始的clientDataJSON
+func (c *Config) verifyClientData(data, ceremony, challenge string) ([]byte, error) {
+	raw, err := decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("decode clientDataJSON error, %s", err)
+	}
+
+	cd := &clientData{}
+	if err := json.Unmarshal(raw, cd); err != nil {
+		return nil, fmt.Errorf("unmarshal clientDataJSON error, %s", err)
+	}
+
+	if cd.Type != ceremony {
+		return nil, fmt.Errorf("client data type %s not %s", cd.Type, ceremony)
+	}
+	if challenge == "" || subtle.ConstantTimeCompare([]byte(cd.Challenge), []byte(challenge)) != 1 {
+		return nil, errors.New("challenge not match")
+	}
+	if !c.allowOrigin(cd.Origin) {
+		return nil, fmt.Errorf("origin %s not allowed", cd.Origin)
+	}
+
+	return raw, nil
+}
+
+// checkAuthenticatorData 校验依赖方ID以及用户在场, 登录时要求认证器校验了用户身份
+func (c *Config) checkAuthenticatorData(ad *authenticatorData, requireUV bool) error {
+	rpIDHash := sha256.Sum256([]byte(c.RPID))
+	if !bytes.Equal(ad.RPIDHash, rpIDHash[:]) {
+		return errors.New("rp id hash not match")
+	}
+	if ad.Flags&flagUserPresent == 0 {
+		return errors.New("user not present")
+	}
+	if requireUV && ad.Flags&flagUserVerified == 0 {
+		return errors.New("user not verified")
+	}
+
+	return nil
+}
+
+type attestationObject struct {
+	Fmt      string          `cbor:"fmt"`
+	AttStmt  cbor.RawMessage `cbor:"attStmt"`
+	AuthData []byte          `cbor:"authData"`
+}
+
+// authenticatorData https://www.w3.org/TR/webauthn-2/#sctn-authenticator-data
+type authenticatorData struct {
+	RPIDHash     []byte
+	Flags        byte
+	SignCount    uint32
+	AAGUID       []byte
+	CredentialID []byte
+	PublicKey    []byte
+}
+
+func parseAuthenticatorData(data []byte) (*authenticatorData, error) {
+	if len(data) < 37 {
+		return nil, fmt.Errorf("authenticator data too short: %d", len(data))
+	}
+
+	ad := &authenticatorData{
+		RPIDHash:  data[:32],
+		Flags:     data[32],
+		SignCount: binary.BigEndian.Uint32(data[33:37]),
+	}
+	if ad.Flags&flagAttestedData == 0 {
+		return ad, nil
+	}
+
+	rest := data[37:]
+	if len(rest) < 18 {
+		return nil, errors.New("attested credential data too short")
+	}
+	ad.AAGUID = rest[:16]
+	idLen := int(binary.BigEndian.Uint16(rest[16:18]))
+	rest = rest[18:]
+	if len(rest) < idLen {
+		return nil, errors.New("credential id too short")
+	}
+	ad.CredentialID = rest[:idLen]
+	rest = rest[idLen:]
+
+	// 公钥之后可能还有扩展数据, 只读取一个CBOR对象
+	dec := cbor.NewDecoder(bytes.NewReader(rest))
+	var pk cbor.RawMessage
+	if err := dec.Decode(&pk); err != nil {
+		return nil, fmt.Errorf("decode credential public key error, %s", err)
+	}
+	ad.PublicKey = rest[:dec.NumBytesRead()]
+	return ad, nil
+}
+
+// COSE Key参数: https://tools.ietf.org/html/rfc8152#section-13
+const (
+	coseKty = 1
+	coseAlg = 3
+
+	coseKtyEC2 = 2
+	coseKtyRSA = 3
+
+	coseCrvP256 = 1
+)
+
+type publicKey struct {
+	alg int64
+	key crypto.PublicKey
+}
+
+func parsePublicKey(data []byte) (*publicKey, error) {
+	m := map[int]interface{}{}
+	if err := cbor.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("unmarshal cose key error, %s", err)
+	}
+
+	kty, _ := toInt64(m[coseKty])
+	alg, _ := toInt64(m[coseAlg])
+	switch {
+	case kty == coseKtyEC2 && alg == AlgES256:
+		crv, _ := toInt64(m[-1])
+		x, _ := m[-2].([]byte)
+		y, _ := m[-3].([]byte)
+		if crv != coseCrvP256 || len(x) != 32 || len(y) != 32 {
+			return nil, errors.New("invalid ec2 public key")
+		}
+		pub := &ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}
+		if !pub.Curve.IsOnCurve(pub.X, pub.Y) {
+			return nil, errors.New("ec2 public key not on curve")
+		}
+		return &publicKey{alg: alg, key: pub}, nil
+	case kty == coseKtyRSA && alg == AlgRS256:
+		n, _ := m[-1].([]byte)
+		e, _ := m[-2].([]byte)
+		if len(n) == 0 || len(e) == 0 || len(e) > 4 {
+			return nil, errors.New("invalid rsa public key")
+		}
+		pub := &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+		return &publicKey{alg: alg, key: pub}, nil
+	default:
+		return nil, fmt.Errorf("unsupported public key, kty: %d alg: %d", kty, alg)
+	}
+}
+
+func (k *publicKey) verify(data, sig []byte) error {
+	digest := sha256.Sum256(data)
+
+	switch pub := k.key.(type) {
+	case *ecdsa.PublicKey:
+		es := struct {
+			R, S *big.Int
+		}{}
+		if _, err := asn1.Unmarshal(sig, &es); err != nil {
+			return fmt.Errorf("unmarshal ecdsa signature error, %s", err)
+		}
+		if !ecdsa.Verify(pub, digest[:], es.R, es.S) {
+			return errors.New("signature not correct")
+		}
+	case *rsa.PublicKey:
+		if err := rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], sig); err != nil {
+			return errors.New("signature not correct")
+		}
+	default:
+		return errors.New("unsupported public key")
+	}
+
+	return nil
+}
+
+func toInt64(v interface{}) (int64, bool) {
+	switch t := v.(type) {
+	case int64:
+		return t, true
+	case uint64:
+		return int64(t), true
+	default:
+		return 0, false
+	}
+}
+
+// 兼容带填充的base64url编码
+func decode(s string) ([]byte, error) {
+	return encoding.DecodeString(strings.TrimRight(s, "="))
+}
@@ -0,0 +1,204 @@
+package webauthn
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"time"
+
+	"github.com/infraboard/mcube/types/ftime"
+)
+
+const (
+	// DefaultTimeout 浏览器等待用户操作认证器的时长
+	DefaultTimeout = 5 * time.Minute
+
+	// PublicKeyType 凭证类型
+	PublicKeyType = "public-key"
+
+	challengeSize = 32
+)
+
+// 支持的COSE签名算法: https://www.iana.org/assignments/cose/cose.xhtml#algorithms
+const (
+	// AlgES256 ECDSA P-256 with SHA-256
+	AlgES256 int64 = -7
+	// AlgRS256 RSASSA-PKCS1-v1_5 with SHA-256
+	AlgRS256 int64 = -257
+)
+
+var (
+	encoding = base64.RawURLEncoding
+)
+
+// NewChallenge 生成随机挑战, 以base64url编码
+func NewChallenge() (string, error) {
+	b := make([]byte, challengeSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate challenge error, %s", err)
+	}
+
+	return encoding.EncodeToString(b), nil
+}
+
+// Config 依赖方(Relying Party)配置: https://www.w3.org/TR/webauthn-2/#relying-party
+type Config struct {
+	RPID    string   // 依赖方ID, 需要是页面所在域名或者其父域名
+	RPName  string   // 依赖方名称, 认证器上展示
+	Origins []string // 允许发起认证的页面来源
+}
+
+func (c *Config) allowOrigin(origin string) bool {
+	for i := range c.Origins {
+		if c.Origins[i] == origin {
+			return true
+		}
+	}
+
+	return false
+}
+
+// NewCreationOptions 注册凭证的参数, 已经注册的凭证不允许重复注册
+func (c *Config) NewCreationOptions(challenge, account, displayName string, exclude []*Credential) *CreationOptions {
+	opts := &CreationOptions{
+		Challenge: challenge,
+		RP:        &RelyingParty{ID: c.RPID, Name: c.RPName},
+		User: &UserEntity{
+			ID:          encoding.EncodeToString([]byte(account)),
+			Name:        account,
+			DisplayName: displayName,
+		},
+		PubKeyCredParams: []*CredentialParameter{
+			{Type: PublicKeyType, Alg: AlgES256},
+			{Type: PublicKeyType, Alg: AlgRS256},
+		},
+		Timeout: DefaultTimeout.Milliseconds(),
+		AuthenticatorSelection: &AuthenticatorSelection{
+			ResidentKey:      "preferred",
+			UserVerification: "required",
+		},
+		Attestation: "none",
+	}
+	if opts.User.DisplayName == "" {
+		opts.User.DisplayName = account
+	}
+
+	for i := range exclude {
+		opts.ExcludeCredentials = append(opts.ExcludeCredentials, exclude[i].Descriptor())
+	}
+
+	return opts
+}
+
+// NewRequestOptions 登录断言的参数, 只允许用户已注册的凭证
+func (c *Config) NewRequestOptions(challenge string, allow []*Credential) *RequestOptions {
+	opts := &RequestOptions{
+		Challenge:        challenge,
+		Timeout:          DefaultTimeout.Milliseconds(),
+		RPID:             c.RPID,
+		UserVerification: "required",
+	}
+
+	for i := range allow {
+		opts.AllowCredentials = append(opts.AllowCredentials, allow[i].Descriptor())
+	}
+
+	return opts
+}
+
+// CreationOptions https://www.w3.org/TR/webauthn-2/#dictdef-publickeycredentialcreationoptions
+type CreationOptions struct {
+	Challenge              string                  `json:"challenge"`
+	RP                     *RelyingParty           `json:"rp"`
+	User                   *UserEntity             `json:"user"`
+	PubKeyCredParams       []*CredentialParameter  `json:"pubKeyCredParams"`
+	Timeout                int64                   `json:"timeout,omitempty"`
+	ExcludeCredentials     []*CredentialDescriptor `json:"excludeCredentials,omitempty"`
+	AuthenticatorSelection *AuthenticatorSelection `json:"authenticatorSelection,omitempty"`
+	Attestation            string                  `json:"attestation,omitempty"`
+}
+
+// RequestOptions https://www.w3.org/TR/webauthn-2/#dictdef-publickeycredentialrequestoptions
+type RequestOptions struct {
+	Challenge        string                  `json:"challenge"`
+	Timeout          int64                   `json:"timeout,omitempty"`
+	RPID             string                  `json:"rpId,omitempty"`
+	AllowCredentials []*CredentialDescriptor `json:"allowCredentials,omitempty"`
+	UserVerification string                  `json:"userVerification,omitempty"`
+}
+
+// RelyingParty todo
+type RelyingParty struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// UserEntity 用户ID为账号的base64url编码
+type UserEntity struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+}
+
+// CredentialParameter todo
+type CredentialParameter struct {
+	Type string `json:"type"`
+	Alg  int64  `json:"alg"`
+}
+
+// CredentialDescriptor todo
+type CredentialDescriptor struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+// AuthenticatorSelection todo
+type AuthenticatorSelection struct {
+	ResidentKey      string `json:"residentKey,omitempty"`
+	UserVerification string `json:"userVerification,omitempty"`
+}
+
+// Credential 用户注册的凭证, 只保存公钥
+type Credential struct {
+	ID         string     `bson:"id" json:"id"`                               // 凭证ID, base64url编码
+	Name       string     `bson:"name" json:"name,omitempty"`                 // 凭证名称, 方便用户区分
+	PublicKey  []byte     `bson:"public_key" json:"-"`                        // COSE格式的公钥
+	Algorithm  int64      `bson:"algorithm" json:"algorithm"`                 // 签名算法
+	AAGUID     string     `bson:"aaguid" json:"aaguid,omitempty"`             // 认证器型号
+	SignCount  uint32     `bson:"sign_count" json:"-"`                        // 签名计数器, 用于发现克隆的认证器
+	CreateAt   ftime.Time `bson:"create_at" json:"create_at,omitempty"`       // 注册时间
+	LastUsedAt ftime.Time `bson:"last_used_at" json:"last_used_at,omitempty"` // 最近一次使用时间
+}
+
+// Descriptor todo
+func (c *Credential) Descriptor() *CredentialDescriptor {
+	return &CredentialDescriptor{Type: PublicKeyType, ID: c.ID}
+}
+
+// RegistrationResponse navigator.credentials.create()返回的凭证, 二进制字段使用base64url编码
+type RegistrationResponse struct {
+	ID       string                   `json:"id"`
+	Type     string                   `json:"type"`
+	Response *AttestationResponseData `json:"response"`
+}
+
+// AttestationResponseData https://www.w3.org/TR/webauthn-2/#authenticatorattestationresponse
+type AttestationResponseData struct {
+	ClientDataJSON    string `json:"clientDataJSON"`
+	AttestationObject string `json:"attestationObject"`
+}
+
+// AssertionResponse navigator.credentials.get()返回的凭证, 二进制字段使用base64url编码
+type AssertionResponse struct {
+	ID       string                 `json:"id"`
+	Type     string                 `json:"type"`
+	Response *AssertionResponseData `json:"response"`
+}
+
+// AssertionResponseData https://www.w3.org/TR/webauthn-2/#authenticatorassertionresponse
+type AssertionResponseData struct {
+	ClientDataJSON    string `json:"clientDataJSON"`
+	AuthenticatorData string `json:"authenticatorData"`
+	Signature         string `json:"signature"`
+	UserHandle        string `json:"userHandle,omitempty"`
+}
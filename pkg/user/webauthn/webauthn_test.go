@@ -0,0 +1,209 @@
+package webauthn_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/stretchr/testify/require"
+
+	"github.com/infraboard/keyauth/pkg/user/webauthn"
+)
+
+var (
+	b64 = base64.RawURLEncoding
+)
+
+// authenticator 软件模拟的认证器, 使用P-256密钥
+type authenticator struct {
+	rpID      string
+	origin    string
+	id        []byte
+	key       *ecdsa.PrivateKey
+	signCount uint32
+	flags     byte
+}
+
+func newAuthenticator(t *testing.T, rpID, origin string) *authenticator {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	id := make([]byte, 16)
+	rand.Read(id)
+	return &authenticator{
+		rpID:   rpID,
+		origin: origin,
+		id:     id,
+		key:    key,
+		flags:  0x01 | 0x04, // UP | UV
+	}
+}
+
+func (a *authenticator) clientData(typ, challenge string) []byte {
+	b, _ := json.Marshal(map[string]string{
+		"type":      typ,
+		"challenge": challenge,
+		"origin":    a.origin,
+	})
+	return b
+}
+
+func (a *authenticator) authData(flags byte, attested []byte) []byte {
+	rpIDHash := sha256.Sum256([]byte(a.rpID))
+	data := append([]byte{}, rpIDHash[:]...)
+	data = append(data, flags)
+	counter := make([]byte, 4)
+	binary.BigEndian.PutUint32(counter, a.signCount)
+	data = append(data, counter...)
+	return append(data, attested...)
+}
+
+func (a *authenticator) create(t *testing.T, challenge string) *webauthn.RegistrationResponse {
+	pub, err := cbor.Marshal(map[int]interface{}{
+		1:  2,
+		3:  -7,
+		-1: 1,
+		-2: padTo32(a.key.X.Bytes()),
+		-3: padTo32(a.key.Y.Bytes()),
+	})
+	require.NoError(t, err)
+
+	attested := make([]byte, 16)
+	idLen := make([]byte, 2)
+	binary.BigEndian.PutUint16(idLen, uint16(len(a.id)))
+	attested = append(attested, idLen...)
+	attested = append(attested, a.id...)
+	attested = append(attested, pub...)
+
+	att, err := cbor.Marshal(map[string]interface{}{
+		"fmt":      "none",
+		"attStmt":  map[string]interface{}{},
+		"authData": a.authData(a.flags|0x40, attested),
+	})
+	require.NoError(t, err)
+
+	return &webauthn.RegistrationResponse{
+		ID:   b64.EncodeToString(a.id),
+		Type: webauthn.PublicKeyType,
+		Response: &webauthn.AttestationResponseData{
+			ClientDataJSON:    b64.EncodeToString(a.clientData("webauthn.create", challenge)),
+			AttestationObject: b64.EncodeToString(att),
+		},
+	}
+}
+
+func (a *authenticator) get(t *testing.T, challenge string) *webauthn.AssertionResponse {
+	a.signCount++
+	authData := a.authData(a.flags, nil)
+	clientData := a.clientData("webauthn.get", challenge)
+	hash := sha256.Sum256(clientData)
+	digest := sha256.Sum256(append(append([]byte{}, authData...), hash[:]...))
+
+	r, s, err := ecdsa.Sign(rand.Reader, a.key, digest[:])
+	require.NoError(t, err)
+	sig, err := asn1.Marshal(struct{ R, S *big.Int }{r, s})
+	require.NoError(t, err)
+
+	return &webauthn.AssertionResponse{
+		ID:   b64.EncodeToString(a.id),
+		Type: webauthn.PublicKeyType,
+		Response: &webauthn.AssertionResponseData{
+			ClientDataJSON:    b64.EncodeToString(clientData),
+			AuthenticatorData: b64.EncodeToString(authData),
+			Signature:         b64.EncodeToString(sig),
+		},
+	}
+}
+
+func padTo32(b []byte) []byte {
+	return append(make([]byte, 32-len(b)), b...)
+}
+
+func newConfig() *webauthn.Config {
+	return &webauthn.Config{
+		RPID:    "example.com",
+		RPName:  "keyauth",
+		Origins: []string{"https://example.com"},
+	}
+}
+
+func TestRegistrationAndAssertion(t *testing.T) {
+	should := require.New(t)
+	c := newConfig()
+	a := newAuthenticator(t, c.RPID, c.Origins[0])
+
+	challenge, err := webauthn.NewChallenge()
+	should.NoError(err)
+	opts := c.NewCreationOptions(challenge, "alice", "", nil)
+	should.Equal("alice", opts.User.DisplayName)
+
+	cred, err := c.VerifyRegistration(a.create(t, challenge), challenge)
+	should.NoError(err)
+	should.Equal(b64.EncodeToString(a.id), cred.ID)
+	should.Equal(webauthn.AlgES256, cred.Algorithm)
+
+	challenge, err = webauthn.NewChallenge()
+	should.NoError(err)
+	resp := a.get(t, challenge)
+	should.NoError(c.VerifyAssertion(resp, challenge, cred))
+	should.Equal(uint32(1), cred.SignCount)
+
+	// 重放同一个断言, 计数器没有递增
+	should.Error(c.VerifyAssertion(resp, challenge, cred))
+}
+
+func TestRegistrationRejected(t *testing.T) {
+	should := require.New(t)
+	c := newConfig()
+
+	challenge, _ := webauthn.NewChallenge()
+	other, _ := webauthn.NewChallenge()
+
+	a := newAuthenticator(t, c.RPID, c.Origins[0])
+	_, err := c.VerifyRegistration(a.create(t, other), challenge)
+	should.Error(err)
+
+	a = newAuthenticator(t, c.RPID, "https://evil.com")
+	_, err = c.VerifyRegistration(a.create(t, challenge), challenge)
+	should.Error(err)
+
+	a = newAuthenticator(t, "evil.com", c.Origins[0])
+	_, err = c.VerifyRegistration(a.create(t, challenge), challenge)
+	should.Error(err)
+}
+
+func TestAssertionRejected(t *testing.T) {
+	should := require.New(t)
+	c := newConfig()
+	a := newAuthenticator(t, c.RPID, c.Origins[0])
+
+	challenge, _ := webauthn.NewChallenge()
+	cred, err := c.VerifyRegistration(a.create(t, challenge), challenge)
+	should.NoError(err)
+
+	// 认证器没有校验用户身份
+	a.flags = 0x01
+	should.Error(c.VerifyAssertion(a.get(t, challenge), challenge, cred))
+	a.flags = 0x01 | 0x04
+
+	// 其他认证器的签名
+	b := newAuthenticator(t, c.RPID, c.Origins[0])
+	b.id = a.id
+	should.Error(c.VerifyAssertion(b.get(t, challenge), challenge, cred))
+
+	// 签名内容被篡改
+	resp := a.get(t, challenge)
+	other, _ := webauthn.NewChallenge()
+	resp.Response.ClientDataJSON = b64.EncodeToString(a.clientData("webauthn.get", other))
+	should.Error(c.VerifyAssertion(resp, other, cred))
+
+	should.NoError(c.VerifyAssertion(a.get(t, challenge), challenge, cred))
+}